@@ -1,13 +1,32 @@
 package main
 
 import (
+	"context"
 	"log"
 
+	"quizapi/internal/access"
+	"quizapi/internal/analytics"
+	"quizapi/internal/attachments"
 	"quizapi/internal/auth"
+	"quizapi/internal/bank"
+	"quizapi/internal/certificates"
+	"quizapi/internal/cohorts"
 	"quizapi/internal/config"
 	"quizapi/internal/db"
+	"quizapi/internal/events"
+	"quizapi/internal/export"
+	"quizapi/internal/integrity"
+	"quizapi/internal/leaderboard"
+	"quizapi/internal/live"
+	"quizapi/internal/lti"
 	"quizapi/internal/models"
+	"quizapi/internal/plagiarism"
+	"quizapi/internal/practice"
+	"quizapi/internal/qti"
 	"quizapi/internal/quizzes"
+	"quizapi/internal/revisions"
+	"quizapi/internal/storage"
+	"quizapi/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,20 +34,88 @@ import (
 func main() {
 	cfg := config.Load()
 	d := db.Connect(cfg.MysqlDSN)
+	store, err := storage.NewLocal(cfg.UploadDir)
+	if err != nil {
+		log.Fatalf("failed to init upload storage: %v", err)
+	}
 
 	quizsvc := quizzes.NewService(d)
 	authSvc := auth.NewService(d, cfg.JWTSecret)
+	attachSvc := attachments.NewService(d, store)
+	bankSvc := bank.NewService(d)
+	revSvc := revisions.NewService(d)
+	statsSvc := analytics.NewService(d)
+	boardSvc := leaderboard.NewService(d)
+	liveSvc := live.NewService(d)
+	exportSvc := export.NewService(d)
+	qtiSvc := qti.NewService(d)
+	certSvc := certificates.NewService(d, cfg.PublicURL)
+	cohortSvc := cohorts.NewService(d)
+	accessSvc := access.NewService(d)
+	practiceSvc := practice.NewService(d)
+	integritySvc := integrity.NewService(d)
+	plagiarismSvc := plagiarism.NewService(d)
+	hookSvc := webhooks.NewService(d)
+	ltiKeys, err := lti.LoadKeySet(cfg.LTIKeyFile)
+	if err != nil {
+		log.Fatalf("failed to load LTI key: %v", err)
+	}
+	if cfg.LTIKeyFile == "" {
+		log.Println("LTI_KEY_FILE not set; using an ephemeral LTI signing key")
+	}
+	ltiSvc := lti.NewService(d, ltiKeys, cfg.PublicURL, authSvc.IssueToken)
+
+	// Events from quiz activity fan out to every subscriber; the bus feeds
+	// the admin SSE streams
+	bus := events.NewBus(1024, 64)
+	pub := events.Multi{hookSvc, ltiSvc, bus}
+	quizsvc.SetPublisher(pub)
+	revSvc.SetPublisher(pub)
+	integritySvc.SetPublisher(pub)
+	go hookSvc.Run(context.Background())
+	go ltiSvc.Run(context.Background())
+	go plagiarismSvc.Run(context.Background())
 
 	quizH := quizzes.NewHandler(quizsvc)
 	authH := auth.NewHandler(authSvc)
+	attachH := attachments.NewHandler(attachSvc)
+	bankH := bank.NewHandler(bankSvc)
+	revH := revisions.NewHandler(revSvc)
+	statsH := analytics.NewHandler(statsSvc)
+	boardH := leaderboard.NewHandler(boardSvc)
+	liveH := live.NewHandler(liveSvc)
+	exportH := export.NewHandler(exportSvc)
+	qtiH := qti.NewHandler(qtiSvc)
+	certH := certificates.NewHandler(certSvc)
+	cohortH := cohorts.NewHandler(cohortSvc)
+	accessH := access.NewHandler(accessSvc)
+	practiceH := practice.NewHandler(practiceSvc)
+	integrityH := integrity.NewHandler(integritySvc)
+	plagiarismH := plagiarism.NewHandler(plagiarismSvc)
+	hookH := webhooks.NewHandler(hookSvc)
+	ltiH := lti.NewHandler(ltiSvc)
+	eventsH := events.NewHandler(bus)
 
-	r := gin.Default()
+	// gin.Default, but with stream tokens kept out of the access log
+	r := gin.New()
+	r.Use(auth.Logger(), gin.Recovery())
 
 	// --Public routes--
 	// Anyone can register/login, or see the list of available quizzes
+	// (signed-in users also see quizzes assigned to their cohorts)
 	r.POST("/register", authH.Register)
 	r.POST("/login", authH.Login)
-	r.GET("/quizzes", quizH.ListQuizzes)
+	r.GET("/quizzes", authSvc.OptionalAuthMiddleware(), quizH.ListQuizzes)
+	r.GET("/attachments/:attachmentID", attachH.Get)
+	r.GET("/certificates/:code", certH.Verify)
+	r.GET("/certificates/:code/pdf", certH.PDF)
+
+	// LTI 1.3 endpoints are called by the LMS and the launched browser
+	r.GET("/lti/jwks", ltiH.JWKS)
+	r.GET("/lti/login", ltiH.Login)
+	r.POST("/lti/login", ltiH.Login)
+	r.POST("/lti/launch", ltiH.Launch)
+	r.POST("/lti/deep-link", ltiH.DeepLink)
 
 	// --Authenticated routes--
 	// A user must have a valid token to access these, but any role is fine
@@ -36,7 +123,25 @@ func main() {
 	authRoutes.Use(authSvc.AuthMiddleware())
 	{
 		authRoutes.GET("/quizzes/:quizID/questions", quizH.GetQuestions)
+		authRoutes.POST("/quizzes/:quizID/access", accessH.Redeem)
 		authRoutes.POST("/quizzes/:quizID/submit", quizH.Submit)
+		authRoutes.GET("/quizzes/:quizID/adaptive", quizH.NextAdaptive)
+		authRoutes.POST("/quizzes/:quizID/adaptive/answer", quizH.AnswerAdaptive)
+		authRoutes.GET("/attempts/:attemptID", quizH.GetAttempt)
+		authRoutes.PUT("/attempts/:attemptID/answers/:questionID", quizH.SaveAnswer)
+		authRoutes.DELETE("/attempts/:attemptID/answers/:questionID", quizH.DiscardAnswer)
+		authRoutes.POST("/attempts/:attemptID/submit", quizH.FinalizeAttempt)
+		authRoutes.POST("/attempts/:attemptID/integrity-events", integrityH.Report)
+		authRoutes.GET("/quizzes/:quizID/leaderboard", boardH.QuizBoard)
+		authRoutes.GET("/quizzes/:quizID/leaderboard/me", boardH.MyQuizRank)
+		authRoutes.GET("/leaderboard", boardH.GlobalBoard)
+		authRoutes.GET("/leaderboard/me", boardH.MyGlobalRank)
+		authRoutes.PUT("/me/leaderboard-privacy", boardH.SetPrivacy)
+		authRoutes.GET("/me/certificates", certH.Mine)
+		authRoutes.GET("/me/assignments", cohortH.Mine)
+		authRoutes.GET("/me/practice/next", practiceH.Next)
+		authRoutes.POST("/me/practice/:questionID/answer", practiceH.Answer)
+		authRoutes.GET("/live/sessions/:pin/join", liveH.Join)
 	}
 	// --Admin-Only routes--
 	// A user must have a valid token and the "admin" role to access these
@@ -44,7 +149,77 @@ func main() {
 	adminRoutes.Use(authSvc.AuthMiddleware(), auth.RoleMiddleware(models.RoleAdmin))
 	{
 		adminRoutes.POST("/quizzes", quizH.CreateQuiz)
+		adminRoutes.POST("/quizzes/:quizID/publish", quizH.PublishQuiz)
+		adminRoutes.GET("/quizzes/:quizID/events", eventsH.Stream)
+		adminRoutes.PUT("/quizzes/:quizID/schedule", quizH.SetSchedule)
+		adminRoutes.PUT("/quizzes/:quizID/adaptive", quizH.SetAdaptive)
+		adminRoutes.PUT("/quizzes/:quizID/marking", quizH.SetMarking)
+		adminRoutes.PUT("/quizzes/:quizID/submission-policy", quizH.SetSubmissionPolicy)
+		adminRoutes.GET("/quizzes/:quizID/extensions", quizH.ListExtensions)
+		adminRoutes.PUT("/quizzes/:quizID/extensions/:userID", quizH.GrantExtension)
+		adminRoutes.DELETE("/quizzes/:quizID/extensions/:userID", quizH.RevokeExtension)
+		adminRoutes.GET("/quizzes/:quizID/assignments", cohortH.ListAssignments)
+		adminRoutes.PUT("/quizzes/:quizID/assignments/:cohortID", cohortH.Assign)
+		adminRoutes.DELETE("/quizzes/:quizID/assignments/:cohortID", cohortH.Unassign)
+		adminRoutes.PUT("/quizzes/:quizID/visibility", accessH.SetVisibility)
+		adminRoutes.GET("/quizzes/:quizID/access-codes", accessH.ListCodes)
+		adminRoutes.POST("/quizzes/:quizID/access-codes", accessH.CreateCode)
+		adminRoutes.POST("/quizzes/:quizID/access-codes/:codeID/revoke", accessH.RevokeCode)
+		adminRoutes.GET("/quizzes/:quizID/grants", accessH.ListGrants)
+		adminRoutes.PUT("/quizzes/:quizID/grants/:userID", accessH.Grant)
+		adminRoutes.DELETE("/quizzes/:quizID/grants/:userID", accessH.Revoke)
+		adminRoutes.PUT("/quizzes/:quizID/certificate-settings", certH.UpdateSettings)
+		adminRoutes.POST("/certificates/:code/revoke", certH.Revoke)
 		adminRoutes.POST("/quizzes/:quizID/questions", quizH.AddQuestion)
+		adminRoutes.POST("/quizzes/:quizID/sections", quizH.CreateSection)
+		adminRoutes.PUT("/quizzes/:quizID/sections/order", quizH.ReorderSections)
+		adminRoutes.PUT("/quizzes/:quizID/sections/:sectionID", quizH.UpdateSection)
+		adminRoutes.DELETE("/quizzes/:quizID/sections/:sectionID", quizH.DeleteSection)
+		adminRoutes.PUT("/quizzes/:quizID/questions/order", quizH.ReorderQuestions)
+		adminRoutes.PUT("/quizzes/:quizID/questions/:questionID", quizH.UpdateQuestion)
+		adminRoutes.PUT("/quizzes/:quizID/questions/:questionID/section", quizH.MoveQuestion)
+		adminRoutes.GET("/quizzes/:quizID/questions/:questionID/revisions", revH.List)
+		adminRoutes.GET("/quizzes/:quizID/questions/:questionID/revisions/diff", revH.Diff)
+		adminRoutes.POST("/quizzes/:quizID/questions/:questionID/regrade", revH.Regrade)
+		adminRoutes.PUT("/quizzes/:quizID/answers/:answerID/grade", revH.GradeAnswer)
+		adminRoutes.GET("/quizzes/:quizID/submissions", integrityH.List)
+		adminRoutes.GET("/quizzes/:quizID/submissions/:submissionID", integrityH.Get)
+		adminRoutes.POST("/quizzes/:quizID/submissions/:submissionID/invalidate", integrityH.Invalidate)
+		adminRoutes.PUT("/quizzes/:quizID/plagiarism-settings", plagiarismH.UpdateSettings)
+		adminRoutes.GET("/quizzes/:quizID/plagiarism/flags", plagiarismH.Flags)
+		adminRoutes.PUT("/quizzes/:quizID/plagiarism/flags/:flagID", plagiarismH.Review)
+		adminRoutes.POST("/quizzes/:quizID/bank-questions", bankH.LinkToQuiz)
+		adminRoutes.GET("/quizzes/:quizID/analytics", statsH.QuizStats)
+		adminRoutes.GET("/quizzes/:quizID/analytics/items", statsH.ItemAnalysis)
+		adminRoutes.GET("/quizzes/:quizID/results/export", exportH.Results)
+		adminRoutes.POST("/quizzes/:quizID/qti/import", qtiH.Import)
+		adminRoutes.GET("/quizzes/:quizID/qti/export", qtiH.Export)
+		adminRoutes.POST("/attachments", attachH.Upload)
+
+		adminRoutes.GET("/lti/platforms", ltiH.ListPlatforms)
+		adminRoutes.POST("/lti/platforms", ltiH.CreatePlatform)
+
+		adminRoutes.POST("/live/sessions", liveH.Create)
+		adminRoutes.GET("/live/sessions/:pin/host", liveH.Host)
+
+		adminRoutes.GET("/cohorts", cohortH.List)
+		adminRoutes.POST("/cohorts", cohortH.Create)
+		adminRoutes.GET("/cohorts/:cohortID", cohortH.Get)
+		adminRoutes.DELETE("/cohorts/:cohortID", cohortH.Delete)
+		adminRoutes.POST("/cohorts/:cohortID/members", cohortH.AddMembers)
+		adminRoutes.DELETE("/cohorts/:cohortID/members/:userID", cohortH.RemoveMember)
+
+		adminRoutes.GET("/webhooks", hookH.List)
+		adminRoutes.POST("/webhooks", hookH.Create)
+		adminRoutes.DELETE("/webhooks/:webhookID", hookH.Delete)
+		adminRoutes.GET("/webhooks/:webhookID/deliveries", hookH.Deliveries)
+		adminRoutes.POST("/webhooks/:webhookID/deliveries/:deliveryID/redeliver", hookH.Redeliver)
+
+		adminRoutes.GET("/bank/questions", bankH.List)
+		adminRoutes.POST("/bank/questions", bankH.Create)
+		adminRoutes.GET("/bank/questions/:bankID", bankH.Get)
+		adminRoutes.PUT("/bank/questions/:bankID", bankH.Update)
+		adminRoutes.GET("/bank/questions/:bankID/versions/:version", bankH.GetVersion)
 	}
 	log.Printf("listening on %s", cfg.Port)
 	if err := r.Run(cfg.Port); err != nil {
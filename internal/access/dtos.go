@@ -0,0 +1,22 @@
+package access
+
+import (
+	"time"
+
+	"quizapi/internal/models"
+)
+
+type VisibilityReq struct {
+	Visibility models.QuizVisibility `json:"visibility" validate:"required,oneof=public unlisted code"`
+}
+
+// CreateCodeReq generates an access code. Both limits are optional.
+type CreateCodeReq struct {
+	MaxUses   *int       `json:"max_uses" validate:"omitempty,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// RedeemReq unlocks a code-protected quiz for the caller.
+type RedeemReq struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
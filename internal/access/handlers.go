@@ -0,0 +1,181 @@
+package access
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type Handler struct {
+	svc *Service
+	val *validator.Validate
+}
+
+func NewHandler(svc *Service) *Handler { return &Handler{svc: svc, val: validator.New()} }
+
+func (h *Handler) SetVisibility(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	var req VisibilityReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	q, err := h.svc.SetVisibility(uint(quizID), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, q)
+}
+
+// Redeem unlocks a code-protected quiz for the caller.
+func (h *Handler) Redeem(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	var req RedeemReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.svc.Redeem(uint(quizID), c.GetUint("userID"), req.Code, time.Now()); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidCode) || errors.Is(err, ErrCodeRequired) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) ListCodes(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	out, err := h.svc.ListCodes(uint(quizID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *Handler) CreateCode(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	var req CreateCodeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	ac, err := h.svc.CreateCode(uint(quizID), req, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, ac)
+}
+
+func (h *Handler) RevokeCode(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	codeID, err := strconv.Atoi(c.Param("codeID"))
+	if err != nil || codeID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid codeID"})
+		return
+	}
+	ac, err := h.svc.RevokeCode(uint(quizID), uint(codeID), time.Now())
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrCodeNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ac)
+}
+
+func (h *Handler) ListGrants(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	out, err := h.svc.ListGrants(uint(quizID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *Handler) Grant(c *gin.Context) {
+	quizID, userID, ok := quizAndUser(c)
+	if !ok {
+		return
+	}
+	g, err := h.svc.Grant(quizID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, g)
+}
+
+func (h *Handler) Revoke(c *gin.Context) {
+	quizID, userID, ok := quizAndUser(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.Revoke(quizID, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func quizAndUser(c *gin.Context) (quizID, userID uint, ok bool) {
+	q, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || q <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return 0, 0, false
+	}
+	u, err := strconv.Atoi(c.Param("userID"))
+	if err != nil || u <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid userID"})
+		return 0, 0, false
+	}
+	return uint(q), uint(u), true
+}
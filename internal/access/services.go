@@ -0,0 +1,192 @@
+package access
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"quizapi/internal/codes"
+	"quizapi/internal/models"
+)
+
+var (
+	ErrCodeRequired = errors.New("quiz requires an access code")
+	// ErrInvalidCode covers unknown, revoked, expired and used-up codes
+	// alike, so codes can't be probed.
+	ErrInvalidCode  = errors.New("access code is invalid or no longer usable")
+	ErrCodeNotFound = errors.New("access code not found")
+)
+
+type Service struct{ db *gorm.DB }
+
+func NewService(db *gorm.DB) *Service { return &Service{db: db} }
+
+// Listed restricts a quiz query to quizzes listed for userID: public ones,
+// and code-protected ones userID has been granted.
+func Listed(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(`quizzes.visibility = ? OR (quizzes.visibility = ? AND EXISTS
+			(SELECT 1 FROM access_grants ag WHERE ag.quiz_id = quizzes.id AND ag.user_id = ?))`,
+			models.VisibilityPublic, models.VisibilityCode, userID)
+	}
+}
+
+// Check returns ErrCodeRequired if q is code-protected and userID has no
+// grant for it.
+func Check(db *gorm.DB, q *models.Quiz, userID uint) error {
+	if q.Visibility != models.VisibilityCode {
+		return nil
+	}
+	var n int64
+	if err := db.Model(&models.AccessGrant{}).Where("quiz_id = ? AND user_id = ?", q.ID, userID).
+		Count(&n).Error; err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrCodeRequired
+	}
+	return nil
+}
+
+// Redeem grants userID access to the quiz with code. A user who already
+// has a grant keeps it without using up the code.
+func Redeem(db *gorm.DB, quizID, userID uint, code string, now time.Time) error {
+	if userID == 0 {
+		return ErrCodeRequired
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		var n int64
+		if err := tx.Model(&models.AccessGrant{}).Where("quiz_id = ? AND user_id = ?", quizID, userID).
+			Count(&n).Error; err != nil {
+			return err
+		}
+		if n > 0 {
+			return nil
+		}
+		var ac models.AccessCode
+		res := tx.Where("quiz_id = ? AND code = ?", quizID, normalize(code)).Limit(1).Find(&ac)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 || ac.RevokedAt != nil || (ac.ExpiresAt != nil && !now.Before(*ac.ExpiresAt)) {
+			return ErrInvalidCode
+		}
+		// the use is counted with a conditional update so concurrent
+		// redemptions can't exceed MaxUses
+		res = tx.Model(&models.AccessCode{}).
+			Where("id = ? AND (max_uses IS NULL OR uses < max_uses)", ac.ID).
+			Update("uses", gorm.Expr("uses + 1"))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrInvalidCode
+		}
+		g := models.AccessGrant{QuizID: quizID, UserID: userID, AccessCodeID: &ac.ID}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&g).Error
+	})
+}
+
+// Redeem grants userID access to the quiz with code.
+func (s *Service) Redeem(quizID, userID uint, code string, now time.Time) error {
+	return Redeem(s.db, quizID, userID, code, now)
+}
+
+// SetVisibility changes who finds and takes the quiz. Grants are kept, so
+// switching a quiz back to code-protected restores earlier access.
+func (s *Service) SetVisibility(quizID uint, req VisibilityReq) (*models.Quiz, error) {
+	var q models.Quiz
+	if err := s.db.First(&q, quizID).Error; err != nil {
+		return nil, fmt.Errorf("quiz %d not found", quizID)
+	}
+	if err := s.db.Model(&q).Update("visibility", req.Visibility).Error; err != nil {
+		return nil, err
+	}
+	q.Visibility = req.Visibility
+	return &q, nil
+}
+
+// --- Codes ---
+
+func (s *Service) CreateCode(quizID uint, req CreateCodeReq, now time.Time) (*models.AccessCode, error) {
+	var n int64
+	if err := s.db.Model(&models.Quiz{}).Where("id = ?", quizID).Count(&n).Error; err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("quiz %d not found", quizID)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		return nil, errors.New("expires_at must be in the future")
+	}
+	ac := models.AccessCode{QuizID: quizID, Code: codes.New(), MaxUses: req.MaxUses, ExpiresAt: req.ExpiresAt}
+	return &ac, s.db.Create(&ac).Error
+}
+
+func (s *Service) ListCodes(quizID uint) ([]models.AccessCode, error) {
+	out := []models.AccessCode{}
+	return out, s.db.Where("quiz_id = ?", quizID).Order("id").Find(&out).Error
+}
+
+// RevokeCode stops a code from being redeemed. Grants it gave are kept;
+// revoke those individually.
+func (s *Service) RevokeCode(quizID, codeID uint, now time.Time) (*models.AccessCode, error) {
+	var ac models.AccessCode
+	if err := s.db.Where("id = ? AND quiz_id = ?", codeID, quizID).First(&ac).Error; err != nil {
+		return nil, ErrCodeNotFound
+	}
+	if ac.RevokedAt == nil {
+		if err := s.db.Model(&ac).Update("revoked_at", now).Error; err != nil {
+			return nil, err
+		}
+		ac.RevokedAt = &now
+	}
+	return &ac, nil
+}
+
+// --- Grants ---
+
+func (s *Service) ListGrants(quizID uint) ([]models.AccessGrant, error) {
+	out := []models.AccessGrant{}
+	return out, s.db.Where("quiz_id = ?", quizID).Order("id").Find(&out).Error
+}
+
+// Grant gives userID access to the quiz without a code.
+func (s *Service) Grant(quizID, userID uint) (*models.AccessGrant, error) {
+	var n int64
+	if err := s.db.Model(&models.Quiz{}).Where("id = ?", quizID).Count(&n).Error; err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("quiz %d not found", quizID)
+	}
+	if err := s.db.Model(&models.User{}).Where("id = ?", userID).Count(&n).Error; err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("user %d not found", userID)
+	}
+	g := models.AccessGrant{QuizID: quizID, UserID: userID}
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&g).Error; err != nil {
+		return nil, err
+	}
+	return &g, s.db.Where("quiz_id = ? AND user_id = ?", quizID, userID).First(&g).Error
+}
+
+// Revoke removes userID's grant. Attempts already started can't continue.
+func (s *Service) Revoke(quizID, userID uint) error {
+	return s.db.Where("quiz_id = ? AND user_id = ?", quizID, userID).Delete(&models.AccessGrant{}).Error
+}
+
+// normalize lets users type codes in any case, with or without the dash.
+func normalize(code string) string {
+	c := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if len(c) != 12 {
+		return c
+	}
+	return c[0:4] + "-" + c[4:8] + "-" + c[8:12]
+}
@@ -0,0 +1,117 @@
+package access_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"quizapi/internal/access"
+	"quizapi/internal/models"
+	"quizapi/internal/quizzes"
+	"quizapi/internal/testutil"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestCodeProtectedQuiz(t *testing.T) {
+	d := testutil.DB(t)
+	qsvc := quizzes.NewService(d)
+	svc := access.NewService(d)
+	qz, err := qsvc.CreateQuiz("Finals")
+	require.NoError(t, err)
+	q, err := qsvc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{Text: "Q?", Type: "single",
+		Options: []quizzes.CreateQuestionOption{{Text: "yes", IsCorrect: ptr(true)}, {Text: "no", IsCorrect: ptr(false)}}})
+	require.NoError(t, err)
+	_, err = svc.SetVisibility(qz.ID, access.VisibilityReq{Visibility: models.VisibilityCode})
+	require.NoError(t, err)
+	listed := func(userID uint) int64 {
+		_, n, err := qsvc.ListQuizzes(1, 10, quizzes.ListFilter{UserID: userID})
+		require.NoError(t, err)
+		return n
+	}
+
+	_, err = qsvc.GetPublicQuestions(qz.ID, 1)
+	require.ErrorIs(t, err, access.ErrCodeRequired)
+	require.Zero(t, listed(1))
+
+	now := time.Now()
+	once, err := svc.CreateCode(qz.ID, access.CreateCodeReq{MaxUses: ptr(1)}, now)
+	require.NoError(t, err)
+	expiring, err := svc.CreateCode(qz.ID, access.CreateCodeReq{ExpiresAt: ptr(now.Add(time.Hour))}, now)
+	require.NoError(t, err)
+
+	// codes can be typed in any case and without dashes
+	typed := strings.ToLower(strings.ReplaceAll(once.Code, "-", ""))
+	require.NoError(t, access.Redeem(d, qz.ID, 1, typed, now))
+	require.NoError(t, access.Redeem(d, qz.ID, 1, "anything", now)) // already granted
+	_, err = qsvc.GetPublicQuestions(qz.ID, 1)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, listed(1))
+	require.ErrorIs(t, access.Redeem(d, qz.ID, 2, once.Code, now), access.ErrInvalidCode) // used up
+	require.ErrorIs(t, access.Redeem(d, qz.ID, 2, expiring.Code, now.Add(2*time.Hour)), access.ErrInvalidCode)
+
+	// submitting with a code grants access on the way in
+	var right models.Option
+	require.NoError(t, d.Where("question_id = ? AND is_correct = ?", q.ID, true).First(&right).Error)
+	answers := []quizzes.SubmitAnswer{{QuestionID: q.ID, SelectedOptionID: &right.ID}}
+	_, _, err = qsvc.SubmitAndScore(qz.ID, 2, quizzes.SubmitReq{Answers: answers})
+	require.ErrorIs(t, err, access.ErrCodeRequired)
+	_, res, err := qsvc.SubmitAndScore(qz.ID, 2, quizzes.SubmitReq{Answers: answers, AccessCode: expiring.Code})
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Score)
+
+	// unlisted quizzes can be taken by anyone with the link
+	_, err = svc.SetVisibility(qz.ID, access.VisibilityReq{Visibility: models.VisibilityUnlisted})
+	require.NoError(t, err)
+	_, err = qsvc.GetPublicQuestions(qz.ID, 3)
+	require.NoError(t, err)
+	require.Zero(t, listed(1))
+}
+
+func TestSubmitWithCode_RedeemsOnlyOnSuccess(t *testing.T) {
+	d := testutil.DB(t)
+	qsvc := quizzes.NewService(d)
+	svc := access.NewService(d)
+	qz, err := qsvc.CreateQuiz("Cohort finals")
+	require.NoError(t, err)
+	q, err := qsvc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{Text: "Q?", Type: "single",
+		Options: []quizzes.CreateQuestionOption{{Text: "yes", IsCorrect: ptr(true)}, {Text: "no", IsCorrect: ptr(false)}}})
+	require.NoError(t, err)
+	_, err = svc.SetVisibility(qz.ID, access.VisibilityReq{Visibility: models.VisibilityCode})
+	require.NoError(t, err)
+	require.NoError(t, d.Create(&models.QuizAssignment{QuizID: qz.ID, CohortID: 1}).Error)
+	code, err := svc.CreateCode(qz.ID, access.CreateCodeReq{MaxUses: ptr(1)}, time.Now())
+	require.NoError(t, err)
+	var right models.Option
+	require.NoError(t, d.Where("question_id = ? AND is_correct = ?", q.ID, true).First(&right).Error)
+	answers := []quizzes.SubmitAnswer{{QuestionID: q.ID, SelectedOptionID: &right.ID}}
+	unused := func() {
+		t.Helper()
+		var ac models.AccessCode
+		require.NoError(t, d.First(&ac, code.ID).Error)
+		require.Zero(t, ac.Uses)
+		var n int64
+		require.NoError(t, d.Model(&models.AccessGrant{}).Where("quiz_id = ?", qz.ID).Count(&n).Error)
+		require.Zero(t, n)
+	}
+
+	// outside the quiz's cohorts the code is neither checked nor used
+	_, _, err = qsvc.SubmitAndScore(qz.ID, 3, quizzes.SubmitReq{Answers: answers, AccessCode: code.Code})
+	require.ErrorIs(t, err, quizzes.ErrQuizHidden)
+	require.ErrorIs(t, qsvc.Redeem(qz.ID, 3, code.Code), quizzes.ErrQuizHidden)
+	unused()
+
+	// a submit that fails rolls the redemption back
+	require.NoError(t, d.Create(&models.CohortMember{CohortID: 1, UserID: 3}).Error)
+	bad := []quizzes.SubmitAnswer{{QuestionID: q.ID + 100, SelectedOptionID: &right.ID}}
+	_, _, err = qsvc.SubmitAndScore(qz.ID, 3, quizzes.SubmitReq{Answers: bad, AccessCode: code.Code})
+	require.ErrorContains(t, err, "does not belong to quiz")
+	unused()
+
+	_, res, err := qsvc.SubmitAndScore(qz.ID, 3, quizzes.SubmitReq{Answers: answers, AccessCode: code.Code})
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Score)
+	require.NoError(t, access.Check(d, &models.Quiz{ID: qz.ID, Visibility: models.VisibilityCode}, 3))
+}
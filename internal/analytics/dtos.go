@@ -0,0 +1,75 @@
+package analytics
+
+// Bucket is one bar of a histogram covering [From, To).
+// To is nil for the open-ended last bucket.
+type Bucket struct {
+	Label string `json:"label"`
+	From  int    `json:"from"`
+	To    *int   `json:"to"`
+	Count int64  `json:"count"`
+}
+
+type CompletionStats struct {
+	Timed         int64    `json:"timed"` // submissions with a known duration
+	MedianSeconds *int     `json:"median_seconds"`
+	MeanSeconds   *float64 `json:"mean_seconds"`
+	Buckets       []Bucket `json:"buckets"`
+}
+
+// QuizStats summarizes a quiz's submissions. Score statistics are in whole
+// percentages and only cover submissions with at least one auto-graded answer.
+type QuizStats struct {
+	QuizID         uint            `json:"quiz_id"`
+	Attempts       int64           `json:"attempts"`
+	UniqueTakers   int64           `json:"unique_takers"`
+	Scored         int64           `json:"scored"`
+	MeanPercent    *float64        `json:"mean_percent"`
+	MedianPercent  *int            `json:"median_percent"`
+	Percentiles    map[string]int  `json:"percentiles"`
+	ScoreHistogram []Bucket        `json:"score_histogram"`
+	CompletionTime CompletionStats `json:"completion_time"`
+	PassPercent    int             `json:"pass_percent"`
+	PassRate       *float64        `json:"pass_rate"`
+}
+
+type OptionStats struct {
+	OptionID     uint    `json:"option_id"`
+	Text         string  `json:"text"`
+	IsCorrect    bool    `json:"is_correct"`
+	Selected     int64   `json:"selected"`
+	SelectedRate float64 `json:"selected_rate"`
+	// selections among the top and bottom scoring groups
+	TopSelected    int64 `json:"top_selected"`
+	BottomSelected int64 `json:"bottom_selected"`
+}
+
+// ItemStats holds classical test theory statistics for one question.
+// PValue is the proportion answering correctly; Discrimination is the
+// point-biserial correlation between the item and the submission percent.
+type ItemStats struct {
+	QuestionID     uint          `json:"question_id"`
+	Text           string        `json:"text"`
+	Type           string        `json:"type"`
+	Responses      int64         `json:"responses"`
+	Correct        int64         `json:"correct"`
+	PValue         *float64      `json:"p_value"`
+	Discrimination *float64      `json:"discrimination"`
+	Options        []OptionStats `json:"options,omitempty"`
+	Flags          []string      `json:"flags"`
+}
+
+type ItemAnalysis struct {
+	QuizID uint  `json:"quiz_id"`
+	Scored int64 `json:"scored"`
+	// Top/bottom groups are the highest and lowest scoring 27% of
+	// submissions; ties at the cut-off can make them slightly larger.
+	TopGroupSize    int64       `json:"top_group_size"`
+	BottomGroupSize int64       `json:"bottom_group_size"`
+	Items           []ItemStats `json:"items"`
+}
+
+// Flags raised by item analysis.
+const (
+	FlagNegativeDiscrimination = "negative_discrimination"
+	FlagUnusedDistractor       = "unused_distractor"
+)
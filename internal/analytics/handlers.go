@@ -0,0 +1,54 @@
+package analytics
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) QuizStats(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	// without ?pass_percent the quiz's certificate pass mark applies
+	var pass *int
+	if raw, ok := c.GetQuery("pass_percent"); ok {
+		p, err := strconv.Atoi(raw)
+		if err != nil || p < 0 || p > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "pass_percent must be in 0..100"})
+			return
+		}
+		pass = &p
+	}
+	stats, err := h.svc.QuizStats(uint(quizID), pass)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+func (h *Handler) ItemAnalysis(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	res, err := h.svc.ItemAnalysis(uint(quizID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,386 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"quizapi/internal/models"
+	"quizapi/internal/quizzes"
+)
+
+// percentiles reported alongside the median
+var percentileRanks = []int{25, 50, 75, 90}
+
+// completion time bucket boundaries in seconds
+var durationBounds = []int{60, 5 * 60, 10 * 60, 20 * 60, 30 * 60, 60 * 60}
+
+type Service struct{ db *gorm.DB }
+
+func NewService(db *gorm.DB) *Service { return &Service{db: db} }
+
+// defaultPassPercent is the pass mark for quizzes without certificates.
+const defaultPassPercent = 50
+
+// QuizStats computes per-quiz aggregates in SQL; at most a few hundred
+// grouped rows are loaded into Go regardless of the number of submissions.
+// Invalidated submissions are left out here and in ItemAnalysis. A nil
+// passPercent uses the quiz's certificate pass mark, or 50 if it has none.
+func (s *Service) QuizStats(quizID uint, pass *int) (*QuizStats, error) {
+	passPercent := defaultPassPercent
+	if pass != nil {
+		passPercent = *pass
+	} else {
+		var quiz models.Quiz
+		if err := s.db.Select("pass_percent").Limit(1).Find(&quiz, quizID).Error; err != nil {
+			return nil, err
+		}
+		if quiz.PassPercent != nil {
+			passPercent = *quiz.PassPercent
+		}
+	}
+	subs := func() *gorm.DB {
+		return s.db.Model(&models.Submission{}).Where("quiz_id = ? AND invalidated_at IS NULL", quizID)
+	}
+	scored := func() *gorm.DB { return subs().Where("total > 0") }
+
+	var agg struct {
+		Attempts int64
+		Takers   int64
+		Scored   int64
+		Passed   int64
+		Mean     *float64
+	}
+	if err := subs().Select(`COUNT(*) AS attempts,
+		COUNT(DISTINCT CASE WHEN user_id > 0 THEN user_id END) AS takers,
+		COALESCE(SUM(CASE WHEN total > 0 THEN 1 ELSE 0 END), 0) AS scored,
+		COALESCE(SUM(CASE WHEN total > 0 AND percent >= ? THEN 1 ELSE 0 END), 0) AS passed,
+		AVG(CASE WHEN total > 0 THEN percent END) AS mean`, passPercent).
+		Scan(&agg).Error; err != nil {
+		return nil, err
+	}
+
+	out := &QuizStats{
+		QuizID:       quizID,
+		Attempts:     agg.Attempts,
+		UniqueTakers: agg.Takers,
+		Scored:       agg.Scored,
+		MeanPercent:  agg.Mean,
+		Percentiles:  map[string]int{},
+		PassPercent:  passPercent,
+	}
+	if agg.Scored > 0 {
+		rate := float64(agg.Passed) / float64(agg.Scored)
+		out.PassRate = &rate
+	}
+
+	for _, p := range percentileRanks {
+		v, err := nthValue(scored(), "percent", nearestRank(p, agg.Scored))
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			out.Percentiles[fmt.Sprintf("p%d", p)] = *v
+		}
+	}
+	if v, ok := out.Percentiles["p50"]; ok {
+		out.MedianPercent = &v
+	}
+
+	hist, err := s.scoreHistogram(scored())
+	if err != nil {
+		return nil, err
+	}
+	out.ScoreHistogram = hist
+
+	ct, err := s.completionStats(func() *gorm.DB { return subs().Where("duration_seconds IS NOT NULL") })
+	if err != nil {
+		return nil, err
+	}
+	out.CompletionTime = *ct
+	return out, nil
+}
+
+// scoreHistogram groups by exact percent in SQL (at most 101 rows) and
+// folds the counts into ten 10-point buckets, the last one including 100.
+func (s *Service) scoreHistogram(scored *gorm.DB) ([]Bucket, error) {
+	var rows []struct {
+		Percent int
+		N       int64
+	}
+	if err := scored.Select("percent, COUNT(*) AS n").Group("percent").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	buckets := make([]Bucket, 10)
+	for i := range buckets {
+		from, to := i*10, i*10+10
+		label := fmt.Sprintf("%d-%d", from, to-1)
+		if i == 9 {
+			to, label = 101, "90-100"
+		}
+		buckets[i] = Bucket{Label: label, From: from, To: &to}
+	}
+	for _, r := range rows {
+		i := min(max(r.Percent/10, 0), 9)
+		buckets[i].Count += r.N
+	}
+	return buckets, nil
+}
+
+func (s *Service) completionStats(timed func() *gorm.DB) (*CompletionStats, error) {
+	var agg struct {
+		N    int64
+		Mean *float64
+	}
+	if err := timed().Select("COUNT(*) AS n, AVG(duration_seconds) AS mean").Scan(&agg).Error; err != nil {
+		return nil, err
+	}
+	out := &CompletionStats{Timed: agg.N, MeanSeconds: agg.Mean}
+	med, err := nthValue(timed(), "duration_seconds", nearestRank(50, agg.N))
+	if err != nil {
+		return nil, err
+	}
+	out.MedianSeconds = med
+
+	// CASE expression mapping a duration to its bucket index
+	var b strings.Builder
+	b.WriteString("CASE")
+	for i, bound := range durationBounds {
+		fmt.Fprintf(&b, " WHEN duration_seconds < %d THEN %d", bound, i)
+	}
+	fmt.Fprintf(&b, " ELSE %d END", len(durationBounds))
+
+	var rows []struct {
+		Bucket int
+		N      int64
+	}
+	if err := timed().Select(b.String() + " AS bucket, COUNT(*) AS n").
+		Group("bucket").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out.Buckets = make([]Bucket, len(durationBounds)+1)
+	from := 0
+	for i := range out.Buckets {
+		bk := Bucket{From: from}
+		if i < len(durationBounds) {
+			to := durationBounds[i]
+			bk.To = &to
+			bk.Label = fmt.Sprintf("%s-%s", fmtDuration(from), fmtDuration(to))
+			from = to
+		} else {
+			bk.Label = fmtDuration(from) + "+"
+		}
+		out.Buckets[i] = bk
+	}
+	for _, r := range rows {
+		out.Buckets[r.Bucket].Count = r.N
+	}
+	return out, nil
+}
+
+// groupFraction is the share of submissions in each of the top and bottom
+// groups used for distractor analysis (Kelley's 27%).
+const groupFraction = 0.27
+
+// ItemAnalysis computes difficulty, discrimination and option selection
+// statistics for every question in the quiz. Options are merged across
+// edits by lineage, so selections of a reworded option count toward the
+// option that replaced it.
+func (s *Service) ItemAnalysis(quizID uint) (*ItemAnalysis, error) {
+	var qs []models.Question
+	if err := s.db.Preload("Options", quizzes.ByPosition).Scopes(quizzes.ByPosition).
+		Where("quiz_id = ?", quizID).Find(&qs).Error; err != nil {
+		return nil, err
+	}
+	if len(qs) == 0 {
+		return nil, fmt.Errorf("quiz %d not found or has no questions", quizID)
+	}
+
+	scored := func() *gorm.DB {
+		return s.db.Model(&models.Submission{}).Where("quiz_id = ? AND total > 0 AND invalidated_at IS NULL", quizID)
+	}
+	var n int64
+	if err := scored().Count(&n).Error; err != nil {
+		return nil, err
+	}
+	out := &ItemAnalysis{QuizID: quizID, Scored: n, Items: make([]ItemStats, 0, len(qs))}
+
+	// per-question correctness and score moments in one grouped query
+	var rows []struct {
+		QuestionID uint
+		N          int64
+		Correct    int64
+		M1         *float64 // mean percent of those answering correctly
+		M0         *float64 // ... and incorrectly
+		Mean       float64
+		MeanSq     float64
+	}
+	if err := s.db.Table("answers a").
+		Joins("JOIN submissions s ON s.id = a.submission_id").
+		Where("s.quiz_id = ? AND s.invalidated_at IS NULL AND a.is_correct IS NOT NULL", quizID).
+		Select(`a.question_id AS question_id, COUNT(*) AS n,
+			SUM(CASE WHEN a.is_correct = ? THEN 1 ELSE 0 END) AS correct,
+			AVG(CASE WHEN a.is_correct = ? THEN s.percent END) AS m1,
+			AVG(CASE WHEN a.is_correct = ? THEN s.percent END) AS m0,
+			AVG(s.percent) AS mean, AVG(s.percent * s.percent) AS mean_sq`, true, true, false).
+		Group("a.question_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	byQuestion := make(map[uint]int, len(rows))
+	for i, r := range rows {
+		byQuestion[r.QuestionID] = i
+	}
+
+	// top/bottom cut-offs by submission percent
+	var lowCut, highCut *int
+	if k := int64(math.Ceil(groupFraction * float64(n))); k > 0 {
+		var err error
+		if lowCut, err = nthValue(scored(), "percent", k); err != nil {
+			return nil, err
+		}
+		if highCut, err = nthValue(scored(), "percent", n-k+1); err != nil {
+			return nil, err
+		}
+		if err := scored().Where("percent >= ?", *highCut).Count(&out.TopGroupSize).Error; err != nil {
+			return nil, err
+		}
+		if err := scored().Where("percent <= ?", *lowCut).Count(&out.BottomGroupSize).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	optSel, err := s.optionSelections(quizID, lowCut, highCut)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, q := range qs {
+		it := ItemStats{QuestionID: q.ID, Text: q.Text, Type: string(q.Type), Flags: []string{}}
+		if i, ok := byQuestion[q.ID]; ok {
+			r := rows[i]
+			it.Responses, it.Correct = r.N, r.Correct
+			p := float64(r.Correct) / float64(r.N)
+			it.PValue = &p
+			if rpb, ok := pointBiserial(p, r.M1, r.M0, r.Mean, r.MeanSq); ok {
+				it.Discrimination = &rpb
+				if rpb < 0 {
+					it.Flags = append(it.Flags, FlagNegativeDiscrimination)
+				}
+			}
+		}
+		unused := false
+		for _, o := range q.Options {
+			sel := optSel[o.Key()]
+			os := OptionStats{
+				OptionID:       o.ID,
+				Text:           o.Text,
+				IsCorrect:      o.IsCorrect,
+				Selected:       sel.total,
+				TopSelected:    sel.top,
+				BottomSelected: sel.bottom,
+			}
+			if it.Responses > 0 {
+				os.SelectedRate = float64(sel.total) / float64(it.Responses)
+				if !o.IsCorrect && sel.total == 0 {
+					unused = true
+				}
+			}
+			it.Options = append(it.Options, os)
+		}
+		if unused {
+			it.Flags = append(it.Flags, FlagUnusedDistractor)
+		}
+		out.Items = append(out.Items, it)
+	}
+	return out, nil
+}
+
+type selection struct{ total, top, bottom int64 }
+
+// optionSelections counts selections per option lineage key, overall and
+// within the top (percent >= highCut) and bottom (percent <= lowCut) groups.
+func (s *Service) optionSelections(quizID uint, lowCut, highCut *int) (map[uint]selection, error) {
+	low, high := -1, 101 // no groups: match nothing
+	if lowCut != nil && highCut != nil {
+		low, high = *lowCut, *highCut
+	}
+	var rows []struct {
+		OptionID uint
+		OriginID *uint
+		Total    int64
+		Top      int64
+		Bottom   int64
+	}
+	if err := s.db.Table("answer_options ao").
+		Joins("JOIN answers a ON a.id = ao.answer_id").
+		Joins("JOIN submissions s ON s.id = a.submission_id").
+		Joins("JOIN options o ON o.id = ao.option_id").
+		Where("s.quiz_id = ? AND s.invalidated_at IS NULL", quizID).
+		Select(`ao.option_id AS option_id, o.origin_id AS origin_id, COUNT(*) AS total,
+			SUM(CASE WHEN s.total > 0 AND s.percent >= ? THEN 1 ELSE 0 END) AS top,
+			SUM(CASE WHEN s.total > 0 AND s.percent <= ? THEN 1 ELSE 0 END) AS bottom`, high, low).
+		Group("ao.option_id, o.origin_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[uint]selection, len(rows))
+	for _, r := range rows {
+		key := models.Option{ID: r.OptionID, OriginID: r.OriginID}.Key()
+		sel := out[key]
+		sel.total += r.Total
+		sel.top += r.Top
+		sel.bottom += r.Bottom
+		out[key] = sel
+	}
+	return out, nil
+}
+
+// pointBiserial computes r_pb = (M1 - M0) / s * sqrt(p * (1 - p)) where s
+// is the population standard deviation of the submission percent. It is
+// undefined when everyone (or no one) got the item right or scores don't vary.
+func pointBiserial(p float64, m1, m0 *float64, mean, meanSq float64) (float64, bool) {
+	if m1 == nil || m0 == nil || p <= 0 || p >= 1 {
+		return 0, false
+	}
+	variance := meanSq - mean*mean
+	if variance <= 1e-9 {
+		return 0, false
+	}
+	return (*m1 - *m0) / math.Sqrt(variance) * math.Sqrt(p*(1-p)), true
+}
+
+// --- helpers ---
+
+// nearestRank returns the 1-based rank of the p-th percentile among n values.
+func nearestRank(p int, n int64) int64 {
+	if n == 0 {
+		return 0
+	}
+	return max(int64(math.Ceil(float64(p)/100*float64(n))), 1)
+}
+
+// nthValue returns the rank-th smallest value of col (1-based) or nil when rank is 0.
+func nthValue(q *gorm.DB, col string, rank int64) (*int, error) {
+	if rank == 0 {
+		return nil, nil
+	}
+	var v []int
+	if err := q.Order(col).Offset(int(rank-1)).Limit(1).Pluck(col, &v).Error; err != nil {
+		return nil, err
+	}
+	if len(v) == 0 {
+		return nil, nil
+	}
+	return &v[0], nil
+}
+
+func fmtDuration(secs int) string {
+	if secs%3600 == 0 && secs > 0 {
+		return fmt.Sprintf("%dh", secs/3600)
+	}
+	if secs%60 == 0 {
+		return fmt.Sprintf("%dm", secs/60)
+	}
+	return fmt.Sprintf("%ds", secs)
+}
@@ -0,0 +1,109 @@
+package analytics_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"quizapi/internal/analytics"
+	"quizapi/internal/models"
+	"quizapi/internal/quizzes"
+	"quizapi/internal/testutil"
+)
+
+func TestQuizStats_Aggregates(t *testing.T) {
+	d := testutil.DB(t)
+	qz := &models.Quiz{Title: "stats"}
+	require.NoError(t, d.Create(qz).Error)
+
+	// user 1 twice, user 2 once, plus one text-only submission (total 0)
+	rows := []models.Submission{
+		{QuizID: qz.ID, UserID: 1, Score: 2, Total: 10, Percent: 20, DurationSeconds: ptr(30)},
+		{QuizID: qz.ID, UserID: 1, Score: 8, Total: 10, Percent: 80, DurationSeconds: ptr(400)},
+		{QuizID: qz.ID, UserID: 2, Score: 10, Total: 10, Percent: 100, DurationSeconds: ptr(4000)},
+		{QuizID: qz.ID, UserID: 3},
+	}
+	require.NoError(t, d.Create(&rows).Error)
+
+	st, err := analytics.NewService(d).QuizStats(qz.ID, nil)
+	require.NoError(t, err)
+	require.Equal(t, 50, st.PassPercent)
+	require.EqualValues(t, 4, st.Attempts)
+	require.EqualValues(t, 3, st.UniqueTakers)
+	require.EqualValues(t, 3, st.Scored)
+	require.InDelta(t, 66.67, *st.MeanPercent, 0.01)
+	require.Equal(t, 80, *st.MedianPercent)
+	require.InDelta(t, 2.0/3, *st.PassRate, 0.001)
+	require.EqualValues(t, 1, st.ScoreHistogram[2].Count)
+	require.EqualValues(t, 1, st.ScoreHistogram[9].Count)
+
+	require.EqualValues(t, 3, st.CompletionTime.Timed)
+	require.Equal(t, 400, *st.CompletionTime.MedianSeconds)
+	require.EqualValues(t, 1, st.CompletionTime.Buckets[0].Count)
+	require.EqualValues(t, 1, st.CompletionTime.Buckets[2].Count)
+	require.EqualValues(t, 1, st.CompletionTime.Buckets[6].Count)
+
+	// the certificate pass mark is the default; the caller can override it
+	require.NoError(t, d.Model(qz).Update("pass_percent", 90).Error)
+	st, err = analytics.NewService(d).QuizStats(qz.ID, nil)
+	require.NoError(t, err)
+	require.Equal(t, 90, st.PassPercent)
+	require.InDelta(t, 1.0/3, *st.PassRate, 0.001)
+	st, err = analytics.NewService(d).QuizStats(qz.ID, ptr(20))
+	require.NoError(t, err)
+	require.InDelta(t, 1.0, *st.PassRate, 0.001)
+}
+
+func TestItemAnalysis_FlagsBadItems(t *testing.T) {
+	d := testutil.DB(t)
+	qsvc := quizzes.NewService(d)
+	qz, err := qsvc.CreateQuiz("items")
+	require.NoError(t, err)
+
+	var qids []uint
+	for i := 0; i < 3; i++ {
+		q, err := qsvc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{
+			Text: "q", Type: "single",
+			Options: []quizzes.CreateQuestionOption{
+				{Text: "right", IsCorrect: ptr(true)},
+				{Text: "wrong", IsCorrect: ptr(false)},
+				{Text: "never picked", IsCorrect: ptr(false)},
+			},
+		})
+		require.NoError(t, err)
+		qids = append(qids, q.ID)
+	}
+	pub, err := qsvc.GetPublicQuestions(qz.ID, 0)
+	require.NoError(t, err)
+	opt := func(q, o int) *uint { return &pub.Questions[q].Options[o].ID }
+
+	// strong takers get q0 and q1 right but q2 wrong; weak takers the opposite
+	for user := uint(1); user <= 6; user++ {
+		strong := user <= 3
+		pick := map[bool]int{true: 0, false: 1}
+		_, _, err := qsvc.SubmitAndScore(qz.ID, user, quizzes.SubmitReq{Answers: []quizzes.SubmitAnswer{
+			{QuestionID: qids[0], SelectedOptionID: opt(0, pick[strong])},
+			{QuestionID: qids[1], SelectedOptionID: opt(1, pick[strong])},
+			{QuestionID: qids[2], SelectedOptionID: opt(2, pick[!strong])},
+		}})
+		require.NoError(t, err)
+	}
+
+	ia, err := analytics.NewService(d).ItemAnalysis(qz.ID)
+	require.NoError(t, err)
+	require.EqualValues(t, 6, ia.Scored)
+	require.Len(t, ia.Items, 3)
+
+	good, bad := ia.Items[0], ia.Items[2]
+	require.InDelta(t, 0.5, *good.PValue, 1e-9)
+	require.Greater(t, *good.Discrimination, 0.0)
+	require.Less(t, *bad.Discrimination, 0.0)
+	require.Contains(t, bad.Flags, analytics.FlagNegativeDiscrimination)
+	require.Contains(t, good.Flags, analytics.FlagUnusedDistractor)
+	// ties at the cut-off put all three strong takers in the top group
+	require.EqualValues(t, 3, ia.TopGroupSize)
+	require.EqualValues(t, 3, good.Options[0].TopSelected)
+	require.EqualValues(t, 0, good.Options[0].BottomSelected)
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,11 @@
+package attachments
+
+type UploadResp struct {
+	ID          uint   `json:"id"`
+	URL         string `json:"url"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	// Markdown is a ready-to-paste image snippet for question/option text.
+	Markdown string `json:"markdown"`
+}
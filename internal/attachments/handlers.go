@@ -0,0 +1,77 @@
+package attachments
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"quizapi/internal/storage"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) Upload(c *gin.Context) {
+	fh, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "multipart field 'file' required"})
+		return
+	}
+	if fh.Size > MaxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file exceeds %d bytes", MaxSize)})
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+
+	a, err := h.svc.Upload(c.Request.Context(), fh.Filename, f)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	url := fmt.Sprintf("/attachments/%d", a.ID)
+	c.JSON(http.StatusCreated, UploadResp{
+		ID:          a.ID,
+		URL:         url,
+		Filename:    a.Filename,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		Markdown:    fmt.Sprintf("![%s](%s)", a.Filename, url),
+	})
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("attachmentID"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attachmentID"})
+		return
+	}
+	a, rc, err := h.svc.Open(c.Request.Context(), uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "attachment not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rc.Close()
+
+	// attachments are immutable, so they can be cached aggressively
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, a.Size, a.ContentType, rc, nil)
+}
@@ -0,0 +1,116 @@
+package attachments
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"gorm.io/gorm"
+
+	"quizapi/internal/models"
+	"quizapi/internal/storage"
+)
+
+// MaxSize caps a single upload (5 MiB).
+const MaxSize = 5 << 20
+
+var allowedTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+type Service struct {
+	db    *gorm.DB
+	store storage.Storage
+}
+
+func NewService(db *gorm.DB, store storage.Storage) *Service {
+	return &Service{db: db, store: store}
+}
+
+// Upload sniffs the content type, stores the blob and records its metadata.
+// Only images are accepted; the client-supplied type is ignored.
+func (s *Service) Upload(ctx context.Context, filename string, r io.Reader) (*models.Attachment, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("empty file")
+		}
+		return nil, err
+	}
+	head = head[:n]
+	ctype := http.DetectContentType(head)
+	ext, ok := allowedTypes[ctype]
+	if !ok {
+		return nil, fmt.Errorf("unsupported content type %s", ctype)
+	}
+
+	key, err := newKey(ext)
+	if err != nil {
+		return nil, err
+	}
+	body := &countingReader{r: io.MultiReader(bytes.NewReader(head), io.LimitReader(r, MaxSize-int64(n)+1))}
+	if err := s.store.Put(ctx, key, body); err != nil {
+		return nil, err
+	}
+	if body.n > MaxSize {
+		_ = s.store.Delete(ctx, key)
+		return nil, fmt.Errorf("file exceeds %d bytes", MaxSize)
+	}
+
+	a := &models.Attachment{
+		Filename:    filename,
+		ContentType: ctype,
+		Size:        body.n,
+		StorageKey:  key,
+	}
+	if err := s.db.Create(a).Error; err != nil {
+		_ = s.store.Delete(ctx, key)
+		return nil, err
+	}
+	return a, nil
+}
+
+// Open returns the attachment metadata and a reader for its content.
+func (s *Service) Open(ctx context.Context, id uint) (*models.Attachment, io.ReadCloser, error) {
+	var a models.Attachment
+	if err := s.db.First(&a, id).Error; err != nil {
+		return nil, nil, err
+	}
+	rc, err := s.store.Open(ctx, a.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &a, rc, nil
+}
+
+// --- helpers ---
+
+func newKey(ext string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	h := hex.EncodeToString(b)
+	// shard by prefix so a single directory doesn't grow unbounded
+	return h[:2] + "/" + h + ext, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -2,7 +2,9 @@
 package auth
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"quizapi/internal/models"
@@ -11,10 +13,16 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// AuthMiddleware checks for a valid JWT token
+// AuthMiddleware checks for a valid JWT token. Browsers can't set headers
+// on WebSocket handshakes or EventSource requests, so those may pass the
+// token as ?token= instead.
 func (s *Service) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
+		streaming := c.IsWebsocket() || c.GetHeader("Accept") == "text/event-stream"
+		if authHeader == "" && streaming && c.Query("token") != "" {
+			authHeader = "Bearer " + c.Query("token")
+		}
 		if authHeader == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authorization header required"})
 			return
@@ -42,6 +50,43 @@ func (s *Service) AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// Logger is gin's request logger with any ?token= value masked, so JWTs
+// passed by streaming clients don't end up in access logs.
+func Logger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(p gin.LogFormatterParams) string {
+		return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v\n%s",
+			p.TimeStamp.Format("2006/01/02 - 15:04:05"), p.StatusCode, p.Latency, p.ClientIP,
+			p.Method, redactToken(p.Path), p.ErrorMessage)
+	})
+}
+
+func redactToken(path string) string {
+	u, err := url.Parse(path)
+	if err != nil {
+		return path
+	}
+	q := u.Query()
+	if !q.Has("token") {
+		return path
+	}
+	q.Set("token", "REDACTED")
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// OptionalAuthMiddleware lets anonymous requests through, but validates a
+// token if one is sent so handlers can tailor the response to the user.
+func (s *Service) OptionalAuthMiddleware() gin.HandlerFunc {
+	required := s.AuthMiddleware()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		required(c)
+	}
+}
+
 // RoleMiddleware checks if the user has the required role
 func RoleMiddleware(requiredRole models.Role) gin.HandlerFunc {
 	return func(c *gin.Context) {
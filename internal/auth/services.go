@@ -56,6 +56,11 @@ func (s *Service) LoginUser(username, password string) (string, error) {
 		return "", errors.New("invalid credentials")
 	}
 
+	return s.IssueToken(&user)
+}
+
+// IssueToken returns a signed 24h JWT for the user.
+func (s *Service) IssueToken(user *models.User) (string, error) {
 	expirationTime := time.Now().Add(24 * time.Hour)
 	claims := &Claims{
 		UserID: user.ID,
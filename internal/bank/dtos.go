@@ -0,0 +1,42 @@
+package bank
+
+import (
+	"quizapi/internal/models"
+	"quizapi/internal/quizzes"
+)
+
+// QuestionReq creates a bank question or replaces its content with a new
+// version. When updating, an option that carries the ID of an option in the
+// current version continues it, so past answers to linked quiz questions
+// can be regraded against the new version; options without an ID are new.
+// An update leaves the difficulty and tags unchanged when they are omitted;
+// an empty tags list clears them.
+type QuestionReq struct {
+	Text        string                         `json:"text" validate:"required,min=1"`
+	Type        string                         `json:"type" validate:"required,oneof=single multiple text"`
+	WordLimit   *int                           `json:"word_limit"`
+	Explanation string                         `json:"explanation"`
+	Options     []quizzes.UpdateQuestionOption `json:"options" validate:"dive"`
+	Difficulty  *int                           `json:"difficulty" validate:"omitempty,min=1,max=5"`
+	Tags        []string                       `json:"tags" validate:"dive,min=1,max=64"`
+}
+
+// LinkReq adds a bank question to a quiz. If Version is set the quiz
+// question is pinned to it; otherwise it follows the latest version.
+type LinkReq struct {
+	BankQuestionID uint  `json:"bank_question_id" validate:"required"`
+	Version        *int  `json:"version" validate:"omitempty,min=1"`
+	SectionID      *uint `json:"section_id"`
+}
+
+type QuestionResp struct {
+	models.BankQuestion
+	Latest *models.BankQuestionVersion `json:"latest"`
+}
+
+type ListResp struct {
+	Questions    []models.BankQuestion `json:"questions"`
+	TotalRecords int64                 `json:"total_records"`
+	Page         int                   `json:"page"`
+	Limit        int                   `json:"limit"`
+}
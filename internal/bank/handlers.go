@@ -0,0 +1,137 @@
+package bank
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type Handler struct {
+	svc *Service
+	val *validator.Validate
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc, val: validator.New()}
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	var req QuestionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	bq, err := h.svc.Create(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, bq)
+}
+
+func (h *Handler) List(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	difficulty, _ := strconv.Atoi(c.Query("difficulty"))
+
+	qs, total, err := h.svc.List(c.Query("tag"), difficulty, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ListResp{Questions: qs, TotalRecords: total, Page: page, Limit: limit})
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("bankID"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bankID"})
+		return
+	}
+	bq, err := h.svc.Get(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, bq)
+}
+
+func (h *Handler) GetVersion(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("bankID"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bankID"})
+		return
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+		return
+	}
+	v, err := h.svc.GetVersion(uint(id), version)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, v)
+}
+
+func (h *Handler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("bankID"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bankID"})
+		return
+	}
+	var req QuestionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	bq, err := h.svc.Update(uint(id), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, bq)
+}
+
+func (h *Handler) LinkToQuiz(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	var req LinkReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	q, err := h.svc.LinkToQuiz(uint(quizID), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": q.ID, "bank_version_id": q.BankVersionID})
+}
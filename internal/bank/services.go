@@ -0,0 +1,358 @@
+package bank
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"quizapi/internal/models"
+	"quizapi/internal/quizzes"
+	"quizapi/internal/revisions"
+)
+
+type Service struct{ db *gorm.DB }
+
+func NewService(db *gorm.DB) *Service { return &Service{db: db} }
+
+// Create validates the content and stores it as version 1.
+func (s *Service) Create(req QuestionReq) (*QuestionResp, error) {
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+	bq := &models.BankQuestion{Difficulty: difficultyOrDefault(req.Difficulty), CurrentVersion: 1}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(bq).Error; err != nil {
+			return err
+		}
+		if err := setTags(tx, bq, req.Tags); err != nil {
+			return err
+		}
+		_, err := createVersion(tx, bq.ID, 1, req, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(bq.ID)
+}
+
+// Get returns a bank question with its tags and latest version.
+func (s *Service) Get(id uint) (*QuestionResp, error) {
+	var bq models.BankQuestion
+	if err := s.db.Preload("Tags").First(&bq, id).Error; err != nil {
+		return nil, fmt.Errorf("bank question %d not found", id)
+	}
+	v, err := s.GetVersion(id, bq.CurrentVersion)
+	if err != nil {
+		return nil, err
+	}
+	return &QuestionResp{BankQuestion: bq, Latest: v}, nil
+}
+
+func (s *Service) GetVersion(id uint, version int) (*models.BankQuestionVersion, error) {
+	var v models.BankQuestionVersion
+	if err := s.db.Preload("Options", quizzes.ByPosition).
+		Where("bank_question_id = ? AND version = ?", id, version).First(&v).Error; err != nil {
+		return nil, fmt.Errorf("version %d of bank question %d not found", version, id)
+	}
+	return &v, nil
+}
+
+// List pages through bank questions, optionally filtered by tag and difficulty.
+func (s *Service) List(tag string, difficulty, page, limit int) ([]models.BankQuestion, int64, error) {
+	q := s.db.Model(&models.BankQuestion{})
+	if tag != "" {
+		q = q.Joins("JOIN bank_question_tags bqt ON bqt.bank_question_id = bank_questions.id").
+			Joins("JOIN tags ON tags.id = bqt.tag_id").
+			Where("tags.name = ?", normalizeTag(tag))
+	}
+	if difficulty > 0 {
+		q = q.Where("bank_questions.difficulty = ?", difficulty)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []models.BankQuestion
+	err := q.Preload("Tags").Offset((page - 1) * limit).Limit(limit).
+		Order("bank_questions.id desc").Find(&out).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
+
+// Update stores req as a new version and updates tags/difficulty in place.
+// Quiz questions linked to this entry without a pin are moved to the new
+// version the way quizzes.UpdateQuestion edits a question: unchanged
+// options are kept, changed ones are replaced by rows linked to the old
+// ones, and the rest are soft-deleted so past answers stay intact.
+func (s *Service) Update(id uint, req QuestionReq) (*QuestionResp, error) {
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var bq models.BankQuestion
+		if err := tx.First(&bq, id).Error; err != nil {
+			return fmt.Errorf("bank question %d not found", id)
+		}
+		var prev models.BankQuestionVersion
+		if err := tx.Preload("Options").Where("bank_question_id = ? AND version = ?", bq.ID, bq.CurrentVersion).
+			First(&prev).Error; err != nil {
+			return err
+		}
+		bq.CurrentVersion++
+		if req.Difficulty != nil {
+			bq.Difficulty = *req.Difficulty
+		}
+		if err := tx.Save(&bq).Error; err != nil {
+			return err
+		}
+		if req.Tags != nil {
+			if err := setTags(tx, &bq, req.Tags); err != nil {
+				return err
+			}
+		}
+		v, err := createVersion(tx, bq.ID, bq.CurrentVersion, req, &prev)
+		if err != nil {
+			return err
+		}
+
+		var linked []models.Question
+		if err := tx.Where("bank_question_id = ? AND bank_pinned = ?", id, false).
+			Find(&linked).Error; err != nil {
+			return err
+		}
+		for i := range linked {
+			if err := materialize(tx, &linked[i], v, bq.Difficulty); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(id)
+}
+
+// LinkToQuiz copies a bank version into the quiz as a new question that
+// keeps a reference back to the bank entry.
+func (s *Service) LinkToQuiz(quizID uint, req LinkReq) (*models.Question, error) {
+	var bq models.BankQuestion
+	if err := s.db.First(&bq, req.BankQuestionID).Error; err != nil {
+		return nil, fmt.Errorf("bank question %d not found", req.BankQuestionID)
+	}
+	version := bq.CurrentVersion
+	if req.Version != nil {
+		version = *req.Version
+	}
+	v, err := s.GetVersion(bq.ID, version)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.First(&models.Quiz{}, quizID).Error; err != nil {
+		return nil, fmt.Errorf("quiz %d not found", quizID)
+	}
+	if err := quizzes.CheckSection(s.db, quizID, req.SectionID); err != nil {
+		return nil, err
+	}
+
+	q := &models.Question{
+		QuizID:         quizID,
+		SectionID:      req.SectionID,
+		BankQuestionID: &bq.ID,
+		BankPinned:     req.Version != nil,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		pos, err := quizzes.NextPosition(tx.Model(&models.Question{}).Where("quiz_id = ?", quizID))
+		if err != nil {
+			return err
+		}
+		q.Position = pos
+		return materialize(tx, q, v, bq.Difficulty)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// --- helpers ---
+
+// materialize writes version v's content and the bank difficulty onto q
+// (creating q if it is new) and brings q's options in line with v's. An option continuing one that
+// q already shows keeps its row if unchanged, or is replaced by a row
+// whose OriginID links it to the old one; the rest are soft-deleted.
+func materialize(tx *gorm.DB, q *models.Question, v *models.BankQuestionVersion, difficulty int) error {
+	current, byKey, err := lineage(tx, q)
+	if err != nil {
+		return err
+	}
+	q.Text = v.Text
+	q.Type = v.Type
+	q.WordLimit = v.WordLimit
+	q.Explanation = v.Explanation
+	q.Difficulty = logit(difficulty)
+	q.BankVersionID = &v.ID
+	if err := tx.Omit("Options").Save(q).Error; err != nil {
+		return err
+	}
+	kept := map[uint]bool{}
+	for _, o := range v.Options {
+		var origin *uint
+		if old, ok := byKey[o.Key()]; ok {
+			kept[old.ID] = true
+			if old.Text == o.Text && old.IsCorrect == o.IsCorrect {
+				if err := tx.Model(&old).Updates(map[string]any{
+					"position": o.Position, "bank_key": o.Key(),
+				}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err := tx.Delete(&old).Error; err != nil {
+				return err
+			}
+			key := old.Key()
+			origin = &key
+		}
+		bankKey := o.Key()
+		op := &models.Option{QuestionID: q.ID, Position: o.Position, Text: o.Text, IsCorrect: o.IsCorrect,
+			OriginID: origin, BankKey: &bankKey}
+		if err := tx.Create(op).Error; err != nil {
+			return err
+		}
+	}
+	for _, old := range current {
+		if !kept[old.ID] {
+			if err := tx.Delete(&old).Error; err != nil {
+				return err
+			}
+		}
+	}
+	_, err = revisions.Record(tx, q)
+	return err
+}
+
+// lineage returns q's live options and, keyed by bank option lineage, the
+// ones materialized from the bank version q currently shows. Options are
+// matched by their BankKey, so reordering them in the quiz keeps the
+// match; options copied before BankKey was stored fall back to matching
+// by position and content.
+func lineage(tx *gorm.DB, q *models.Question) ([]models.Option, map[uint]models.Option, error) {
+	byKey := map[uint]models.Option{}
+	if q.ID == 0 {
+		return nil, byKey, nil
+	}
+	var current []models.Option
+	if err := tx.Where("question_id = ?", q.ID).Find(&current).Error; err != nil {
+		return nil, nil, err
+	}
+	if q.BankVersionID == nil {
+		return current, byKey, nil
+	}
+	var shown []models.BankOption
+	if err := tx.Where("version_id = ?", *q.BankVersionID).Find(&shown).Error; err != nil {
+		return nil, nil, err
+	}
+	byPos := make(map[int]models.Option, len(current))
+	for _, o := range current {
+		if o.BankKey != nil {
+			byKey[*o.BankKey] = o
+		} else {
+			byPos[o.Position] = o
+		}
+	}
+	for _, bo := range shown {
+		if _, ok := byKey[bo.Key()]; ok {
+			continue
+		}
+		if o, ok := byPos[bo.Position]; ok && o.Text == bo.Text && o.IsCorrect == bo.IsCorrect {
+			byKey[bo.Key()] = o
+		}
+	}
+	return current, byKey, nil
+}
+
+func validate(req QuestionReq) error {
+	opts := make([]quizzes.CreateQuestionOption, 0, len(req.Options))
+	for _, o := range req.Options {
+		opts = append(opts, o.CreateQuestionOption)
+	}
+	return quizzes.ValidateQuestion(models.QuestionType(req.Type), req.WordLimit, opts)
+}
+
+// createVersion stores req as the given version. Options carrying an ID
+// continue that option of prev, the version being replaced (nil for the
+// first version).
+func createVersion(tx *gorm.DB, bankID uint, version int, req QuestionReq, prev *models.BankQuestionVersion) (*models.BankQuestionVersion, error) {
+	v := &models.BankQuestionVersion{
+		BankQuestionID: bankID,
+		Version:        version,
+		Text:           req.Text,
+		Type:           models.QuestionType(req.Type),
+		WordLimit:      req.WordLimit,
+		Explanation:    req.Explanation,
+	}
+	byID := map[uint]models.BankOption{}
+	if prev != nil {
+		for _, o := range prev.Options {
+			byID[o.ID] = o
+		}
+	}
+	used := map[uint]bool{}
+	for i, o := range req.Options {
+		bo := models.BankOption{
+			Position:  i,
+			Text:      o.Text,
+			IsCorrect: o.IsCorrect != nil && *o.IsCorrect,
+		}
+		if o.ID != nil {
+			old, ok := byID[*o.ID]
+			if !ok || used[old.ID] {
+				return nil, fmt.Errorf("option %d invalid for bank question %d", *o.ID, bankID)
+			}
+			used[old.ID] = true
+			key := old.Key()
+			bo.OriginID = &key
+		}
+		v.Options = append(v.Options, bo)
+	}
+	return v, tx.Create(v).Error
+}
+
+func setTags(tx *gorm.DB, bq *models.BankQuestion, names []string) error {
+	tags := make([]models.Tag, 0, len(names))
+	seen := map[string]bool{}
+	for _, n := range names {
+		n = normalizeTag(n)
+		if n == "" || seen[n] {
+			continue
+		}
+		seen[n] = true
+		t := models.Tag{Name: n}
+		if err := tx.Where(models.Tag{Name: n}).FirstOrCreate(&t).Error; err != nil {
+			return err
+		}
+		tags = append(tags, t)
+	}
+	return tx.Model(bq).Association("Tags").Replace(tags)
+}
+
+func normalizeTag(t string) string {
+	return strings.ToLower(strings.TrimSpace(t))
+}
+
+func difficultyOrDefault(d *int) int {
+	if d == nil {
+		return 3
+	}
+	return *d
+}
+
+// logit maps a bank difficulty rating (1 easy .. 5 hard) onto the logit
+// scale adaptive quizzes use: 3 is average (0) and 1 and 5 are ±3.
+func logit(d int) float64 { return float64(d-3) * 1.5 }
@@ -0,0 +1,161 @@
+package bank_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"quizapi/internal/bank"
+	"quizapi/internal/models"
+	"quizapi/internal/quizzes"
+	"quizapi/internal/revisions"
+	"quizapi/internal/testutil"
+)
+
+func TestUpdate_PropagatesToUnpinnedOnly(t *testing.T) {
+	d := testutil.DB(t)
+	quizSvc := quizzes.NewService(d)
+	svc := bank.NewService(d)
+
+	yes, no := true, false
+	bq, err := svc.Create(bank.QuestionReq{
+		Text: "2+2?", Type: "single", Tags: []string{"Math"}, Difficulty: ptr(1),
+		Options: []quizzes.UpdateQuestionOption{opt("4", yes, nil), opt("5", no, nil)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "math", bq.Tags[0].Name)
+
+	qz, err := quizSvc.CreateQuiz("linked")
+	require.NoError(t, err)
+	follow, err := svc.LinkToQuiz(qz.ID, bank.LinkReq{BankQuestionID: bq.ID})
+	require.NoError(t, err)
+	pinned, err := svc.LinkToQuiz(qz.ID, bank.LinkReq{BankQuestionID: bq.ID, Version: ptr(1)})
+	require.NoError(t, err)
+	require.Equal(t, -3.0, follow.Difficulty) // bank rating 1 on the logit scale
+	_, err = svc.LinkToQuiz(qz.ID, bank.LinkReq{BankQuestionID: bq.ID, SectionID: ptr(uint(999))})
+	require.ErrorContains(t, err, "does not belong to quiz")
+
+	// answer the unpinned copy before the edit
+	pub, err := quizSvc.GetPublicQuestions(qz.ID, learner)
+	require.NoError(t, err)
+	oldOpt := pub.Questions[0].Options[0].ID
+	sub, _, err := quizSvc.SubmitAndScore(qz.ID, learner, quizzes.SubmitReq{Answers: []quizzes.SubmitAnswer{
+		{QuestionID: follow.ID, SelectedOptionID: &oldOpt},
+	}})
+	require.NoError(t, err)
+
+	_, err = svc.Update(bq.ID, bank.QuestionReq{
+		Text: "2+3?", Type: "single", Difficulty: ptr(5),
+		Options: []quizzes.UpdateQuestionOption{opt("4", no, nil), opt("5", yes, nil)},
+	})
+	require.NoError(t, err)
+
+	var followed, kept models.Question
+	require.NoError(t, d.First(&followed, follow.ID).Error)
+	require.Equal(t, "2+3?", followed.Text)
+	require.Equal(t, 3.0, followed.Difficulty)
+	require.NoError(t, d.First(&kept, pinned.ID).Error)
+	require.Equal(t, "2+2?", kept.Text)
+	require.Equal(t, -3.0, kept.Difficulty)
+	updated, err := svc.Get(bq.ID)
+	require.NoError(t, err)
+	require.Len(t, updated.Tags, 1, "omitted tags are left alone")
+	_, err = svc.Update(bq.ID, bank.QuestionReq{
+		Text: "2+3?", Type: "single", Tags: []string{},
+		Options: []quizzes.UpdateQuestionOption{opt("4", no, nil), opt("5", yes, nil)},
+	})
+	require.NoError(t, err)
+	updated, err = svc.Get(bq.ID)
+	require.NoError(t, err)
+	require.Equal(t, 5, updated.Difficulty, "omitted difficulty is left alone")
+	require.Empty(t, updated.Tags)
+
+	// the old answer still points at version 1 and at its original option
+	var ans models.Answer
+	require.NoError(t, d.Where("submission_id = ?", sub.ID).First(&ans).Error)
+	var v models.BankQuestionVersion
+	require.NoError(t, d.First(&v, *ans.BankVersionID).Error)
+	require.Equal(t, 1, v.Version)
+	var opt models.Option
+	require.NoError(t, d.Unscoped().First(&opt, oldOpt).Error)
+	require.True(t, opt.IsCorrect)
+	require.True(t, opt.DeletedAt.Valid)
+}
+
+// learner is the user ID used for quiz takers in tests.
+const learner = 42
+
+func ptr[T any](v T) *T { return &v }
+
+// opt builds a bank option; a non-nil id continues that option of the
+// current version.
+func opt(text string, correct bool, id *uint) quizzes.UpdateQuestionOption {
+	return quizzes.UpdateQuestionOption{ID: id, CreateQuestionOption: quizzes.CreateQuestionOption{Text: text, IsCorrect: &correct}}
+}
+
+func TestUpdate_RegradesAcrossVersions(t *testing.T) {
+	d := testutil.DB(t)
+	quizSvc := quizzes.NewService(d)
+	svc := bank.NewService(d)
+	revSvc := revisions.NewService(d)
+
+	bq, err := svc.Create(bank.QuestionReq{
+		Text: "Largest planet?", Type: "single",
+		Options: []quizzes.UpdateQuestionOption{opt("Saturn", true, nil), opt("Jupiter", false, nil), opt("Mars", false, nil)},
+	})
+	require.NoError(t, err)
+	qz, err := quizSvc.CreateQuiz("planets")
+	require.NoError(t, err)
+	q, err := svc.LinkToQuiz(qz.ID, bank.LinkReq{BankQuestionID: bq.ID})
+	require.NoError(t, err)
+
+	pub, err := quizSvc.GetPublicQuestions(qz.ID, learner)
+	require.NoError(t, err)
+	jupiter := pub.Questions[0].Options[1].ID
+	sub, _, err := quizSvc.SubmitAndScore(qz.ID, learner, quizzes.SubmitReq{Answers: []quizzes.SubmitAnswer{
+		{QuestionID: q.ID, SelectedOptionID: &jupiter},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, 0, sub.Score)
+
+	// reordering the quiz's copy doesn't break its link to the bank options
+	shown := pub.Questions[0].Options
+	require.NoError(t, quizSvc.ReorderQuestions(qz.ID, quizzes.ReorderQuestionsReq{
+		OptionOrders: map[uint][]uint{q.ID: {shown[2].ID, shown[1].ID, shown[0].ID}},
+	}))
+
+	// fix the key and reword Jupiter; both continue their lineage
+	v1 := bq.Latest.Options
+	_, err = svc.Update(bq.ID, bank.QuestionReq{
+		Text: "Largest planet?", Type: "single",
+		Options: []quizzes.UpdateQuestionOption{
+			opt("Saturn", false, &v1[0].ID), opt("Jupiter (gas giant)", true, &v1[1].ID), opt("Mars", false, &v1[2].ID),
+		},
+	})
+	require.NoError(t, err)
+	var mars models.Option
+	require.NoError(t, d.Where("question_id = ? AND text = ?", q.ID, "Mars").First(&mars).Error)
+	require.Equal(t, pub.Questions[0].Options[2].ID, mars.ID, "unchanged options keep their row")
+
+	res, err := revSvc.Regrade(qz.ID, q.ID, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, res.AnswersChanged)
+	require.NoError(t, d.First(sub, sub.ID).Error)
+	require.Equal(t, 1, sub.Score)
+	var entry models.LeaderboardEntry
+	require.NoError(t, d.Where("quiz_id = ? AND user_id = ?", qz.ID, learner).First(&entry).Error)
+	require.Equal(t, 1, entry.BestScore, "the leaderboard follows the regrade")
+
+	// an option dropped without a successor can't be regraded
+	v2, err := svc.Get(bq.ID)
+	require.NoError(t, err)
+	_, err = svc.Update(bq.ID, bank.QuestionReq{
+		Text: "Largest planet?", Type: "single",
+		Options: []quizzes.UpdateQuestionOption{opt("Saturn", false, &v2.Latest.Options[0].ID), opt("Neptune", true, nil)},
+	})
+	require.NoError(t, err)
+	_, err = revSvc.Regrade(qz.ID, q.ID, nil)
+	require.ErrorContains(t, err, "across bank versions")
+	require.NoError(t, d.First(sub, sub.ID).Error)
+	require.Equal(t, 1, sub.Score)
+}
@@ -0,0 +1,37 @@
+package certificates
+
+import "time"
+
+// SettingsReq replaces a quiz's certificate settings. A null pass_percent
+// disables certificates; an empty template uses the default.
+type SettingsReq struct {
+	PassPercent *int   `json:"pass_percent" validate:"omitempty,min=1,max=100"`
+	Template    string `json:"template" validate:"max=10000"`
+}
+
+type RevokeReq struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+// VerifyResp is the public view of a certificate.
+type VerifyResp struct {
+	Code         string     `json:"code"`
+	Valid        bool       `json:"valid"`
+	Recipient    string     `json:"recipient"`
+	QuizTitle    string     `json:"quiz_title"`
+	Percent      int        `json:"percent"`
+	IssuedAt     time.Time  `json:"issued_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	RevokeReason string     `json:"revoke_reason,omitempty"`
+}
+
+// TemplateData is available to certificate templates, e.g. {{.Name}} or
+// {{.IssuedAt.Format "2 January 2006"}}.
+type TemplateData struct {
+	Name      string
+	Quiz      string
+	Percent   int
+	IssuedAt  time.Time
+	Code      string
+	VerifyURL string
+}
@@ -0,0 +1,102 @@
+package certificates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type Handler struct {
+	svc *Service
+	val *validator.Validate
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc, val: validator.New()}
+}
+
+// Verify is public: anyone holding a code can check it.
+func (h *Handler) Verify(c *gin.Context) {
+	resp, err := h.svc.Verify(c.Param("code"))
+	if err != nil {
+		writeErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) PDF(c *gin.Context) {
+	var buf bytes.Buffer
+	if err := h.svc.WritePDF(c.Param("code"), &buf); err != nil {
+		writeErr(c, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="certificate-%s.pdf"`, c.Param("code")))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+func (h *Handler) Mine(c *gin.Context) {
+	certs, err := h.svc.ListForUser(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, certs)
+}
+
+func (h *Handler) Revoke(c *gin.Context) {
+	var req RevokeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	cert, err := h.svc.Revoke(c.Param("code"), req.Reason)
+	if err != nil {
+		writeErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cert)
+}
+
+func (h *Handler) UpdateSettings(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	var req SettingsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	quiz, err := h.svc.UpdateSettings(uint(quizID), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, quiz)
+}
+
+func writeErr(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrRevoked):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,248 @@
+package certificates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"gorm.io/gorm"
+
+	"quizapi/internal/codes"
+	"quizapi/internal/models"
+)
+
+var (
+	ErrNotFound = errors.New("certificate not found")
+	ErrRevoked  = errors.New("certificate has been revoked")
+)
+
+// ReasonBelowThreshold is recorded when a re-grade drops a submission
+// under the pass mark. Only certificates revoked for this reason are
+// re-issued if a later re-grade lifts the score again.
+const ReasonBelowThreshold = "score fell below the pass threshold after re-grading"
+
+// DefaultTemplate is used when a quiz has no template of its own. The
+// first non-empty line is the heading.
+const DefaultTemplate = `Certificate of Completion
+
+This certifies that
+{{.Name}}
+has passed "{{.Quiz}}" with a score of {{.Percent}}%.
+
+Issued {{.IssuedAt.Format "2 January 2006"}}
+Verification code {{.Code}} - {{.VerifyURL}}`
+
+type Service struct {
+	db        *gorm.DB
+	publicURL string
+}
+
+func NewService(db *gorm.DB, publicURL string) *Service {
+	return &Service{db: db, publicURL: strings.TrimRight(publicURL, "/")}
+}
+
+// Sync issues or revokes the certificate for sub so that it matches the
+// quiz's pass threshold. It runs inside the transaction that scored sub.
+// Invalidated submissions never get a certificate.
+func Sync(tx *gorm.DB, sub *models.Submission) error {
+	if sub.UserID == 0 || sub.InvalidatedAt != nil {
+		return nil
+	}
+	var quiz models.Quiz
+	if err := tx.First(&quiz, sub.QuizID).Error; err != nil {
+		return err
+	}
+	if quiz.PassPercent == nil {
+		return nil // existing certificates stay valid if certificates are turned off
+	}
+	var certs []models.Certificate
+	if err := tx.Where("submission_id = ?", sub.ID).Order("id").Find(&certs).Error; err != nil {
+		return err
+	}
+	passed := sub.Total > 0 && sub.Percent >= *quiz.PassPercent
+	for _, c := range certs {
+		switch {
+		case c.RevokedAt == nil && passed:
+			return nil
+		case c.RevokedAt == nil:
+			return revoke(tx.Where("id = ?", c.ID), ReasonBelowThreshold)
+		case c.RevokeReason != ReasonBelowThreshold:
+			return nil // revoked by an admin or invalidation; a re-grade doesn't undo that
+		}
+	}
+	if !passed {
+		return nil
+	}
+	var user models.User
+	if err := tx.First(&user, sub.UserID).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.Certificate{
+		Code:         codes.New(),
+		QuizID:       quiz.ID,
+		UserID:       user.ID,
+		SubmissionID: sub.ID,
+		Recipient:    user.Username,
+		QuizTitle:    quiz.Title,
+		Percent:      sub.Percent,
+		IssuedAt:     time.Now(),
+	}).Error
+}
+
+// RevokeForSubmission revokes every valid certificate issued for the
+// submission, e.g. when the submission is invalidated.
+func RevokeForSubmission(tx *gorm.DB, submissionID uint, reason string) error {
+	return revoke(tx.Where("submission_id = ?", submissionID), reason)
+}
+
+func revoke(scoped *gorm.DB, reason string) error {
+	return scoped.Model(&models.Certificate{}).Where("revoked_at IS NULL").
+		Updates(map[string]any{"revoked_at": time.Now(), "revoke_reason": reason}).Error
+}
+
+// Revoke revokes a certificate by code.
+func (s *Service) Revoke(code, reason string) (*models.Certificate, error) {
+	c, err := s.get(code)
+	if err != nil {
+		return nil, err
+	}
+	if c.RevokedAt == nil {
+		if err := revoke(s.db.Where("id = ?", c.ID), reason); err != nil {
+			return nil, err
+		}
+	}
+	return s.get(code)
+}
+
+func (s *Service) Verify(code string) (*VerifyResp, error) {
+	c, err := s.get(code)
+	if err != nil {
+		return nil, err
+	}
+	return &VerifyResp{
+		Code:         c.Code,
+		Valid:        c.RevokedAt == nil,
+		Recipient:    c.Recipient,
+		QuizTitle:    c.QuizTitle,
+		Percent:      c.Percent,
+		IssuedAt:     c.IssuedAt,
+		RevokedAt:    c.RevokedAt,
+		RevokeReason: c.RevokeReason,
+	}, nil
+}
+
+func (s *Service) ListForUser(userID uint) ([]models.Certificate, error) {
+	var out []models.Certificate
+	return out, s.db.Where("user_id = ?", userID).Order("issued_at desc").Find(&out).Error
+}
+
+// UpdateSettings sets the quiz's pass threshold and template. The template
+// is rendered once with sample data so mistakes surface here rather than
+// when someone downloads a certificate.
+func (s *Service) UpdateSettings(quizID uint, req SettingsReq) (*models.Quiz, error) {
+	var quiz models.Quiz
+	if err := s.db.First(&quiz, quizID).Error; err != nil {
+		return nil, fmt.Errorf("quiz %d not found", quizID)
+	}
+	if req.Template != "" {
+		if _, err := render(req.Template, TemplateData{Name: "Ada", Quiz: quiz.Title, Percent: 100, IssuedAt: time.Now(), Code: "SAMPLE"}); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.db.Model(&quiz).Updates(map[string]any{
+		"pass_percent": req.PassPercent, "certificate_template": req.Template,
+	}).Error; err != nil {
+		return nil, err
+	}
+	quiz.PassPercent, quiz.CertificateTemplate = req.PassPercent, req.Template
+	return &quiz, nil
+}
+
+// WritePDF renders a valid certificate as a landscape A4 PDF.
+func (s *Service) WritePDF(code string, w io.Writer) error {
+	c, err := s.get(code)
+	if err != nil {
+		return err
+	}
+	if c.RevokedAt != nil {
+		return ErrRevoked
+	}
+	var quiz models.Quiz
+	if err := s.db.First(&quiz, c.QuizID).Error; err != nil {
+		return err
+	}
+	text, err := render(quiz.CertificateTemplate, TemplateData{
+		Name:      c.Recipient,
+		Quiz:      c.QuizTitle,
+		Percent:   c.Percent,
+		IssuedAt:  c.IssuedAt,
+		Code:      c.Code,
+		VerifyURL: s.publicURL + "/certificates/" + c.Code,
+	})
+	if err != nil {
+		return err
+	}
+
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetTitle("Certificate "+c.Code, true)
+	pdf.SetMargins(25, 30, 25)
+	pdf.AddPage()
+	w0, h0 := pdf.GetPageSize()
+	pdf.SetLineWidth(1.5)
+	pdf.Rect(10, 10, w0-20, h0-20, "D")
+	pdf.SetLineWidth(0.4)
+	pdf.Rect(14, 14, w0-28, h0-28, "D")
+
+	tr := pdf.UnicodeTranslatorFromDescriptor("") // core fonts are cp1252
+	heading := true
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			pdf.Ln(6)
+		case heading:
+			pdf.SetFont("Helvetica", "B", 30)
+			pdf.MultiCell(0, 14, tr(line), "", "C", false)
+			pdf.Ln(4)
+			heading = false
+		default:
+			pdf.SetFont("Helvetica", "", 15)
+			pdf.MultiCell(0, 9, tr(line), "", "C", false)
+		}
+	}
+	return pdf.Output(w)
+}
+
+// --- helpers ---
+
+func (s *Service) get(code string) (*models.Certificate, error) {
+	var c models.Certificate
+	res := s.db.Where("code = ?", strings.ToUpper(code)).Limit(1).Find(&c)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+	return &c, nil
+}
+
+func render(tmpl string, data TemplateData) (string, error) {
+	if tmpl == "" {
+		tmpl = DefaultTemplate
+	}
+	t, err := template.New("certificate").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid certificate template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("invalid certificate template: %w", err)
+	}
+	return buf.String(), nil
+}
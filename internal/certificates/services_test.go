@@ -0,0 +1,100 @@
+package certificates_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"quizapi/internal/certificates"
+	"quizapi/internal/models"
+	"quizapi/internal/quizzes"
+	"quizapi/internal/revisions"
+	"quizapi/internal/testutil"
+)
+
+const learner = 42
+
+func ptr[T any](v T) *T { return &v }
+
+func TestCertificateLifecycle(t *testing.T) {
+	d := testutil.DB(t)
+	require.NoError(t, d.Create(&models.User{ID: learner, Username: "ada", PasswordHash: "x", Role: models.RoleUser}).Error)
+	qsvc := quizzes.NewService(d)
+	csvc := certificates.NewService(d, "https://quiz.test")
+
+	qz, err := qsvc.CreateQuiz("Safety")
+	require.NoError(t, err)
+	var qs []*models.Question
+	for _, text := range []string{"Exit?", "Alarm?"} {
+		q, err := qsvc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{Text: text, Type: "single",
+			Options: []quizzes.CreateQuestionOption{{Text: "yes", IsCorrect: ptr(true)}, {Text: "no", IsCorrect: ptr(false)}}})
+		require.NoError(t, err)
+		qs = append(qs, q)
+	}
+	_, err = csvc.UpdateSettings(qz.ID, certificates.SettingsReq{Template: "{{.Nope}}"})
+	require.Error(t, err)
+	_, err = csvc.UpdateSettings(qz.ID, certificates.SettingsReq{PassPercent: ptr(50), Template: "Well done\n{{.Name}} {{.Percent}}%"})
+	require.NoError(t, err)
+
+	var opts []models.Option
+	require.NoError(t, d.Order("question_id, position").Find(&opts).Error)
+	submit := func(firstRight bool) *models.Submission {
+		pick := opts[1].ID
+		if firstRight {
+			pick = opts[0].ID
+		}
+		sub, _, err := qsvc.SubmitAndScore(qz.ID, learner, quizzes.SubmitReq{Answers: []quizzes.SubmitAnswer{
+			{QuestionID: qs[0].ID, SelectedOptionID: &pick},
+			{QuestionID: qs[1].ID, SelectedOptionID: &opts[3].ID},
+		}})
+		require.NoError(t, err)
+		return sub
+	}
+
+	submit(false) // 0%: no certificate
+	sub := submit(true)
+	certs, err := csvc.ListForUser(learner)
+	require.NoError(t, err)
+	require.Len(t, certs, 1)
+	code := certs[0].Code
+	require.Equal(t, sub.ID, certs[0].SubmissionID)
+
+	v, err := csvc.Verify(code)
+	require.NoError(t, err)
+	require.True(t, v.Valid)
+	require.Equal(t, "ada", v.Recipient)
+	require.Equal(t, 50, v.Percent)
+
+	var pdf bytes.Buffer
+	require.NoError(t, csvc.WritePDF(code, &pdf))
+	require.True(t, bytes.HasPrefix(pdf.Bytes(), []byte("%PDF")))
+
+	// a re-grade that drops the score revokes; lifting it again re-issues
+	setCorrect := func(ok bool) {
+		require.NoError(t, d.Model(&models.Answer{}).Where("submission_id = ? AND question_id = ?", sub.ID, qs[0].ID).
+			Update("is_correct", ok).Error)
+		require.NoError(t, d.Transaction(func(tx *gorm.DB) error { return revisions.RescoreSubmission(tx, sub.ID) }))
+	}
+	setCorrect(false)
+	v, err = csvc.Verify(code)
+	require.NoError(t, err)
+	require.False(t, v.Valid)
+	require.Equal(t, certificates.ReasonBelowThreshold, v.RevokeReason)
+	require.ErrorIs(t, csvc.WritePDF(code, &pdf), certificates.ErrRevoked)
+
+	setCorrect(true)
+	certs, err = csvc.ListForUser(learner)
+	require.NoError(t, err)
+	require.Len(t, certs, 2)
+
+	// an admin revocation sticks across re-grades
+	_, err = csvc.Revoke(certs[0].Code, "submission invalidated")
+	require.NoError(t, err)
+	setCorrect(false)
+	setCorrect(true)
+	certs, err = csvc.ListForUser(learner)
+	require.NoError(t, err)
+	require.Len(t, certs, 2)
+}
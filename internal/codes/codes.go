@@ -0,0 +1,11 @@
+// Package codes generates the short human-typable codes used for
+// certificates and quiz access.
+package codes
+
+import "crypto/rand"
+
+// New returns a random code like "K7QX-M2PA-3DFE" (60 bits, base32).
+func New() string {
+	t := rand.Text()
+	return t[0:4] + "-" + t[4:8] + "-" + t[8:12]
+}
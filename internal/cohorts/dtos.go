@@ -0,0 +1,56 @@
+package cohorts
+
+import "time"
+
+type CreateCohortReq struct {
+	Name        string `json:"name" validate:"required,min=1,max=100"`
+	Description string `json:"description"`
+}
+
+type AddMembersReq struct {
+	UserIDs []uint `json:"user_ids" validate:"required,min=1"`
+}
+
+// AssignReq assigns a quiz to a cohort; a null due_at means no deadline.
+type AssignReq struct {
+	DueAt *time.Time `json:"due_at"`
+}
+
+type CohortResp struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	MemberCount int       `json:"member_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Member struct {
+	UserID   uint      `json:"user_id"`
+	Username string    `json:"username"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type CohortDetailResp struct {
+	CohortResp
+	Members []Member `json:"members"`
+}
+
+// Assignment statuses reported by GET /me/assignments.
+const (
+	StatusNotStarted = "not_started"
+	StatusInProgress = "in_progress"
+	StatusSubmitted  = "submitted"
+	StatusOverdue    = "overdue"
+)
+
+// AssignmentResp is one quiz a user has to complete. When the quiz is
+// assigned to several of the user's cohorts, Cohorts lists them all and
+// DueAt is the latest of their deadlines (null if any has none).
+type AssignmentResp struct {
+	QuizID      uint       `json:"quiz_id"`
+	Title       string     `json:"title"`
+	Cohorts     []string   `json:"cohorts"`
+	DueAt       *time.Time `json:"due_at"`
+	Status      string     `json:"status"`
+	SubmittedAt *time.Time `json:"submitted_at,omitempty"`
+}
@@ -0,0 +1,195 @@
+package cohorts
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type Handler struct {
+	svc *Service
+	val *validator.Validate
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc, val: validator.New()}
+}
+
+func (h *Handler) List(c *gin.Context) {
+	out, err := h.svc.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	var req CreateCohortReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	out, err := h.svc.Create(req)
+	if err != nil {
+		writeErr(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	cohortID, err := strconv.Atoi(c.Param("cohortID"))
+	if err != nil || cohortID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cohortID"})
+		return
+	}
+	out, err := h.svc.Get(uint(cohortID))
+	if err != nil {
+		writeErr(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	cohortID, err := strconv.Atoi(c.Param("cohortID"))
+	if err != nil || cohortID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cohortID"})
+		return
+	}
+	if err := h.svc.Delete(uint(cohortID)); err != nil {
+		writeErr(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) AddMembers(c *gin.Context) {
+	cohortID, err := strconv.Atoi(c.Param("cohortID"))
+	if err != nil || cohortID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cohortID"})
+		return
+	}
+	var req AddMembersReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.svc.AddMembers(uint(cohortID), req.UserIDs); err != nil {
+		writeErr(c, err, http.StatusBadRequest)
+		return
+	}
+	out, err := h.svc.Get(uint(cohortID))
+	if err != nil {
+		writeErr(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *Handler) RemoveMember(c *gin.Context) {
+	cohortID, err := strconv.Atoi(c.Param("cohortID"))
+	if err != nil || cohortID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cohortID"})
+		return
+	}
+	userID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil || userID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid userID"})
+		return
+	}
+	if err := h.svc.RemoveMember(uint(cohortID), uint(userID)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) ListAssignments(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	out, err := h.svc.ListAssignments(uint(quizID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *Handler) Assign(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	cohortID, err := strconv.Atoi(c.Param("cohortID"))
+	if err != nil || cohortID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cohortID"})
+		return
+	}
+	var req AssignReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	out, err := h.svc.Assign(uint(quizID), uint(cohortID), req)
+	if err != nil {
+		writeErr(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *Handler) Unassign(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	cohortID, err := strconv.Atoi(c.Param("cohortID"))
+	if err != nil || cohortID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cohortID"})
+		return
+	}
+	if err := h.svc.Unassign(uint(quizID), uint(cohortID)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) Mine(c *gin.Context) {
+	out, err := h.svc.MyAssignments(c.GetUint("userID"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func writeErr(c *gin.Context, err error, fallback int) {
+	switch {
+	case errors.Is(err, ErrCohortNotFound), errors.Is(err, ErrQuizNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrNameTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(fallback, gin.H{"error": err.Error()})
+	}
+}
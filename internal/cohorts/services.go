@@ -0,0 +1,270 @@
+package cohorts
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"quizapi/internal/models"
+)
+
+var (
+	ErrCohortNotFound = errors.New("cohort not found")
+	ErrQuizNotFound   = errors.New("quiz not found")
+	ErrNameTaken      = errors.New("a cohort with that name already exists")
+)
+
+type Service struct{ db *gorm.DB }
+
+func NewService(db *gorm.DB) *Service { return &Service{db: db} }
+
+// Visible restricts a quiz query to quizzes userID may see: those without
+// any assignment, and those assigned to one of the user's cohorts.
+func Visible(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(`NOT EXISTS (SELECT 1 FROM quiz_assignments qa WHERE qa.quiz_id = quizzes.id)
+			OR EXISTS (SELECT 1 FROM quiz_assignments qa JOIN cohort_members cm ON cm.cohort_id = qa.cohort_id
+				WHERE qa.quiz_id = quizzes.id AND cm.user_id = ?)`, userID)
+	}
+}
+
+// CanSee reports whether userID may see (and take) the quiz.
+func CanSee(db *gorm.DB, quizID, userID uint) (bool, error) {
+	var n int64
+	err := db.Model(&models.Quiz{}).Where("quizzes.id = ?", quizID).Scopes(Visible(userID)).Count(&n).Error
+	return n > 0, err
+}
+
+// --- Cohorts ---
+
+func (s *Service) Create(req CreateCohortReq) (*CohortResp, error) {
+	var n int64
+	if err := s.db.Model(&models.Cohort{}).Where("name = ?", req.Name).Count(&n).Error; err != nil {
+		return nil, err
+	}
+	if n > 0 {
+		return nil, ErrNameTaken
+	}
+	c := models.Cohort{Name: req.Name, Description: req.Description}
+	if err := s.db.Create(&c).Error; err != nil {
+		return nil, err
+	}
+	return &CohortResp{ID: c.ID, Name: c.Name, Description: c.Description, CreatedAt: c.CreatedAt}, nil
+}
+
+func (s *Service) List() ([]CohortResp, error) {
+	out := []CohortResp{}
+	err := s.db.Model(&models.Cohort{}).
+		Select("cohorts.id, cohorts.name, cohorts.description, cohorts.created_at, COUNT(cm.user_id) AS member_count").
+		Joins("LEFT JOIN cohort_members cm ON cm.cohort_id = cohorts.id").
+		Group("cohorts.id, cohorts.name, cohorts.description, cohorts.created_at").
+		Order("cohorts.name").Scan(&out).Error
+	return out, err
+}
+
+func (s *Service) Get(cohortID uint) (*CohortDetailResp, error) {
+	var c models.Cohort
+	if err := s.db.First(&c, cohortID).Error; err != nil {
+		return nil, ErrCohortNotFound
+	}
+	members := []Member{}
+	err := s.db.Table("cohort_members cm").
+		Select("cm.user_id, u.username, cm.created_at AS joined_at").
+		Joins("JOIN users u ON u.id = cm.user_id").
+		Where("cm.cohort_id = ?", cohortID).Order("u.username").Scan(&members).Error
+	if err != nil {
+		return nil, err
+	}
+	return &CohortDetailResp{
+		CohortResp: CohortResp{ID: c.ID, Name: c.Name, Description: c.Description, MemberCount: len(members), CreatedAt: c.CreatedAt},
+		Members:    members,
+	}, nil
+}
+
+// Delete removes a cohort with its memberships and assignments. Quizzes
+// left without any assignment become visible to everyone again.
+func (s *Service) Delete(cohortID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Delete(&models.Cohort{}, cohortID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrCohortNotFound
+		}
+		if err := tx.Where("cohort_id = ?", cohortID).Delete(&models.CohortMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("cohort_id = ?", cohortID).Delete(&models.QuizAssignment{}).Error
+	})
+}
+
+// AddMembers adds users to a cohort; users who are already members are
+// left as they are.
+func (s *Service) AddMembers(cohortID uint, userIDs []uint) error {
+	if err := s.db.First(&models.Cohort{}, cohortID).Error; err != nil {
+		return ErrCohortNotFound
+	}
+	ids := slices.Compact(slices.Sorted(slices.Values(userIDs)))
+	var found []uint
+	if err := s.db.Model(&models.User{}).Where("id IN ?", ids).Pluck("id", &found).Error; err != nil {
+		return err
+	}
+	if len(found) != len(ids) {
+		for _, id := range ids {
+			if !slices.Contains(found, id) {
+				return fmt.Errorf("user %d not found", id)
+			}
+		}
+	}
+	rows := make([]models.CohortMember, len(ids))
+	for i, id := range ids {
+		rows[i] = models.CohortMember{CohortID: cohortID, UserID: id}
+	}
+	return s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+func (s *Service) RemoveMember(cohortID, userID uint) error {
+	res := s.db.Where("cohort_id = ? AND user_id = ?", cohortID, userID).Delete(&models.CohortMember{})
+	if res.Error == nil && res.RowsAffected == 0 {
+		return fmt.Errorf("user %d is not a member of cohort %d", userID, cohortID)
+	}
+	return res.Error
+}
+
+// --- Assignments ---
+
+// Assign assigns a quiz to a cohort, or updates the due date if it
+// already is. From then on the quiz is hidden from non-members.
+func (s *Service) Assign(quizID, cohortID uint, req AssignReq) (*models.QuizAssignment, error) {
+	if err := s.db.First(&models.Quiz{}, quizID).Error; err != nil {
+		return nil, ErrQuizNotFound
+	}
+	if err := s.db.First(&models.Cohort{}, cohortID).Error; err != nil {
+		return nil, ErrCohortNotFound
+	}
+	a := models.QuizAssignment{QuizID: quizID, CohortID: cohortID}
+	if req.DueAt != nil {
+		due := req.DueAt.UTC()
+		a.DueAt = &due
+	}
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "quiz_id"}, {Name: "cohort_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"due_at", "updated_at"}),
+	}).Create(&a).Error
+	if err != nil {
+		return nil, err
+	}
+	return &a, s.db.Where("quiz_id = ? AND cohort_id = ?", quizID, cohortID).First(&a).Error
+}
+
+func (s *Service) ListAssignments(quizID uint) ([]models.QuizAssignment, error) {
+	out := []models.QuizAssignment{}
+	return out, s.db.Where("quiz_id = ?", quizID).Order("cohort_id").Find(&out).Error
+}
+
+func (s *Service) Unassign(quizID, cohortID uint) error {
+	res := s.db.Where("quiz_id = ? AND cohort_id = ?", quizID, cohortID).Delete(&models.QuizAssignment{})
+	if res.Error == nil && res.RowsAffected == 0 {
+		return fmt.Errorf("quiz %d is not assigned to cohort %d", quizID, cohortID)
+	}
+	return res.Error
+}
+
+// MyAssignments lists the quizzes assigned to any of the user's cohorts,
+// soonest deadline first (quizzes without one last).
+func (s *Service) MyAssignments(userID uint, now time.Time) ([]AssignmentResp, error) {
+	var rows []struct {
+		QuizID uint
+		Title  string
+		Cohort string
+		DueAt  *time.Time
+	}
+	err := s.db.Table("quiz_assignments qa").
+		Select("qa.quiz_id, q.title, c.name AS cohort, qa.due_at").
+		Joins("JOIN cohort_members cm ON cm.cohort_id = qa.cohort_id AND cm.user_id = ?", userID).
+		Joins("JOIN quizzes q ON q.id = qa.quiz_id").
+		Joins("JOIN cohorts c ON c.id = qa.cohort_id").
+		Order("qa.quiz_id, c.name").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	out := []AssignmentResp{}
+	byQuiz := map[uint]int{}
+	var noDeadline []uint
+	for _, r := range rows {
+		i, ok := byQuiz[r.QuizID]
+		if !ok {
+			i = len(out)
+			byQuiz[r.QuizID] = i
+			out = append(out, AssignmentResp{QuizID: r.QuizID, Title: r.Title, DueAt: r.DueAt})
+		}
+		a := &out[i]
+		a.Cohorts = append(a.Cohorts, r.Cohort)
+		switch {
+		case r.DueAt == nil:
+			noDeadline = append(noDeadline, r.QuizID)
+		case a.DueAt != nil && r.DueAt.After(*a.DueAt):
+			a.DueAt = r.DueAt
+		}
+	}
+	if len(out) == 0 {
+		return out, nil
+	}
+	for _, id := range noDeadline {
+		out[byQuiz[id]].DueAt = nil
+	}
+
+	quizIDs := make([]uint, len(out))
+	for i, a := range out {
+		quizIDs[i] = a.QuizID
+	}
+	var subs []models.Submission
+	if err := s.db.Select("quiz_id, created_at").Where("user_id = ? AND quiz_id IN ?", userID, quizIDs).
+		Order("created_at").Find(&subs).Error; err != nil {
+		return nil, err
+	}
+	submitted := map[uint]time.Time{}
+	for _, sub := range subs {
+		submitted[sub.QuizID] = sub.CreatedAt // latest wins
+	}
+	var open []uint
+	if err := s.db.Model(&models.Attempt{}).Where("user_id = ? AND quiz_id IN ? AND submitted_at IS NULL", userID, quizIDs).
+		Pluck("quiz_id", &open).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range out {
+		a := &out[i]
+		if at, ok := submitted[a.QuizID]; ok {
+			a.Status, a.SubmittedAt = StatusSubmitted, &at
+			continue
+		}
+		switch {
+		case a.DueAt != nil && !now.Before(*a.DueAt):
+			a.Status = StatusOverdue
+		case slices.Contains(open, a.QuizID):
+			a.Status = StatusInProgress
+		default:
+			a.Status = StatusNotStarted
+		}
+	}
+	slices.SortStableFunc(out, func(a, b AssignmentResp) int {
+		switch {
+		case a.DueAt == nil && b.DueAt == nil:
+			return cmp.Compare(a.QuizID, b.QuizID)
+		case a.DueAt == nil:
+			return 1
+		case b.DueAt == nil:
+			return -1
+		}
+		return a.DueAt.Compare(*b.DueAt)
+	})
+	return out, nil
+}
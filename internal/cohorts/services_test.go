@@ -0,0 +1,92 @@
+package cohorts_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"quizapi/internal/cohorts"
+	"quizapi/internal/models"
+	"quizapi/internal/quizzes"
+	"quizapi/internal/testutil"
+)
+
+const learner = 42
+
+func ptr[T any](v T) *T { return &v }
+
+func TestAssignmentsHideQuizzesAndReportStatus(t *testing.T) {
+	d := testutil.DB(t)
+	for _, u := range []models.User{{ID: learner, Username: "ada"}, {ID: learner + 1, Username: "bob"}} {
+		u.PasswordHash, u.Role = "x", models.RoleUser
+		require.NoError(t, d.Create(&u).Error)
+	}
+	qsvc := quizzes.NewService(d)
+	csvc := cohorts.NewService(d)
+
+	var quizIDs []uint
+	for _, title := range []string{"Open to all", "Due soon", "Overdue", "No deadline"} {
+		qz, err := qsvc.CreateQuiz(title)
+		require.NoError(t, err)
+		_, err = qsvc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{Text: "Why?", Type: "text", WordLimit: ptr(50)})
+		require.NoError(t, err)
+		quizIDs = append(quizIDs, qz.ID)
+	}
+
+	c, err := csvc.Create(cohorts.CreateCohortReq{Name: "Spring 2026"})
+	require.NoError(t, err)
+	_, err = csvc.Create(cohorts.CreateCohortReq{Name: "Spring 2026"})
+	require.ErrorIs(t, err, cohorts.ErrNameTaken)
+	require.NoError(t, csvc.AddMembers(c.ID, []uint{learner, learner}))
+	require.Error(t, csvc.AddMembers(c.ID, []uint{999}))
+
+	now := time.Now()
+	_, err = csvc.Assign(quizIDs[1], c.ID, cohorts.AssignReq{DueAt: ptr(now.Add(time.Hour))})
+	require.NoError(t, err)
+	_, err = csvc.Assign(quizIDs[2], c.ID, cohorts.AssignReq{DueAt: ptr(now.Add(-time.Hour))})
+	require.NoError(t, err)
+	_, err = csvc.Assign(quizIDs[3], c.ID, cohorts.AssignReq{})
+	require.NoError(t, err)
+
+	// non-members only see the unassigned quiz and cannot take the others
+	list, n, err := qsvc.ListQuizzes(1, 10, quizzes.ListFilter{UserID: learner + 1})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n)
+	require.Equal(t, quizIDs[0], list[0].ID)
+	_, err = qsvc.GetPublicQuestions(quizIDs[1], learner+1)
+	require.ErrorIs(t, err, quizzes.ErrQuizHidden)
+	_, n, err = qsvc.ListQuizzes(1, 10, quizzes.ListFilter{UserID: learner})
+	require.NoError(t, err)
+	require.EqualValues(t, 4, n)
+
+	_, err = qsvc.GetPublicQuestions(quizIDs[1], learner)
+	require.NoError(t, err)
+	pub, err := qsvc.GetPublicQuestions(quizIDs[3], learner)
+	require.NoError(t, err)
+	_, _, err = qsvc.SubmitAndScore(quizIDs[3], learner, quizzes.SubmitReq{Answers: []quizzes.SubmitAnswer{
+		{QuestionID: pub.Questions[0].ID, TextAnswer: ptr("because")},
+	}})
+	require.NoError(t, err)
+
+	mine, err := csvc.MyAssignments(learner, now)
+	require.NoError(t, err)
+	require.Len(t, mine, 3)
+	got := map[string]string{}
+	for _, a := range mine {
+		got[a.Title] = a.Status
+	}
+	require.Equal(t, map[string]string{
+		"Due soon":    cohorts.StatusInProgress,
+		"Overdue":     cohorts.StatusOverdue,
+		"No deadline": cohorts.StatusSubmitted,
+	}, got)
+	require.Equal(t, []string{"Overdue", "Due soon", "No deadline"}, []string{mine[0].Title, mine[1].Title, mine[2].Title})
+	require.Equal(t, []string{"Spring 2026"}, mine[0].Cohorts)
+
+	// deleting the cohort makes the quizzes public again
+	require.NoError(t, csvc.Delete(c.ID))
+	_, n, err = qsvc.ListQuizzes(1, 10, quizzes.ListFilter{UserID: learner + 1})
+	require.NoError(t, err)
+	require.EqualValues(t, 4, n)
+}
@@ -11,6 +11,12 @@ type Config struct {
 	MysqlDSN  string
 	Port      string
 	JWTSecret string
+	UploadDir string
+	// PublicURL is the externally visible base URL, used for LTI redirects.
+	PublicURL string
+	// LTIKeyFile is a PEM RSA private key used to sign LTI messages.
+	// An ephemeral key is generated when empty.
+	LTIKeyFile string
 }
 
 func Load() *Config {
@@ -26,6 +32,14 @@ func Load() *Config {
 	if jwtSecret == "" {
 		jwtSecret = "mysecretkey"
 	}
+	uploadDir := os.Getenv("UPLOAD_DIR")
+	if uploadDir == "" {
+		uploadDir = "uploads"
+	}
+	publicURL := os.Getenv("PUBLIC_URL")
+	if publicURL == "" {
+		publicURL = "http://localhost" + port
+	}
 	// Test if JWT secret is valid
 	if _, err := jwt.Parse("", func(token *jwt.Token) (interface{}, error) {
 		return []byte(jwtSecret), nil
@@ -33,9 +47,12 @@ func Load() *Config {
 		panic(fmt.Sprintf("invalid JWT secret: %v", err))
 	}
 	return &Config{
-		MysqlDSN:  dsn,
-		Port:      port,
-		JWTSecret: jwtSecret,
+		MysqlDSN:   dsn,
+		Port:       port,
+		JWTSecret:  jwtSecret,
+		UploadDir:  uploadDir,
+		PublicURL:  publicURL,
+		LTIKeyFile: os.Getenv("LTI_KEY_FILE"),
 	}
 }
 
@@ -15,16 +15,51 @@ func Connect(dsn string) *gorm.DB {
 		log.Fatalf("failed to connect MySQL: %v", err)
 	}
 	// AutoMigrate will create tables, missing foreign keys, constraints, columns and indexes.
-	if err := db.AutoMigrate(
+	if err := db.AutoMigrate(Models()...); err != nil {
+		log.Fatalf("automigrate failed: %v", err)
+	}
+	return db
+}
+
+// Models lists every table the API uses, in migration order.
+func Models() []any {
+	return []any{
 		&models.Quiz{},
+		&models.QuizExtension{},
+		&models.Cohort{},
+		&models.CohortMember{},
+		&models.QuizAssignment{},
+		&models.AccessCode{},
+		&models.AccessGrant{},
+		&models.Section{},
 		&models.Question{},
+		&models.QuestionRevision{},
 		&models.Option{},
+		&models.Attempt{},
+		&models.DraftAnswer{},
+		&models.IntegrityEvent{},
+		&models.AdaptiveItem{},
 		&models.Submission{},
 		&models.Answer{},
 		&models.AnswerOption{},
+		&models.TextSignature{},
+		&models.SimilarityFlag{},
 		&models.User{},
-	); err != nil {
-		log.Fatalf("automigrate failed: %v", err)
+		&models.LeaderboardEntry{},
+		&models.GlobalScore{},
+		&models.Certificate{},
+		&models.PracticeCard{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.LTIPlatform{},
+		&models.LTIUser{},
+		&models.LTILoginState{},
+		&models.LTIGradeLink{},
+		&models.LTIPassback{},
+		&models.Attachment{},
+		&models.Tag{},
+		&models.BankQuestion{},
+		&models.BankQuestionVersion{},
+		&models.BankOption{},
 	}
-	return db
 }
@@ -0,0 +1,153 @@
+package events
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sequenced is an event as delivered by a Bus. ID is unique for the life
+// of the process and can be handed back to Subscribe to resume.
+type Sequenced struct {
+	ID string
+	Event
+}
+
+// Bus is an in-process Publisher that fans events out to subscribers of a
+// quiz. It keeps the most recent events so a subscriber that reconnects
+// can pick up where it left off.
+//
+// Publishing never blocks: each subscriber has a bounded buffer, and a
+// subscriber that falls behind is dropped (its channel is closed). It can
+// then resubscribe from the last event it saw.
+type Bus struct {
+	mu     sync.Mutex
+	epoch  string // distinguishes IDs from a previous process
+	seq    uint64
+	ring   []Sequenced // the last len(ring) events, oldest at head once full
+	head   int
+	full   bool
+	buffer int
+	subs   map[*Subscription]struct{}
+}
+
+// NewBus keeps backlog events for resumption and buffers up to buffer
+// events per subscriber.
+func NewBus(backlog, buffer int) *Bus {
+	return &Bus{
+		epoch:  strconv.FormatInt(time.Now().UnixNano(), 36),
+		ring:   make([]Sequenced, backlog),
+		buffer: buffer,
+		subs:   map[*Subscription]struct{}{},
+	}
+}
+
+// Subscription receives a quiz's events on C until it is closed, either by
+// Close or by the bus when the subscriber falls behind.
+type Subscription struct {
+	C      <-chan Sequenced
+	ch     chan Sequenced
+	quizID uint
+	bus    *Bus
+}
+
+func (b *Bus) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	ev := Sequenced{ID: fmt.Sprintf("%s-%d", b.epoch, b.seq), Event: e}
+	if len(b.ring) > 0 {
+		b.ring[b.head] = ev
+		b.head = (b.head + 1) % len(b.ring)
+		b.full = b.full || b.head == 0
+	}
+	for sub := range b.subs {
+		if sub.quizID != e.QuizID {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			delete(b.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Subscribe starts receiving events for quizID. With a lastEventID, the
+// retained events after it are replayed first; complete is false if some
+// events since then are no longer available (or the ID is from another
+// process), in which case the subscriber should refetch its state.
+func (b *Bus) Subscribe(quizID uint, lastEventID string) (sub *Subscription, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []Sequenced
+	complete = true
+	if lastEventID != "" {
+		after, ok := b.parseID(lastEventID)
+		if !ok {
+			complete, after = false, 0
+		}
+		oldest := b.seq - uint64(b.retained()) + 1
+		if after+1 < oldest {
+			complete = false
+		}
+		for i := range b.retained() {
+			ev := b.at(i)
+			if seqOf(ev.ID) > after && ev.QuizID == quizID {
+				replay = append(replay, ev)
+			}
+		}
+	}
+
+	ch := make(chan Sequenced, b.buffer+len(replay))
+	for _, ev := range replay {
+		ch <- ev
+	}
+	sub = &Subscription{C: ch, ch: ch, quizID: quizID, bus: b}
+	b.subs[sub] = struct{}{}
+	return sub, complete
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	if _, ok := s.bus.subs[s]; ok {
+		delete(s.bus.subs, s)
+		close(s.ch)
+	}
+}
+
+func (b *Bus) retained() int {
+	if b.full {
+		return len(b.ring)
+	}
+	return b.head
+}
+
+// at returns the i-th oldest retained event.
+func (b *Bus) at(i int) Sequenced {
+	if !b.full {
+		return b.ring[i]
+	}
+	return b.ring[(b.head+i)%len(b.ring)]
+}
+
+func (b *Bus) parseID(id string) (uint64, bool) {
+	epoch, _, ok := strings.Cut(id, "-")
+	if !ok || epoch != b.epoch {
+		return 0, false
+	}
+	seq := seqOf(id)
+	return seq, seq <= b.seq
+}
+
+func seqOf(id string) uint64 {
+	_, n, _ := strings.Cut(id, "-")
+	seq, _ := strconv.ParseUint(n, 10, 64)
+	return seq
+}
@@ -0,0 +1,60 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"quizapi/internal/events"
+)
+
+func drain(sub *events.Subscription) []events.Sequenced {
+	var out []events.Sequenced
+	for {
+		select {
+		case ev, ok := <-sub.C:
+			if !ok {
+				return out
+			}
+			out = append(out, ev)
+		default:
+			return out
+		}
+	}
+}
+
+func TestBus_ResumeAndSlowSubscriber(t *testing.T) {
+	bus := events.NewBus(4, 2)
+	sub, complete := bus.Subscribe(1, "")
+	require.True(t, complete)
+
+	bus.Publish(events.New(events.SubmissionCreated, 1, nil))
+	bus.Publish(events.New(events.SubmissionCreated, 2, nil)) // another quiz
+	bus.Publish(events.New(events.SubmissionGraded, 1, nil))
+	got := drain(sub)
+	require.Len(t, got, 2)
+	require.Equal(t, events.SubmissionGraded, got[1].Type)
+
+	// resuming from the first event replays only what came after it
+	again, complete := bus.Subscribe(1, got[0].ID)
+	require.True(t, complete)
+	replay := drain(again)
+	require.Len(t, replay, 1)
+	require.Equal(t, got[1].ID, replay[0].ID)
+	again.Close()
+	again.Close()
+
+	// a subscriber that stops reading is dropped rather than blocking
+	for range 3 {
+		bus.Publish(events.New(events.AnswerGraded, 1, nil))
+	}
+	require.Len(t, drain(sub), 2)
+	_, ok := <-sub.C
+	require.False(t, ok)
+
+	// events from before the backlog, or from another process, are lost
+	_, complete = bus.Subscribe(1, got[0].ID)
+	require.False(t, complete)
+	_, complete = bus.Subscribe(1, "zzz-1")
+	require.False(t, complete)
+}
@@ -0,0 +1,134 @@
+package events
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"quizapi/internal/models"
+)
+
+// Event types emitted by the service.
+const (
+	SubmissionCreated     = "submission.created"
+	SubmissionGraded      = "submission.graded"
+	SubmissionInvalidated = "submission.invalidated"
+	AnswerGraded          = "answer.graded"
+	QuizPublished         = "quiz.published"
+	QuizScheduleChanged   = "quiz.schedule_changed"
+)
+
+// Event is something that happened to a quiz. Data is JSON-serializable.
+type Event struct {
+	Type   string    `json:"type"`
+	QuizID uint      `json:"quiz_id"`
+	Time   time.Time `json:"time"`
+	Data   any       `json:"data"`
+}
+
+// Publisher receives events. Implementations must not block the caller
+// for long: events are published from request handlers.
+type Publisher interface {
+	Publish(e Event)
+}
+
+// Recorder is a Publisher that queues events in the database for later
+// delivery. Producers queue an event with Record inside the transaction
+// that made the change, so it is kept exactly when the change commits;
+// a Recorder's Publish then does nothing.
+type Recorder interface {
+	Publisher
+	Record(tx *gorm.DB, e Event) error
+}
+
+// Record queues e with every Recorder in p (looking inside Multi). Call it
+// in the producing transaction and Publish e once that commits.
+func Record(tx *gorm.DB, p Publisher, e Event) error {
+	switch p := p.(type) {
+	case Multi:
+		for _, q := range p {
+			if err := Record(tx, q, e); err != nil {
+				return err
+			}
+		}
+	case Recorder:
+		return p.Record(tx, e)
+	}
+	return nil
+}
+
+// Multi fans an event out to several publishers in order.
+type Multi []Publisher
+
+func (m Multi) Publish(e Event) {
+	for _, p := range m {
+		p.Publish(e)
+	}
+}
+
+// Discard drops every event; it is the default publisher.
+var Discard Publisher = discard{}
+
+type discard struct{}
+
+func (discard) Publish(Event) {}
+
+// New stamps an event with the current time.
+func New(typ string, quizID uint, data any) Event {
+	return Event{Type: typ, QuizID: quizID, Time: time.Now().UTC(), Data: data}
+}
+
+// SubmissionData is the payload of submission.* events.
+type SubmissionData struct {
+	SubmissionID    uint    `json:"submission_id"`
+	UserID          uint    `json:"user_id"`
+	Score           int     `json:"score"`
+	Total           int     `json:"total"`
+	Marks           float64 `json:"marks"`
+	MaxMarks        float64 `json:"max_marks"`
+	Percent         int     `json:"percent"`
+	DurationSeconds *int    `json:"duration_seconds"`
+	// InvalidationReason is set on submission.invalidated.
+	InvalidationReason string `json:"invalidation_reason,omitempty"`
+}
+
+// AnswerData is the payload of answer.graded, sent when an admin grades a
+// text answer by hand.
+type AnswerData struct {
+	AnswerID     uint `json:"answer_id"`
+	SubmissionID uint `json:"submission_id"`
+	QuestionID   uint `json:"question_id"`
+	UserID       uint `json:"user_id"`
+	Correct      bool `json:"correct"`
+}
+
+// QuizData is the payload of quiz.* events.
+type QuizData struct {
+	QuizID      uint       `json:"quiz_id"`
+	Title       string     `json:"title"`
+	PublishedAt *time.Time `json:"published_at"`
+	OpensAt     *time.Time `json:"opens_at"`
+	ClosesAt    *time.Time `json:"closes_at"`
+}
+
+// Quiz builds a quiz event of the given type.
+func Quiz(typ string, q *models.Quiz) Event {
+	return New(typ, q.ID, QuizData{
+		QuizID: q.ID, Title: q.Title, PublishedAt: q.PublishedAt, OpensAt: q.OpensAt, ClosesAt: q.ClosesAt,
+	})
+}
+
+// Submission builds a submission event of the given type.
+func Submission(typ string, sub *models.Submission) Event {
+	return New(typ, sub.QuizID, SubmissionData{
+		SubmissionID:       sub.ID,
+		UserID:             sub.UserID,
+		Score:              sub.Score,
+		Total:              sub.Total,
+		Marks:              sub.Marks,
+		MaxMarks:           sub.MaxMarks,
+		Percent:            sub.Percent,
+		DurationSeconds:    sub.DurationSeconds,
+		InvalidationReason: sub.InvalidationReason,
+	})
+}
@@ -0,0 +1,77 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// keepAlive is how often an idle stream gets a comment line, so proxies
+// don't time it out.
+const keepAlive = 15 * time.Second
+
+// StreamReset is sent instead of a replay when events since the client's
+// Last-Event-ID are no longer available; the client should refetch.
+const StreamReset = "stream.reset"
+
+type Handler struct{ bus *Bus }
+
+func NewHandler(bus *Bus) *Handler { return &Handler{bus: bus} }
+
+// Stream serves a quiz's events as Server-Sent Events. Each event's name
+// is its type and its data is the JSON-encoded Event. Browsers resume
+// automatically by sending Last-Event-ID when they reconnect, which also
+// happens when the server drops a client that can't keep up.
+func (h *Handler) Stream(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	sub, complete := h.bus.Subscribe(uint(quizID), c.GetHeader("Last-Event-ID"))
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprint(c.Writer, "retry: 3000\n\n")
+	if !complete {
+		fmt.Fprintf(c.Writer, "event: %s\ndata: {}\n\n", StreamReset)
+	}
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case ev, ok := <-sub.C:
+			if !ok {
+				return // dropped for falling behind; the client reconnects
+			}
+			if err := writeEvent(c.Writer, ev); err != nil {
+				return
+			}
+		case <-ticker.C:
+			fmt.Fprint(c.Writer, ": keep-alive\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeEvent(w io.Writer, ev Sequenced) error {
+	data, err := json.Marshal(ev.Event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, data)
+	return err
+}
@@ -0,0 +1,49 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVWriter writes rows as CSV. Text that a spreadsheet would run as a
+// formula is escaped; numbers are written as is.
+type CSVWriter struct{ w *csv.Writer }
+
+func NewCSVWriter(w io.Writer) *CSVWriter { return &CSVWriter{w: csv.NewWriter(w)} }
+
+func (c *CSVWriter) WriteRow(cells []any) error {
+	record := make([]string, len(cells))
+	for i, v := range cells {
+		switch v := v.(type) {
+		case string:
+			record[i] = escapeFormula(v)
+		case int:
+			record[i] = strconv.Itoa(v)
+		case uint:
+			record[i] = strconv.FormatUint(uint64(v), 10)
+		case float64:
+			record[i] = formatFloat(v)
+		}
+	}
+	return c.w.Write(record)
+}
+
+// Flush writes any buffered rows and reports earlier write errors.
+func (c *CSVWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// escapeFormula neutralizes text that a spreadsheet would read as a
+// formula by prefixing it with a quote, as OWASP recommends for CSV
+// exports.
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func formatFloat(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
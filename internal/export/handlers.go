@@ -0,0 +1,70 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// Results streams a quiz's submissions as CSV (default) or XLSX (?format=xlsx).
+func (h *Handler) Results(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	if err := h.svc.CheckQuiz(uint(quizID)); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrQuizNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := fmt.Sprintf("quiz-%d-results", quizID)
+	var w interface {
+		RowWriter
+		Flush() error
+	}
+	switch c.DefaultQuery("format", "csv") {
+	case "csv":
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, name))
+		w = NewCSVWriter(c.Writer)
+	case "xlsx":
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.xlsx"`, name))
+		w = NewXLSXWriter(c.Writer, "Results")
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or xlsx"})
+		return
+	}
+	err = h.svc.WriteResults(uint(quizID), w)
+	if err != nil && !c.Writer.Written() {
+		// nothing sent yet (e.g. the quiz has no questions): report normally
+		c.Writer.Header().Del("Content-Disposition")
+		c.Writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if ferr := w.Flush(); err == nil {
+		err = ferr
+	}
+	// headers are already sent once streaming starts, so just log failures
+	if err != nil {
+		log.Printf("export quiz %d: %v", quizID, err)
+	}
+}
@@ -0,0 +1,202 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"quizapi/internal/models"
+	"quizapi/internal/quizzes"
+)
+
+// batchSize bounds how many submissions (and their answers) are held in
+// memory at once while streaming.
+const batchSize = 500
+
+// RowWriter receives the export one row at a time. Cells are strings,
+// numbers (int, uint or float64) or nil for an empty cell, so each format
+// can store them natively.
+type RowWriter interface {
+	WriteRow(cells []any) error
+}
+
+var ErrQuizNotFound = errors.New("quiz not found")
+
+type Service struct{ db *gorm.DB }
+
+func NewService(db *gorm.DB) *Service { return &Service{db: db} }
+
+// CheckQuiz returns ErrQuizNotFound unless the quiz exists.
+func (s *Service) CheckQuiz(quizID uint) error {
+	var n int64
+	if err := s.db.Model(&models.Quiz{}).Where("id = ?", quizID).Count(&n).Error; err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrQuizNotFound
+	}
+	return nil
+}
+
+// WriteResults streams every submission of the quiz to w: one row per
+// submission with the taker, timestamps, score, when and why it was
+// invalidated (if it was) and, per question, the selected options or text
+// answer and the points awarded. Questions are in quiz order. Options are
+// looked up including soft-deleted ones so answers given against older
+// revisions still show their original text.
+func (s *Service) WriteResults(quizID uint, w RowWriter) error {
+	var qs []models.Question
+	if err := s.db.Scopes(quizzes.ByPosition).Where("quiz_id = ?", quizID).Find(&qs).Error; err != nil {
+		return err
+	}
+	if len(qs) == 0 {
+		return fmt.Errorf("quiz %d not found or has no questions", quizID)
+	}
+	qids := make([]uint, len(qs))
+	col := make(map[uint]int, len(qs))
+	for i, q := range qs {
+		qids[i] = q.ID
+		col[q.ID] = i
+	}
+	var opts []models.Option
+	if err := s.db.Unscoped().Where("question_id IN ?", qids).Find(&opts).Error; err != nil {
+		return err
+	}
+	optText := make(map[uint]string, len(opts))
+	for _, o := range opts {
+		optText[o.ID] = o.Text
+	}
+
+	header := []any{"submission_id", "user_id", "username", "started_at", "submitted_at",
+		"duration_seconds", "score", "total", "marks", "max_marks", "percent", "invalidated_at", "invalidation_reason"}
+	for i := range qs {
+		header = append(header, fmt.Sprintf("q%d_answer", i+1), fmt.Sprintf("q%d_points", i+1))
+	}
+	if err := w.WriteRow(header); err != nil {
+		return err
+	}
+
+	var batch []models.Submission
+	res := s.db.Where("quiz_id = ?", quizID).Order("id").
+		FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+			return s.writeBatch(batch, qs, col, optText, w)
+		})
+	return res.Error
+}
+
+func (s *Service) writeBatch(batch []models.Submission, qs []models.Question, col map[uint]int,
+	optText map[uint]string, w RowWriter) error {
+	subIDs := make([]uint, len(batch))
+	userIDs := make([]uint, 0, len(batch))
+	for i, sub := range batch {
+		subIDs[i] = sub.ID
+		if sub.UserID != 0 {
+			userIDs = append(userIDs, sub.UserID)
+		}
+	}
+
+	var answers []models.Answer
+	if err := s.db.Preload("Options").Where("submission_id IN ?", subIDs).Find(&answers).Error; err != nil {
+		return err
+	}
+	bySub := make(map[uint][]models.Answer, len(batch))
+	for _, a := range answers {
+		bySub[a.SubmissionID] = append(bySub[a.SubmissionID], a)
+	}
+
+	usernames := map[uint]string{}
+	if len(userIDs) > 0 {
+		var users []models.User
+		if err := s.db.Select("id, username").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+			return err
+		}
+		for _, u := range users {
+			usernames[u.ID] = u.Username
+		}
+	}
+	var attempts []models.Attempt
+	if err := s.db.Where("submission_id IN ?", subIDs).Find(&attempts).Error; err != nil {
+		return err
+	}
+	started := make(map[uint]time.Time, len(attempts))
+	for _, a := range attempts {
+		started[*a.SubmissionID] = a.StartedAt
+	}
+
+	for _, sub := range batch {
+		row := []any{
+			sub.ID,
+			sub.UserID,
+			usernames[sub.UserID],
+			formatTime(started[sub.ID]),
+			formatTime(sub.CreatedAt),
+			intOrNil(sub.DurationSeconds),
+			sub.Score,
+			sub.Total,
+			sub.Marks,
+			sub.MaxMarks,
+			sub.Percent,
+			formatTimePtr(sub.InvalidatedAt),
+			sub.InvalidationReason,
+		}
+		cells := make([]any, 2*len(qs))
+		for _, a := range bySub[sub.ID] {
+			i, ok := col[a.QuestionID]
+			if !ok {
+				continue
+			}
+			cells[2*i] = answerText(a, optText)
+			switch {
+			case a.Marks != nil:
+				cells[2*i+1] = *a.Marks
+			case a.IsCorrect != nil: // graded before marks were stored
+				cells[2*i+1] = 0
+				if *a.IsCorrect {
+					cells[2*i+1] = 1
+				}
+			}
+		}
+		if err := w.WriteRow(append(row, cells...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// --- helpers ---
+
+func answerText(a models.Answer, optText map[uint]string) string {
+	if a.TextAnswer != nil {
+		return *a.TextAnswer
+	}
+	parts := make([]string, 0, len(a.Options))
+	for _, ao := range a.Options {
+		parts = append(parts, optText[ao.OptionID])
+	}
+	return strings.Join(parts, "; ")
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return formatTime(*t)
+}
+
+// intOrNil returns *p, or nil (an empty cell) when p is nil.
+func intOrNil(p *int) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
@@ -0,0 +1,114 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+
+	"quizapi/internal/export"
+	"quizapi/internal/models"
+	"quizapi/internal/quizzes"
+	"quizapi/internal/testutil"
+)
+
+func TestWriteResults_CSV(t *testing.T) {
+	d := testutil.DB(t)
+	user := &models.User{Username: "dana", PasswordHash: "x", Role: models.RoleUser}
+	require.NoError(t, d.Create(user).Error)
+
+	qsvc := quizzes.NewService(d)
+	qz, err := qsvc.CreateQuiz("export")
+	require.NoError(t, err)
+	yes, no, limit := true, false, 50
+	choice, err := qsvc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{
+		Text: "Pick", Type: "multiple",
+		Options: []quizzes.CreateQuestionOption{
+			{Text: "a", IsCorrect: &yes}, {Text: "b", IsCorrect: &yes}, {Text: "c", IsCorrect: &no},
+		},
+	})
+	require.NoError(t, err)
+	text, err := qsvc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{Text: "Explain", Type: "text", WordLimit: &limit})
+	require.NoError(t, err)
+
+	pub, err := qsvc.GetPublicQuestions(qz.ID, user.ID)
+	require.NoError(t, err)
+	opts := pub.Questions[0].Options
+	essay := "because"
+	_, _, err = qsvc.SubmitAndScore(qz.ID, user.ID, quizzes.SubmitReq{Answers: []quizzes.SubmitAnswer{
+		{QuestionID: choice.ID, SelectedOptionIDs: []uint{opts[0].ID, opts[2].ID}},
+		{QuestionID: text.ID, TextAnswer: &essay},
+	}})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	cw := export.NewCSVWriter(&buf)
+	require.NoError(t, export.NewService(d).WriteResults(qz.ID, cw))
+	require.NoError(t, cw.Flush())
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Equal(t, []string{"q1_answer", "q1_points", "q2_answer", "q2_points"}, rows[0][13:])
+	require.Equal(t, "dana", rows[1][2])
+	require.NotEmpty(t, rows[1][3]) // started_at from the attempt
+	require.Equal(t, []string{"0", "1", "0", "1", "0"}, rows[1][6:11])
+	require.Equal(t, []string{"", ""}, rows[1][11:13]) // not invalidated
+	require.Equal(t, []string{"a; c", "0", "because", ""}, rows[1][13:])
+}
+
+func TestWriteResults_EscapesFormulasAndFlagsInvalidated(t *testing.T) {
+	d := testutil.DB(t)
+	user := &models.User{Username: "@mallory", PasswordHash: "x", Role: models.RoleUser}
+	require.NoError(t, d.Create(user).Error)
+	qsvc := quizzes.NewService(d)
+	qz, err := qsvc.CreateQuiz("export")
+	require.NoError(t, err)
+	text, err := qsvc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{Text: "Explain", Type: "text", WordLimit: ptr(50)})
+	require.NoError(t, err)
+	_, err = qsvc.GetPublicQuestions(qz.ID, user.ID)
+	require.NoError(t, err)
+	payload := `=HYPERLINK("http://evil.example","click")`
+	sub, _, err := qsvc.SubmitAndScore(qz.ID, user.ID, quizzes.SubmitReq{Answers: []quizzes.SubmitAnswer{
+		{QuestionID: text.ID, TextAnswer: &payload},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, d.Model(sub).Updates(map[string]any{
+		"invalidated_at": time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), "invalidation_reason": "-copied",
+	}).Error)
+
+	var buf bytes.Buffer
+	cw := export.NewCSVWriter(&buf)
+	require.NoError(t, export.NewService(d).WriteResults(qz.ID, cw))
+	require.NoError(t, cw.Flush())
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, []string{"invalidated_at", "invalidation_reason"}, rows[0][11:13])
+	require.Equal(t, "'@mallory", rows[1][2])
+	require.Equal(t, []string{"2026-05-01T00:00:00Z", "'-copied"}, rows[1][11:13])
+	require.Equal(t, "'"+payload, rows[1][13])
+
+	// XLSX stores text as inline strings, which never run, so it isn't
+	// escaped; numbers are stored as numbers
+	buf.Reset()
+	xw := export.NewXLSXWriter(&buf, "Results")
+	require.NoError(t, export.NewService(d).WriteResults(qz.ID, xw))
+	require.NoError(t, xw.Flush())
+	f, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+	defer f.Close()
+	xrows, err := f.GetRows("Results")
+	require.NoError(t, err)
+	require.Equal(t, rows[0], xrows[0])
+	require.Equal(t, "@mallory", xrows[1][2])
+	require.Equal(t, "-copied", xrows[1][12])
+	require.Equal(t, payload, xrows[1][13])
+	typ, err := f.GetCellType("Results", "I2") // marks
+	require.NoError(t, err)
+	require.Equal(t, excelize.CellTypeNumber, typ)
+}
+
+func ptr[T any](v T) *T { return &v }
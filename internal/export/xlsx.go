@@ -0,0 +1,126 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// XLSXWriter writes a single-sheet workbook straight to w as rows arrive.
+// The zip entries are streamed, so memory use does not grow with the
+// number of rows. Text is stored as inline strings, which spreadsheets
+// never evaluate, and numbers as numeric cells. Nothing is written until
+// the first row, so a caller can still report an error instead.
+type XLSXWriter struct {
+	zw    *zip.Writer
+	sheet string
+	buf   *bufio.Writer
+	row   int
+}
+
+func NewXLSXWriter(w io.Writer, sheet string) *XLSXWriter {
+	return &XLSXWriter{zw: zip.NewWriter(w), sheet: sheet}
+}
+
+func (x *XLSXWriter) WriteRow(cells []any) error {
+	if x.buf == nil {
+		if err := x.start(); err != nil {
+			return err
+		}
+	}
+	x.row++
+	fmt.Fprintf(x.buf, `<row r="%d">`, x.row)
+	for i, v := range cells {
+		ref := colName(i) + strconv.Itoa(x.row)
+		switch v := v.(type) {
+		case string:
+			if v == "" {
+				continue
+			}
+			fmt.Fprintf(x.buf, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			if err := xml.EscapeText(x.buf, []byte(v)); err != nil {
+				return err
+			}
+			x.buf.WriteString(`</t></is></c>`)
+		case int:
+			fmt.Fprintf(x.buf, `<c r="%s" t="n"><v>%d</v></c>`, ref, v)
+		case uint:
+			fmt.Fprintf(x.buf, `<c r="%s" t="n"><v>%d</v></c>`, ref, v)
+		case float64:
+			fmt.Fprintf(x.buf, `<c r="%s" t="n"><v>%s</v></c>`, ref, formatFloat(v))
+		}
+	}
+	_, err := x.buf.WriteString(`</row>`)
+	return err
+}
+
+// Flush finishes the sheet, writes the remaining workbook parts and closes
+// the zip. The writer can't be used afterwards.
+func (x *XLSXWriter) Flush() error {
+	if x.buf == nil {
+		if err := x.start(); err != nil {
+			return err
+		}
+	}
+	x.buf.WriteString(`</sheetData></worksheet>`)
+	if err := x.buf.Flush(); err != nil {
+		return err
+	}
+	var name strings.Builder
+	if err := xml.EscapeText(&name, []byte(x.sheet)); err != nil {
+		return err
+	}
+	parts := []struct{ path, body string }{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+			`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="` + name.String() + `" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`},
+	}
+	for _, p := range parts {
+		f, err := x.zw.Create(p.path)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.body); err != nil {
+			return err
+		}
+	}
+	return x.zw.Close()
+}
+
+// start opens the sheet entry; rows are then deflated into the zip as
+// they are written.
+func (x *XLSXWriter) start() error {
+	f, err := x.zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	x.buf = bufio.NewWriter(f)
+	_, err = x.buf.WriteString(xml.Header +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return err
+}
+
+// colName turns a 0-based column index into a letter reference (A, B, ..., AA).
+func colName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
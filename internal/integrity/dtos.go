@@ -0,0 +1,57 @@
+package integrity
+
+import (
+	"time"
+
+	"quizapi/internal/models"
+)
+
+// ReportReq is a batch of integrity events from the quiz client. Clients
+// should buffer events and send them every few seconds rather than one
+// request per event.
+type ReportReq struct {
+	Events []EventReq `json:"events" validate:"required,min=1,max=100,dive"`
+}
+
+type EventReq struct {
+	Type       models.IntegrityEventType `json:"type" validate:"required,oneof=tab_switch focus_loss copy paste fullscreen_exit"`
+	OccurredAt time.Time                 `json:"occurred_at" validate:"required"`
+	DurationMs *int                      `json:"duration_ms" validate:"omitempty,min=0"`
+	Detail     string                    `json:"detail" validate:"max=255"`
+}
+
+// RiskSummary condenses an attempt's integrity events. Score runs from 0
+// to 100 and Level buckets it as none, low, medium or high. It is a prompt
+// for review, not proof of cheating.
+type RiskSummary struct {
+	Events      int                               `json:"events"`
+	Counts      map[models.IntegrityEventType]int `json:"counts"`
+	AwaySeconds int                               `json:"away_seconds"`
+	Score       int                               `json:"score"`
+	Level       string                            `json:"level"`
+}
+
+// SubmissionRow is a submission as admins review it.
+type SubmissionRow struct {
+	models.Submission
+	Username  string      `json:"username"`
+	AttemptID *uint       `json:"attempt_id"`
+	Risk      RiskSummary `json:"risk"`
+}
+
+type ListResp struct {
+	Submissions  []SubmissionRow `json:"submissions"`
+	TotalRecords int64           `json:"total_records"`
+	Page         int             `json:"page"`
+	Limit        int             `json:"limit"`
+}
+
+// SubmissionDetail adds the attempt's integrity log, oldest first.
+type SubmissionDetail struct {
+	SubmissionRow
+	Events []models.IntegrityEvent `json:"events"`
+}
+
+type InvalidateReq struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
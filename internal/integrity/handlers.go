@@ -0,0 +1,136 @@
+package integrity
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+
+	"quizapi/internal/quizzes"
+)
+
+type Handler struct {
+	svc *Service
+	val *validator.Validate
+}
+
+func NewHandler(svc *Service) *Handler { return &Handler{svc: svc, val: validator.New()} }
+
+func (h *Handler) Report(c *gin.Context) {
+	attemptID, err := strconv.Atoi(c.Param("attemptID"))
+	if err != nil || attemptID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attemptID"})
+		return
+	}
+	var req ReportReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.svc.Report(uint(attemptID), c.GetUint("userID"), req, time.Now()); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, quizzes.ErrAttemptNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, quizzes.ErrAttemptClosed):
+			status = http.StatusConflict
+		case errors.Is(err, ErrTooManyEvents):
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) List(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	out, err := h.svc.List(uint(quizID), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	quizID, submissionID, ok := ids(c)
+	if !ok {
+		return
+	}
+	out, err := h.svc.Get(quizID, submissionID)
+	if err != nil {
+		c.JSON(status(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *Handler) Invalidate(c *gin.Context) {
+	quizID, submissionID, ok := ids(c)
+	if !ok {
+		return
+	}
+	var req InvalidateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	sub, err := h.svc.Invalidate(quizID, submissionID, c.GetUint("userID"), req)
+	if err != nil {
+		c.JSON(status(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+// ids parses the quiz and submission IDs, answering 400 if either is bad.
+func ids(c *gin.Context) (quizID, submissionID uint, ok bool) {
+	q, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || q <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return 0, 0, false
+	}
+	s, err := strconv.Atoi(c.Param("submissionID"))
+	if err != nil || s <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid submissionID"})
+		return 0, 0, false
+	}
+	return uint(q), uint(s), true
+}
+
+func status(err error) int {
+	switch {
+	case errors.Is(err, ErrSubmissionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrAlreadyInvalidated):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
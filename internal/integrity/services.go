@@ -0,0 +1,237 @@
+package integrity
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"quizapi/internal/certificates"
+	"quizapi/internal/events"
+	"quizapi/internal/leaderboard"
+	"quizapi/internal/models"
+	"quizapi/internal/quizzes"
+)
+
+var (
+	ErrTooManyEvents      = errors.New("attempt has reached its integrity event limit")
+	ErrSubmissionNotFound = errors.New("submission not found")
+	ErrAlreadyInvalidated = errors.New("submission is already invalidated")
+)
+
+// maxEventsPerAttempt bounds storage for a misbehaving or hostile client.
+const maxEventsPerAttempt = 1000
+
+// awayPerPoint is how many seconds spent in another tab or window add one
+// point of risk.
+const awayPerPoint = 10
+
+// eventWeights are the risk points per event. Pasting weighs most since it
+// is the likeliest sign of answers brought in from elsewhere.
+var eventWeights = map[models.IntegrityEventType]int{
+	models.EventTabSwitch:      8,
+	models.EventFocusLoss:      4,
+	models.EventCopy:           4,
+	models.EventPaste:          12,
+	models.EventFullscreenExit: 8,
+}
+
+type Service struct {
+	db     *gorm.DB
+	events events.Publisher
+}
+
+func NewService(db *gorm.DB) *Service { return &Service{db: db, events: events.Discard} }
+
+// SetPublisher sets where invalidation events are sent.
+func (s *Service) SetPublisher(p events.Publisher) { s.events = p }
+
+// Report stores a batch of integrity events against one of userID's open
+// attempts.
+func (s *Service) Report(attemptID, userID uint, req ReportReq, now time.Time) error {
+	var att models.Attempt
+	if err := s.db.Where("id = ? AND user_id = ?", attemptID, userID).First(&att).Error; err != nil {
+		return quizzes.ErrAttemptNotFound
+	}
+	if att.SubmittedAt != nil {
+		return quizzes.ErrAttemptClosed
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var n int64
+		if err := tx.Model(&models.IntegrityEvent{}).Where("attempt_id = ?", att.ID).Count(&n).Error; err != nil {
+			return err
+		}
+		if int(n)+len(req.Events) > maxEventsPerAttempt {
+			return ErrTooManyEvents
+		}
+		evs := make([]models.IntegrityEvent, 0, len(req.Events))
+		for _, e := range req.Events {
+			evs = append(evs, models.IntegrityEvent{
+				AttemptID:  att.ID,
+				Type:       e.Type,
+				OccurredAt: e.OccurredAt.UTC(),
+				DurationMs: e.DurationMs,
+				Detail:     e.Detail,
+				ReceivedAt: now,
+			})
+		}
+		return tx.Create(&evs).Error
+	})
+}
+
+// List returns a page of the quiz's submissions, newest first, with each
+// one's risk summary.
+func (s *Service) List(quizID uint, page, limit int) (*ListResp, error) {
+	out := &ListResp{Submissions: []SubmissionRow{}, Page: page, Limit: limit}
+	q := s.db.Model(&models.Submission{}).Where("quiz_id = ?", quizID)
+	if err := q.Session(&gorm.Session{}).Count(&out.TotalRecords).Error; err != nil {
+		return nil, err
+	}
+	var subs []models.Submission
+	if err := q.Order("id desc").Offset((page - 1) * limit).Limit(limit).Find(&subs).Error; err != nil {
+		return nil, err
+	}
+	rows, _, err := s.rows(subs)
+	if err != nil {
+		return nil, err
+	}
+	out.Submissions = rows
+	return out, nil
+}
+
+// Get returns one submission with its risk summary and integrity log.
+func (s *Service) Get(quizID, submissionID uint) (*SubmissionDetail, error) {
+	var sub models.Submission
+	if err := s.db.Where("id = ? AND quiz_id = ?", submissionID, quizID).First(&sub).Error; err != nil {
+		return nil, ErrSubmissionNotFound
+	}
+	rows, logs, err := s.rows([]models.Submission{sub})
+	if err != nil {
+		return nil, err
+	}
+	out := &SubmissionDetail{SubmissionRow: rows[0], Events: []models.IntegrityEvent{}}
+	if rows[0].AttemptID != nil {
+		out.Events = append(out.Events, logs[*rows[0].AttemptID]...)
+	}
+	return out, nil
+}
+
+// Invalidate voids a submission with a recorded reason. Its certificates
+// are revoked and the submitter's leaderboard entry falls back to their
+// best remaining submission. It emits submission.invalidated.
+func (s *Service) Invalidate(quizID, submissionID, adminID uint, req InvalidateReq) (*models.Submission, error) {
+	var sub models.Submission
+	var ev events.Event
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND quiz_id = ?", submissionID, quizID).First(&sub).Error; err != nil {
+			return ErrSubmissionNotFound
+		}
+		if sub.InvalidatedAt != nil {
+			return ErrAlreadyInvalidated
+		}
+		now := time.Now()
+		sub.InvalidatedAt, sub.InvalidatedBy, sub.InvalidationReason = &now, &adminID, req.Reason
+		if err := tx.Model(&sub).Updates(map[string]any{
+			"invalidated_at": now, "invalidated_by": adminID, "invalidation_reason": req.Reason,
+		}).Error; err != nil {
+			return err
+		}
+		if err := certificates.RevokeForSubmission(tx, sub.ID, "submission invalidated: "+req.Reason); err != nil {
+			return err
+		}
+		if sub.UserID != 0 {
+			if err := leaderboard.Rebuild(tx, sub.QuizID, sub.UserID); err != nil {
+				return err
+			}
+		}
+		ev = events.Submission(events.SubmissionInvalidated, &sub)
+		return events.Record(tx, s.events, ev)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.events.Publish(ev)
+	return &sub, nil
+}
+
+// rows builds review rows for subs and returns the integrity logs of their
+// attempts, keyed by attempt ID.
+func (s *Service) rows(subs []models.Submission) ([]SubmissionRow, map[uint][]models.IntegrityEvent, error) {
+	subIDs := make([]uint, 0, len(subs))
+	userIDs := make([]uint, 0, len(subs))
+	for _, sub := range subs {
+		subIDs = append(subIDs, sub.ID)
+		userIDs = append(userIDs, sub.UserID)
+	}
+	var atts []models.Attempt
+	if err := s.db.Where("submission_id IN ?", subIDs).Find(&atts).Error; err != nil {
+		return nil, nil, err
+	}
+	attOf := make(map[uint]uint, len(atts))
+	attIDs := make([]uint, 0, len(atts))
+	for _, a := range atts {
+		attOf[*a.SubmissionID] = a.ID
+		attIDs = append(attIDs, a.ID)
+	}
+	var evs []models.IntegrityEvent
+	if err := s.db.Where("attempt_id IN ?", attIDs).Order("occurred_at, id").Find(&evs).Error; err != nil {
+		return nil, nil, err
+	}
+	logs := map[uint][]models.IntegrityEvent{}
+	for _, e := range evs {
+		logs[e.AttemptID] = append(logs[e.AttemptID], e)
+	}
+	var users []models.User
+	if err := s.db.Select("id, username").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, nil, err
+	}
+	names := make(map[uint]string, len(users))
+	for _, u := range users {
+		names[u.ID] = u.Username
+	}
+
+	out := make([]SubmissionRow, 0, len(subs))
+	for _, sub := range subs {
+		row := SubmissionRow{Submission: sub, Username: names[sub.UserID]}
+		if id, ok := attOf[sub.ID]; ok {
+			row.AttemptID = &id
+		}
+		var log []models.IntegrityEvent
+		if row.AttemptID != nil {
+			log = logs[*row.AttemptID]
+		}
+		row.Risk = Summarize(log)
+		out = append(out, row)
+	}
+	return out, logs, nil
+}
+
+// Summarize scores an attempt's integrity events.
+func Summarize(evs []models.IntegrityEvent) RiskSummary {
+	out := RiskSummary{Events: len(evs), Counts: map[models.IntegrityEventType]int{}}
+	awayMs, score := 0, 0
+	for _, e := range evs {
+		out.Counts[e.Type]++
+		score += eventWeights[e.Type]
+		if e.DurationMs != nil {
+			awayMs += *e.DurationMs
+		}
+	}
+	out.AwaySeconds = awayMs / 1000
+	score += out.AwaySeconds / awayPerPoint
+	out.Score = min(score, 100)
+	out.Level = level(out.Score)
+	return out
+}
+
+func level(score int) string {
+	switch {
+	case score == 0:
+		return "none"
+	case score < 25:
+		return "low"
+	case score < 60:
+		return "medium"
+	}
+	return "high"
+}
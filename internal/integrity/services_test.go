@@ -0,0 +1,88 @@
+package integrity_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"quizapi/internal/certificates"
+	"quizapi/internal/integrity"
+	"quizapi/internal/models"
+	"quizapi/internal/quizzes"
+	"quizapi/internal/testutil"
+)
+
+const learner = 42
+
+func ptr[T any](v T) *T { return &v }
+
+func TestInvalidate_RiskAndFallback(t *testing.T) {
+	d := testutil.DB(t)
+	require.NoError(t, d.Create(&models.User{ID: learner, Username: "ada", PasswordHash: "x", Role: models.RoleUser}).Error)
+	qsvc := quizzes.NewService(d)
+	svc := integrity.NewService(d)
+	qz, err := qsvc.CreateQuiz("Exam")
+	require.NoError(t, err)
+	_, err = qsvc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{Text: "Q?", Type: "single",
+		Options: []quizzes.CreateQuestionOption{{Text: "right", IsCorrect: ptr(true)}, {Text: "wrong", IsCorrect: ptr(false)}}})
+	require.NoError(t, err)
+	_, err = certificates.NewService(d, "").UpdateSettings(qz.ID, certificates.SettingsReq{PassPercent: ptr(50)})
+	require.NoError(t, err)
+
+	take := func(opt int, evs ...integrity.EventReq) *models.Submission {
+		pub, err := qsvc.GetPublicQuestions(qz.ID, learner)
+		require.NoError(t, err)
+		if len(evs) > 0 {
+			require.NoError(t, svc.Report(pub.AttemptID, learner, integrity.ReportReq{Events: evs}, time.Now()))
+		}
+		q := pub.Questions[0]
+		sub, _, err := qsvc.SubmitAndScore(qz.ID, learner, quizzes.SubmitReq{Answers: []quizzes.SubmitAnswer{
+			{QuestionID: q.ID, SelectedOptionID: &q.Options[opt].ID},
+		}})
+		require.NoError(t, err)
+		require.ErrorIs(t, svc.Report(pub.AttemptID, learner, integrity.ReportReq{Events: evs}, time.Now()), quizzes.ErrAttemptClosed)
+		return sub
+	}
+	now := time.Now()
+	first := take(1)
+	best := take(0,
+		integrity.EventReq{Type: models.EventTabSwitch, OccurredAt: now, DurationMs: ptr(30_000)},
+		integrity.EventReq{Type: models.EventPaste, OccurredAt: now.Add(time.Second)},
+	)
+
+	got, err := svc.Get(qz.ID, best.ID)
+	require.NoError(t, err)
+	require.Len(t, got.Events, 2)
+	require.Equal(t, integrity.RiskSummary{
+		Events: 2, Counts: map[models.IntegrityEventType]int{models.EventTabSwitch: 1, models.EventPaste: 1},
+		AwaySeconds: 30, Score: 23, Level: "low",
+	}, got.Risk)
+
+	_, err = svc.Invalidate(qz.ID, best.ID, 1, integrity.InvalidateReq{Reason: "answers pasted in"})
+	require.NoError(t, err)
+	_, err = svc.Invalidate(qz.ID, best.ID, 1, integrity.InvalidateReq{Reason: "again"})
+	require.ErrorIs(t, err, integrity.ErrAlreadyInvalidated)
+
+	// the leaderboard falls back to the other submission and the
+	// certificate is revoked
+	var entry models.LeaderboardEntry
+	require.NoError(t, d.Where("quiz_id = ? AND user_id = ?", qz.ID, learner).First(&entry).Error)
+	require.Equal(t, first.ID, entry.SubmissionID)
+	require.Equal(t, 0, entry.BestScore)
+	var gs models.GlobalScore
+	require.NoError(t, d.First(&gs, learner).Error)
+	require.Equal(t, 0, gs.Points)
+	require.Equal(t, 1, gs.Quizzes)
+	var cert models.Certificate
+	require.NoError(t, d.Where("submission_id = ?", best.ID).First(&cert).Error)
+	require.NotNil(t, cert.RevokedAt)
+	require.Equal(t, "submission invalidated: answers pasted in", cert.RevokeReason)
+
+	list, err := svc.List(qz.ID, 1, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, list.TotalRecords)
+	require.Equal(t, best.ID, list.Submissions[0].ID)
+	require.NotNil(t, list.Submissions[0].InvalidatedAt)
+	require.Equal(t, "none", list.Submissions[1].Risk.Level)
+}
@@ -0,0 +1,35 @@
+package leaderboard
+
+import "time"
+
+type Row struct {
+	Rank            int64     `json:"rank"`
+	UserID          uint      `json:"user_id"`
+	Username        string    `json:"username"`
+	Marks           float64   `json:"marks"`
+	Score           int       `json:"score"`             // right answers
+	Percent         *int      `json:"percent,omitempty"` // per-quiz boards only
+	DurationSeconds *int      `json:"duration_seconds"`
+	Quizzes         *int      `json:"quizzes,omitempty"` // global board only
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type BoardResp struct {
+	QuizID       *uint `json:"quiz_id,omitempty"`
+	Rows         []Row `json:"rows"`
+	TotalRecords int64 `json:"total_records"`
+	Page         int   `json:"page"`
+	Limit        int   `json:"limit"`
+}
+
+// MyRankResp is returned for "my rank" lookups. Rank is computed against
+// visible users only, so it is still available to users who opted out.
+type MyRankResp struct {
+	Ranked bool `json:"ranked"`
+	Row    *Row `json:"row,omitempty"`
+	Hidden bool `json:"hidden"`
+}
+
+type PrivacyReq struct {
+	OptOut *bool `json:"opt_out" validate:"required"`
+}
@@ -0,0 +1,129 @@
+package leaderboard
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+
+	"quizapi/internal/access"
+	"quizapi/internal/models"
+)
+
+type Handler struct {
+	svc *Service
+	val *validator.Validate
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc, val: validator.New()}
+}
+
+func (h *Handler) QuizBoard(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	if !h.checkQuiz(c, uint(quizID)) {
+		return
+	}
+	page, limit := pagination(c)
+	res, err := h.svc.QuizBoard(uint(quizID), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) GlobalBoard(c *gin.Context) {
+	page, limit := pagination(c)
+	res, err := h.svc.GlobalBoard(page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) MyQuizRank(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	if !h.checkQuiz(c, uint(quizID)) {
+		return
+	}
+	res, err := h.svc.MyQuizRank(uint(quizID), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) MyGlobalRank(c *gin.Context) {
+	res, err := h.svc.MyGlobalRank(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) SetPrivacy(c *gin.Context) {
+	var req PrivacyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.svc.SetOptOut(c.GetUint("userID"), *req.OptOut); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"opt_out": *req.OptOut})
+}
+
+// pagination reads ?page and ?limit with the same defaults as ListQuizzes.
+func pagination(c *gin.Context) (int, int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	return page, limit
+}
+
+// checkQuiz writes an error response and returns false unless the caller
+// may see the quiz. Admins see every board.
+func (h *Handler) checkQuiz(c *gin.Context, quizID uint) bool {
+	if role, _ := c.Get("role"); role == models.RoleAdmin {
+		return true
+	}
+	err := h.svc.CheckQuiz(quizID, c.GetUint("userID"))
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, ErrQuizHidden):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, access.ErrCodeRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+	return false
+}
@@ -0,0 +1,303 @@
+package leaderboard
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"quizapi/internal/access"
+	"quizapi/internal/cohorts"
+	"quizapi/internal/models"
+)
+
+// ErrQuizHidden is returned for quiz boards the caller may not see: those of
+// missing quizzes and of quizzes assigned to cohorts the caller is not in.
+var ErrQuizHidden = errors.New("quiz not found")
+
+// noDuration sorts entries without a known completion time last.
+const noDuration = 1<<31 - 1
+
+type Service struct{ db *gorm.DB }
+
+func NewService(db *gorm.DB) *Service { return &Service{db: db} }
+
+// Record updates the submitter's quiz entry and global score if sub beats
+// their previous best. It runs inside the submit transaction so rankings
+// are maintained incrementally instead of being recomputed on read.
+func Record(tx *gorm.DB, sub *models.Submission) error {
+	if sub.UserID == 0 || sub.Total == 0 || sub.InvalidatedAt != nil {
+		return nil
+	}
+	var cur models.LeaderboardEntry
+	res := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("quiz_id = ? AND user_id = ?", sub.QuizID, sub.UserID).Limit(1).Find(&cur)
+	if res.Error != nil {
+		return res.Error
+	}
+
+	next := models.LeaderboardEntry{
+		QuizID:       sub.QuizID,
+		UserID:       sub.UserID,
+		BestMarks:    sub.Marks,
+		BestScore:    sub.Score,
+		BestPercent:  sub.Percent,
+		BestDuration: sub.DurationSeconds,
+		SubmissionID: sub.ID,
+	}
+	if res.RowsAffected == 0 {
+		if err := tx.Create(&next).Error; err != nil {
+			return err
+		}
+		return addGlobal(tx, sub.UserID, next.BestMarks, next.BestScore, durationOf(next.BestDuration), 1)
+	}
+	if !better(next, cur) {
+		return nil
+	}
+	prev := cur // Updates writes the new values back into cur
+	if err := tx.Model(&cur).Updates(map[string]any{
+		"best_marks":    next.BestMarks,
+		"best_score":    next.BestScore,
+		"best_percent":  next.BestPercent,
+		"best_duration": next.BestDuration,
+		"submission_id": next.SubmissionID,
+	}).Error; err != nil {
+		return err
+	}
+	return addGlobal(tx, sub.UserID,
+		next.BestMarks-prev.BestMarks, next.BestScore-prev.BestScore,
+		durationOf(next.BestDuration)-durationOf(prev.BestDuration), 0)
+}
+
+// Rebuild recomputes the user's quiz entry from their valid submissions,
+// e.g. after the best one was invalidated, and adjusts their global score.
+func Rebuild(tx *gorm.DB, quizID, userID uint) error {
+	var cur models.LeaderboardEntry
+	res := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("quiz_id = ? AND user_id = ?", quizID, userID).Limit(1).Find(&cur)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 1 {
+		if err := tx.Delete(&cur).Error; err != nil {
+			return err
+		}
+		if err := addGlobal(tx, userID, -cur.BestMarks, -cur.BestScore, -durationOf(cur.BestDuration), -1); err != nil {
+			return err
+		}
+	}
+	var subs []models.Submission
+	if err := tx.Where("quiz_id = ? AND user_id = ? AND invalidated_at IS NULL", quizID, userID).
+		Order("id").Find(&subs).Error; err != nil {
+		return err
+	}
+	for i := range subs {
+		if err := Record(tx, &subs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addGlobal(tx *gorm.DB, userID uint, marks float64, points, duration, quizzes int) error {
+	gs := models.GlobalScore{UserID: userID}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&gs).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.GlobalScore{}).Where("user_id = ?", userID).Updates(map[string]any{
+		"marks":    gorm.Expr("marks + ?", marks),
+		"points":   gorm.Expr("points + ?", points),
+		"duration": gorm.Expr("duration + ?", duration),
+		"quizzes":  gorm.Expr("quizzes + ?", quizzes),
+	}).Error
+}
+
+// CheckQuiz returns ErrQuizHidden unless userID may see the quiz, and
+// access.ErrCodeRequired if it is code-protected and userID has no grant,
+// mirroring the checks for taking it.
+func (s *Service) CheckQuiz(quizID, userID uint) error {
+	var q models.Quiz
+	res := s.db.Limit(1).Find(&q, quizID)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrQuizHidden
+	}
+	visible, err := cohorts.CanSee(s.db, quizID, userID)
+	if err != nil {
+		return err
+	}
+	if !visible {
+		return ErrQuizHidden
+	}
+	return access.Check(s.db, &q, userID)
+}
+
+// QuizBoard returns a page of the quiz leaderboard, excluding opted-out users.
+func (s *Service) QuizBoard(quizID uint, page, limit int) (*BoardResp, error) {
+	q := s.quizVisible(quizID)
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	var rows []Row
+	if err := s.quizVisible(quizID).
+		Select(`e.user_id, u.username, e.best_marks AS marks, e.best_score AS score, e.best_percent AS percent,
+			e.best_duration AS duration_seconds, e.updated_at`).
+		Order("e.best_marks DESC").Order(quizDurationOrder).Order("e.updated_at, e.id").
+		Offset((page - 1) * limit).Limit(limit).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if err := assignRanks(rows, (page-1)*limit, func(r Row) (int64, error) {
+		return s.quizRankOf(quizID, r.Marks, r.DurationSeconds)
+	}); err != nil {
+		return nil, err
+	}
+	return &BoardResp{QuizID: &quizID, Rows: rows, TotalRecords: total, Page: page, Limit: limit}, nil
+}
+
+// GlobalBoard ranks users by the sum of their best per-quiz marks.
+func (s *Service) GlobalBoard(page, limit int) (*BoardResp, error) {
+	var total int64
+	if err := s.globalVisible().Count(&total).Error; err != nil {
+		return nil, err
+	}
+	var rows []Row
+	if err := s.globalVisible().
+		Select("g.user_id, u.username, g.marks, g.points AS score, g.duration AS duration_seconds, g.quizzes, g.updated_at").
+		Order("g.marks DESC, g.duration, g.user_id").
+		Offset((page - 1) * limit).Limit(limit).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if err := assignRanks(rows, (page-1)*limit, func(r Row) (int64, error) {
+		return s.globalRankOf(r.Marks, durationOf(r.DurationSeconds))
+	}); err != nil {
+		return nil, err
+	}
+	return &BoardResp{Rows: rows, TotalRecords: total, Page: page, Limit: limit}, nil
+}
+
+// MyQuizRank looks up the user's position on a quiz leaderboard.
+func (s *Service) MyQuizRank(quizID, userID uint) (*MyRankResp, error) {
+	var row Row
+	res := s.db.Table("leaderboard_entries e").Joins("JOIN users u ON u.id = e.user_id").
+		Where("e.quiz_id = ? AND e.user_id = ?", quizID, userID).
+		Select(`e.user_id, u.username, e.best_marks AS marks, e.best_score AS score, e.best_percent AS percent,
+			e.best_duration AS duration_seconds, e.updated_at`).Limit(1).Scan(&row)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return &MyRankResp{}, res.Error
+	}
+	rank, err := s.quizRankOf(quizID, row.Marks, row.DurationSeconds)
+	if err != nil {
+		return nil, err
+	}
+	row.Rank = rank
+	return &MyRankResp{Ranked: true, Row: &row, Hidden: s.optedOut(userID)}, nil
+}
+
+// MyGlobalRank looks up the user's position on the global leaderboard.
+func (s *Service) MyGlobalRank(userID uint) (*MyRankResp, error) {
+	var row Row
+	res := s.db.Table("global_scores g").Joins("JOIN users u ON u.id = g.user_id").
+		Where("g.user_id = ?", userID).
+		Select("g.user_id, u.username, g.marks, g.points AS score, g.duration AS duration_seconds, g.quizzes, g.updated_at").
+		Limit(1).Scan(&row)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return &MyRankResp{}, res.Error
+	}
+	rank, err := s.globalRankOf(row.Marks, durationOf(row.DurationSeconds))
+	if err != nil {
+		return nil, err
+	}
+	row.Rank = rank
+	return &MyRankResp{Ranked: true, Row: &row, Hidden: s.optedOut(userID)}, nil
+}
+
+func (s *Service) SetOptOut(userID uint, optOut bool) error {
+	return s.db.Model(&models.User{}).Where("id = ?", userID).Update("leaderboard_opt_out", optOut).Error
+}
+
+// --- helpers ---
+
+const quizDurationOrder = "COALESCE(e.best_duration, 2147483647)"
+
+func (s *Service) quizVisible(quizID uint) *gorm.DB {
+	return s.db.Table("leaderboard_entries e").Joins("JOIN users u ON u.id = e.user_id").
+		Where("e.quiz_id = ? AND u.leaderboard_opt_out = ?", quizID, false)
+}
+
+func (s *Service) globalVisible() *gorm.DB {
+	return s.db.Table("global_scores g").Joins("JOIN users u ON u.id = g.user_id").
+		Where("u.leaderboard_opt_out = ? AND g.quizzes > 0", false)
+}
+
+// quizRankOf is 1 + the number of visible entries strictly better than (marks, duration).
+func (s *Service) quizRankOf(quizID uint, marks float64, duration *int) (int64, error) {
+	var n int64
+	err := s.quizVisible(quizID).
+		Where("e.best_marks > ? OR (e.best_marks = ? AND "+quizDurationOrder+" < ?)",
+			marks, marks, durationOrMax(duration)).
+		Count(&n).Error
+	return n + 1, err
+}
+
+func (s *Service) globalRankOf(marks float64, duration int) (int64, error) {
+	var n int64
+	err := s.globalVisible().
+		Where("g.marks > ? OR (g.marks = ? AND g.duration < ?)", marks, marks, duration).
+		Count(&n).Error
+	return n + 1, err
+}
+
+// assignRanks gives tied rows the same (competition) rank. Only the first
+// row of the page needs a count query, since it may tie with the previous
+// page; every later row that differs from its predecessor ranks offset+i+1.
+func assignRanks(rows []Row, offset int, rankOf func(Row) (int64, error)) error {
+	for i := range rows {
+		switch {
+		case i == 0:
+			r, err := rankOf(rows[i])
+			if err != nil {
+				return err
+			}
+			rows[i].Rank = r
+		case rows[i].Marks == rows[i-1].Marks &&
+			durationOrMax(rows[i].DurationSeconds) == durationOrMax(rows[i-1].DurationSeconds):
+			rows[i].Rank = rows[i-1].Rank
+		default:
+			rows[i].Rank = int64(offset + i + 1)
+		}
+	}
+	return nil
+}
+
+func (s *Service) optedOut(userID uint) bool {
+	var u models.User
+	if err := s.db.Select("leaderboard_opt_out").First(&u, userID).Error; err != nil {
+		return false
+	}
+	return u.LeaderboardOptOut
+}
+
+// better reports whether a beats b: more marks, then shorter duration.
+func better(a, b models.LeaderboardEntry) bool {
+	if a.BestMarks != b.BestMarks {
+		return a.BestMarks > b.BestMarks
+	}
+	return durationOrMax(a.BestDuration) < durationOrMax(b.BestDuration)
+}
+
+func durationOrMax(d *int) int {
+	if d == nil {
+		return noDuration
+	}
+	return *d
+}
+
+func durationOf(d *int) int {
+	if d == nil {
+		return 0
+	}
+	return *d
+}
@@ -0,0 +1,120 @@
+package leaderboard_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"quizapi/internal/access"
+	"quizapi/internal/leaderboard"
+	"quizapi/internal/models"
+	"quizapi/internal/testutil"
+)
+
+func TestRecord_BestScoreWithTimeTiebreak(t *testing.T) {
+	d := testutil.DB(t)
+	for _, name := range []string{"ann", "bob", "cat"} {
+		require.NoError(t, d.Create(&models.User{Username: name, PasswordHash: "x", Role: models.RoleUser}).Error)
+	}
+	submit := func(quizID, userID uint, score, secs int) {
+		sub := &models.Submission{QuizID: quizID, UserID: userID, Score: score, Total: 10,
+			Marks: float64(score), MaxMarks: 10, Percent: score * 10, DurationSeconds: &secs}
+		require.NoError(t, d.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(sub).Error; err != nil {
+				return err
+			}
+			return leaderboard.Record(tx, sub)
+		}))
+	}
+	submit(1, 1, 7, 100)
+	submit(1, 1, 5, 50) // worse: ignored
+	submit(1, 2, 7, 80) // same score, faster
+	submit(1, 3, 9, 300)
+	submit(2, 1, 10, 60)
+
+	svc := leaderboard.NewService(d)
+	board, err := svc.QuizBoard(1, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, board.Rows, 3)
+	require.Equal(t, "cat", board.Rows[0].Username)
+	require.Equal(t, "bob", board.Rows[1].Username)
+	require.EqualValues(t, 3, board.Rows[2].Rank)
+
+	// ann: 7 + 10 across two quizzes
+	global, err := svc.GlobalBoard(1, 10)
+	require.NoError(t, err)
+	require.Equal(t, "ann", global.Rows[0].Username)
+	require.Equal(t, 17, global.Rows[0].Score)
+
+	// opting out hides cat from the board but their own rank is still available
+	require.NoError(t, svc.SetOptOut(3, true))
+	board, err = svc.QuizBoard(1, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, board.Rows, 2)
+	require.EqualValues(t, 1, board.Rows[0].Rank)
+	me, err := svc.MyQuizRank(1, 3)
+	require.NoError(t, err)
+	require.True(t, me.Hidden)
+	require.EqualValues(t, 1, me.Row.Rank)
+}
+
+func TestRecord_RanksByMarks(t *testing.T) {
+	d := testutil.DB(t)
+	for _, name := range []string{"ann", "bob"} {
+		require.NoError(t, d.Create(&models.User{Username: name, PasswordHash: "x", Role: models.RoleUser}).Error)
+	}
+	submit := func(userID uint, score int, marks float64) {
+		sub := &models.Submission{QuizID: 1, UserID: userID, Score: score, Total: 10,
+			Marks: marks, MaxMarks: 10, Percent: models.Percent(marks, 10)}
+		require.NoError(t, d.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(sub).Error; err != nil {
+				return err
+			}
+			return leaderboard.Record(tx, sub)
+		}))
+	}
+	// under negative marking more right answers can mean fewer marks
+	submit(1, 8, 7.5)
+	submit(2, 7, 7)
+	submit(2, 8, 6.5) // fewer marks: ignored
+
+	svc := leaderboard.NewService(d)
+	board, err := svc.QuizBoard(1, 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, "ann", board.Rows[0].Username)
+	require.Equal(t, 7.5, board.Rows[0].Marks)
+	require.Equal(t, "bob", board.Rows[1].Username)
+	require.Equal(t, 7, board.Rows[1].Score)
+	me, err := svc.MyQuizRank(1, 2)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, me.Row.Rank)
+
+	require.NoError(t, d.Transaction(func(tx *gorm.DB) error { return leaderboard.Rebuild(tx, 1, 2) }))
+	global, err := svc.GlobalBoard(1, 10)
+	require.NoError(t, err)
+	require.Equal(t, 7.0, global.Rows[1].Marks)
+	require.Equal(t, 7, global.Rows[1].Score)
+}
+
+func TestCheckQuiz_HidesBoardsOfUnseenQuizzes(t *testing.T) {
+	d := testutil.DB(t)
+	open := models.Quiz{Title: "Open"}
+	assigned := models.Quiz{Title: "Assigned"}
+	coded := models.Quiz{Title: "Coded", Visibility: models.VisibilityCode}
+	for _, q := range []*models.Quiz{&open, &assigned, &coded} {
+		require.NoError(t, d.Create(q).Error)
+	}
+	require.NoError(t, d.Create(&models.QuizAssignment{QuizID: assigned.ID, CohortID: 1}).Error)
+
+	svc := leaderboard.NewService(d)
+	require.NoError(t, svc.CheckQuiz(open.ID, 2))
+	require.ErrorIs(t, svc.CheckQuiz(open.ID+100, 2), leaderboard.ErrQuizHidden)
+	require.ErrorIs(t, svc.CheckQuiz(assigned.ID, 2), leaderboard.ErrQuizHidden)
+	require.ErrorIs(t, svc.CheckQuiz(coded.ID, 2), access.ErrCodeRequired)
+
+	require.NoError(t, d.Create(&models.CohortMember{CohortID: 1, UserID: 2}).Error)
+	require.NoError(t, d.Create(&models.AccessGrant{QuizID: coded.ID, UserID: 2}).Error)
+	require.NoError(t, svc.CheckQuiz(assigned.ID, 2))
+	require.NoError(t, svc.CheckQuiz(coded.ID, 2))
+}
@@ -0,0 +1,95 @@
+package live
+
+import "quizapi/internal/quizzes"
+
+type CreateSessionReq struct {
+	QuizID uint `json:"quiz_id" validate:"required"`
+}
+
+type SessionResp struct {
+	PIN       string `json:"pin"`
+	QuizID    uint   `json:"quiz_id"`
+	Title     string `json:"title"`
+	Questions int    `json:"questions"`
+}
+
+// Message types. The host sends next, close and end; participants send
+// answer. Everything else is sent by the server.
+const (
+	MsgNext   = "next"   // close the open question, if any, and open the next one
+	MsgClose  = "close"  // stop accepting answers and publish results
+	MsgEnd    = "end"    // end the session with the final leaderboard
+	MsgAnswer = "answer" // answer the open question
+
+	MsgLobby    = "lobby"           // who has joined so far
+	MsgQuestion = "question"        // a question was opened
+	MsgAccepted = "answer_accepted" // to the participant who answered
+	MsgAnswered = "answered"        // to the host, as answers come in
+	MsgResults  = "results"         // a question was closed
+	MsgEnded    = "ended"
+	MsgError    = "error"
+)
+
+// ClientMsg is a message from the host or a participant. Answers carry
+// the same fields as quizzes.SubmitAnswer.
+type ClientMsg struct {
+	Type string `json:"type"`
+	quizzes.SubmitAnswer
+}
+
+type LobbyMsg struct {
+	Type    string   `json:"type"`
+	PIN     string   `json:"pin"`
+	Title   string   `json:"title"`
+	Players []string `json:"players"`
+}
+
+type QuestionMsg struct {
+	Type     string                 `json:"type"`
+	Index    int                    `json:"index"`
+	Count    int                    `json:"count"`
+	Question quizzes.PublicQuestion `json:"question"`
+}
+
+type AcceptedMsg struct {
+	Type       string `json:"type"`
+	QuestionID uint   `json:"question_id"`
+}
+
+type AnsweredMsg struct {
+	Type     string `json:"type"`
+	Answered int    `json:"answered"`
+	Players  int    `json:"players"`
+}
+
+// Standing is a participant's place after a question. Ties on score go to
+// the lower total answer time, as on the quiz leaderboards.
+type Standing struct {
+	Rank     int    `json:"rank"`
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Score    int    `json:"score"`
+	AnswerMS int64  `json:"answer_ms"`
+}
+
+// ResultsMsg is sent when a question closes. OptionCounts is keyed by
+// option ID; text questions have no correct options and are not scored.
+type ResultsMsg struct {
+	Type             string        `json:"type"`
+	QuestionID       uint          `json:"question_id"`
+	CorrectOptionIDs []uint        `json:"correct_option_ids"`
+	OptionCounts     map[uint]int  `json:"option_counts"`
+	Answered         int           `json:"answered"`
+	Correct          map[uint]bool `json:"correct"` // by user ID
+	Leaderboard      []Standing    `json:"leaderboard"`
+}
+
+type EndedMsg struct {
+	Type        string     `json:"type"`
+	Leaderboard []Standing `json:"leaderboard"`
+}
+
+type ErrorMsg struct {
+	Type  string `json:"type"`
+	Error string `json:"error"`
+}
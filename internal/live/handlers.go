@@ -0,0 +1,83 @@
+package live
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/websocket"
+)
+
+type Handler struct {
+	svc      *Service
+	val      *validator.Validate
+	upgrader websocket.Upgrader
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc, val: validator.New(), upgrader: websocket.Upgrader{
+		// Clients authenticate with a bearer token rather than cookies, so
+		// a page on another origin can't ride on a user's session.
+		CheckOrigin: func(*http.Request) bool { return true },
+	}}
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	var req CreateSessionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := h.svc.Create(req.QuizID, c.GetUint("userID"))
+	if err != nil {
+		writeErr(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// Host upgrades the host's connection. Errors before the upgrade are
+// plain JSON responses.
+func (h *Handler) Host(c *gin.Context) {
+	pin := c.Param("pin")
+	if err := h.svc.CanHost(pin, c.GetUint("userID")); err != nil {
+		writeErr(c, err)
+		return
+	}
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return // the upgrader has already replied
+	}
+	h.svc.ServeHost(pin, conn)
+}
+
+func (h *Handler) Join(c *gin.Context) {
+	pin := c.Param("pin")
+	if err := h.svc.CanJoin(pin); err != nil {
+		writeErr(c, err)
+		return
+	}
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	h.svc.ServeParticipant(pin, c.GetUint("userID"), conn)
+}
+
+func writeErr(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrQuizNotFound), errors.Is(err, ErrSessionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrNotHost):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrNoQuestions):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
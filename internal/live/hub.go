@@ -0,0 +1,410 @@
+package live
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"quizapi/internal/models"
+	"quizapi/internal/quizzes"
+)
+
+const (
+	writeWait  = 10 * time.Second
+	pongWait   = 60 * time.Second
+	pingPeriod = pongWait * 9 / 10
+	sendBuffer = 32
+	maxMessage = 64 << 10
+	// sessions are dropped this long after they were created, even if the
+	// host never ended them
+	maxSessionAge = 12 * time.Hour
+)
+
+// client is one WebSocket connection. Writes go through send so a slow
+// client never blocks the session; one that falls too far behind is dropped.
+type client struct {
+	conn *websocket.Conn
+	send chan any
+	done chan struct{}
+	once sync.Once
+}
+
+// closeAfterFlush is queued to close the connection once pending messages
+// have been written.
+type closeAfterFlush struct{}
+
+func newClient(conn *websocket.Conn) *client {
+	c := &client{conn: conn, send: make(chan any, sendBuffer), done: make(chan struct{})}
+	go c.writeLoop()
+	return c
+}
+
+func (c *client) push(msg any) {
+	select {
+	case <-c.done:
+	case c.send <- msg:
+	default:
+		c.close()
+	}
+}
+
+func (c *client) close() {
+	c.once.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+func (c *client) writeLoop() {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case msg := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if _, ok := msg.(closeAfterFlush); ok {
+				c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				c.close()
+				return
+			}
+			if err := c.conn.WriteJSON(msg); err != nil {
+				c.close()
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				c.close()
+				return
+			}
+		}
+	}
+}
+
+// readLoop hands every message from the connection to handle until the
+// connection fails or is closed.
+func (c *client) readLoop(handle func(ClientMsg)) {
+	c.conn.SetReadLimit(maxMessage)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var msg ClientMsg
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.push(ErrorMsg{Type: MsgError, Error: "invalid message: " + err.Error()})
+			continue
+		}
+		handle(msg)
+	}
+}
+
+type player struct {
+	userID   uint
+	name     string
+	score    int
+	answerMS int64
+	client   *client // nil while disconnected
+}
+
+type liveAnswer struct {
+	optionIDs []uint
+	gradable  bool
+	correct   bool
+	ms        int64
+}
+
+// session is one live run of a quiz. All state is guarded by mu, and every
+// message is pushed while holding it so clients see events in order.
+type session struct {
+	mu        sync.Mutex
+	pin       string
+	quizID    uint
+	title     string
+	hostID    uint
+	createdAt time.Time
+	questions []models.Question
+
+	host    *client
+	players map[uint]*player
+	order   []uint // user IDs in join order
+
+	current  int // index of the current question, -1 before the first
+	open     bool
+	openedAt time.Time
+	answers  map[uint]liveAnswer
+	ended    bool
+}
+
+func (s *session) broadcast(msg any) {
+	if s.host != nil {
+		s.host.push(msg)
+	}
+	for _, p := range s.players {
+		if p.client != nil {
+			p.client.push(msg)
+		}
+	}
+}
+
+func (s *session) lobby() LobbyMsg {
+	names := make([]string, 0, len(s.order))
+	for _, id := range s.order {
+		names = append(names, s.players[id].name)
+	}
+	return LobbyMsg{Type: MsgLobby, PIN: s.pin, Title: s.title, Players: names}
+}
+
+func (s *session) questionMsg() QuestionMsg {
+	return QuestionMsg{
+		Type: MsgQuestion, Index: s.current, Count: len(s.questions),
+		Question: quizzes.ToPublicQuestion(s.questions[s.current]),
+	}
+}
+
+// attachHost makes c the host connection, replacing any previous one.
+func (s *session) attachHost(c *client) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return false
+	}
+	if s.host != nil {
+		s.host.close()
+	}
+	s.host = c
+	c.push(s.lobby())
+	if s.open {
+		c.push(s.questionMsg())
+		c.push(AnsweredMsg{Type: MsgAnswered, Answered: len(s.answers), Players: len(s.players)})
+	}
+	return true
+}
+
+// attachPlayer joins a participant, or reconnects one with their score intact.
+func (s *session) attachPlayer(userID uint, name string, c *client) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return false
+	}
+	p, ok := s.players[userID]
+	if !ok {
+		p = &player{userID: userID, name: name}
+		s.players[userID] = p
+		s.order = append(s.order, userID)
+	} else if p.client != nil {
+		p.client.close()
+	}
+	p.client = c
+	s.broadcast(s.lobby())
+	if _, answered := s.answers[userID]; s.open && !answered {
+		c.push(s.questionMsg())
+	}
+	return true
+}
+
+func (s *session) detach(c *client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.host == c {
+		s.host = nil
+	}
+	for _, p := range s.players {
+		if p.client == c {
+			p.client = nil
+		}
+	}
+}
+
+// handleHost applies a host command. It reports whether the session ended.
+func (s *session) handleHost(c *client, msg ClientMsg) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return true
+	}
+	switch msg.Type {
+	case MsgNext:
+		if s.open {
+			s.closeQuestion()
+		}
+		if s.current+1 >= len(s.questions) {
+			s.end()
+			return true
+		}
+		s.current++
+		s.open, s.openedAt, s.answers = true, time.Now(), map[uint]liveAnswer{}
+		s.broadcast(s.questionMsg())
+	case MsgClose:
+		if !s.open {
+			c.push(ErrorMsg{Type: MsgError, Error: "no question is open"})
+			return false
+		}
+		s.closeQuestion()
+	case MsgEnd:
+		if s.open {
+			s.closeQuestion()
+		}
+		s.end()
+		return true
+	default:
+		c.push(ErrorMsg{Type: MsgError, Error: fmt.Sprintf("unknown message type %q", msg.Type)})
+	}
+	return false
+}
+
+// handleAnswer records a participant's answer to the open question. The
+// question closes by itself once every participant has answered.
+func (s *session) handleAnswer(userID uint, c *client, msg ClientMsg) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if msg.Type != MsgAnswer {
+		c.push(ErrorMsg{Type: MsgError, Error: fmt.Sprintf("unknown message type %q", msg.Type)})
+		return
+	}
+	if !s.open {
+		c.push(ErrorMsg{Type: MsgError, Error: "no question is open"})
+		return
+	}
+	q := s.questions[s.current]
+	if msg.QuestionID != q.ID {
+		c.push(ErrorMsg{Type: MsgError, Error: fmt.Sprintf("question %d is not open", msg.QuestionID)})
+		return
+	}
+	if _, ok := s.answers[userID]; ok {
+		c.push(ErrorMsg{Type: MsgError, Error: "question already answered"})
+		return
+	}
+	gradable, correct, err := quizzes.Grade(q, msg.SubmitAnswer)
+	if err != nil {
+		c.push(ErrorMsg{Type: MsgError, Error: err.Error()})
+		return
+	}
+	a := liveAnswer{gradable: gradable, correct: correct, ms: time.Since(s.openedAt).Milliseconds()}
+	switch q.Type {
+	case models.QSingle:
+		a.optionIDs = []uint{*msg.SelectedOptionID}
+	case models.QMultiple:
+		a.optionIDs = slices.Compact(slices.Sorted(slices.Values(msg.SelectedOptionIDs)))
+	}
+	s.answers[userID] = a
+	c.push(AcceptedMsg{Type: MsgAccepted, QuestionID: q.ID})
+	if s.host != nil {
+		s.host.push(AnsweredMsg{Type: MsgAnswered, Answered: len(s.answers), Players: len(s.players)})
+	}
+	if len(s.answers) == len(s.players) {
+		s.closeQuestion()
+	}
+}
+
+// closeQuestion stops accepting answers, scores them and publishes the
+// results with the updated leaderboard.
+func (s *session) closeQuestion() {
+	s.open = false
+	q := s.questions[s.current]
+	res := ResultsMsg{
+		Type: MsgResults, QuestionID: q.ID, CorrectOptionIDs: []uint{},
+		OptionCounts: map[uint]int{}, Answered: len(s.answers), Correct: map[uint]bool{},
+	}
+	for _, o := range q.Options {
+		res.OptionCounts[o.ID] = 0
+		if o.IsCorrect && q.Type != models.QText {
+			res.CorrectOptionIDs = append(res.CorrectOptionIDs, o.ID)
+		}
+	}
+	for userID, a := range s.answers {
+		for _, id := range a.optionIDs {
+			res.OptionCounts[id]++
+		}
+		if !a.gradable {
+			continue
+		}
+		p := s.players[userID]
+		p.answerMS += a.ms
+		res.Correct[userID] = a.correct
+		if a.correct {
+			p.score++
+		}
+	}
+	res.Leaderboard = s.standings()
+	s.broadcast(res)
+}
+
+func (s *session) end() {
+	s.ended = true
+	s.broadcast(EndedMsg{Type: MsgEnded, Leaderboard: s.standings()})
+	s.broadcast(closeAfterFlush{})
+}
+
+func (s *session) standings() []Standing {
+	out := make([]Standing, 0, len(s.players))
+	for _, id := range s.order {
+		p := s.players[id]
+		out = append(out, Standing{UserID: p.userID, Username: p.name, Score: p.score, AnswerMS: p.answerMS})
+	}
+	slices.SortStableFunc(out, func(a, b Standing) int {
+		if a.Score != b.Score {
+			return cmp.Compare(b.Score, a.Score)
+		}
+		return cmp.Compare(a.AnswerMS, b.AnswerMS)
+	})
+	for i := range out {
+		out[i].Rank = i + 1
+		if i > 0 && out[i].Score == out[i-1].Score && out[i].AnswerMS == out[i-1].AnswerMS {
+			out[i].Rank = out[i-1].Rank
+		}
+	}
+	return out
+}
+
+// hub holds the sessions that are running in this process, by PIN.
+type hub struct {
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+func (h *hub) add(s *session) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for pin, old := range h.sessions {
+		if time.Since(old.createdAt) > maxSessionAge {
+			old.mu.Lock()
+			if !old.ended {
+				old.end()
+			}
+			old.mu.Unlock()
+			delete(h.sessions, pin)
+		}
+	}
+	for {
+		s.pin = fmt.Sprintf("%06d", rand.IntN(1_000_000))
+		if _, taken := h.sessions[s.pin]; !taken {
+			break
+		}
+	}
+	h.sessions[s.pin] = s
+}
+
+func (h *hub) get(pin string) *session {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.sessions[pin]
+}
+
+func (h *hub) remove(pin string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.sessions, pin)
+}
@@ -0,0 +1,119 @@
+package live
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"gorm.io/gorm"
+
+	"quizapi/internal/models"
+	"quizapi/internal/quizzes"
+)
+
+var (
+	ErrQuizNotFound    = errors.New("quiz not found")
+	ErrNoQuestions     = errors.New("quiz has no questions")
+	ErrSessionNotFound = errors.New("live session not found")
+	ErrNotHost         = errors.New("only the host can run this session")
+)
+
+// Service runs live sessions. Sessions live in memory in this process;
+// results are not stored as submissions.
+type Service struct {
+	db  *gorm.DB
+	hub *hub
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db, hub: &hub{sessions: map[string]*session{}}}
+}
+
+// Create starts a session for the quiz with hostID as its host and returns
+// the PIN participants join with. The questions are loaded once, so edits
+// made while the session runs don't affect it.
+func (s *Service) Create(quizID, hostID uint) (*SessionResp, error) {
+	var quiz models.Quiz
+	if err := s.db.First(&quiz, quizID).Error; err != nil {
+		return nil, ErrQuizNotFound
+	}
+	var qs []models.Question
+	if err := s.db.Preload("Options", quizzes.ByPosition).Scopes(quizzes.ByPosition).
+		Where("quiz_id = ?", quizID).Find(&qs).Error; err != nil {
+		return nil, err
+	}
+	if len(qs) == 0 {
+		return nil, ErrNoQuestions
+	}
+	sess := &session{
+		quizID:    quizID,
+		title:     quiz.Title,
+		hostID:    hostID,
+		createdAt: time.Now(),
+		questions: qs,
+		players:   map[uint]*player{},
+		current:   -1,
+	}
+	s.hub.add(sess)
+	return &SessionResp{PIN: sess.pin, QuizID: quizID, Title: quiz.Title, Questions: len(qs)}, nil
+}
+
+// CanHost checks that the session exists and userID is its host.
+func (s *Service) CanHost(pin string, userID uint) error {
+	sess := s.hub.get(pin)
+	if sess == nil {
+		return ErrSessionNotFound
+	}
+	if sess.hostID != userID {
+		return ErrNotHost
+	}
+	return nil
+}
+
+// CanJoin checks that the session exists.
+func (s *Service) CanJoin(pin string) error {
+	if s.hub.get(pin) == nil {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// ServeHost runs the host's connection until it closes. A host that
+// reconnects replaces its previous connection.
+func (s *Service) ServeHost(pin string, conn *websocket.Conn) {
+	c := newClient(conn)
+	sess := s.hub.get(pin)
+	if sess == nil || !sess.attachHost(c) {
+		c.push(ErrorMsg{Type: MsgError, Error: ErrSessionNotFound.Error()})
+		c.push(closeAfterFlush{})
+		return
+	}
+	c.readLoop(func(msg ClientMsg) {
+		if sess.handleHost(c, msg) {
+			s.hub.remove(pin)
+		}
+	})
+	sess.detach(c)
+	c.close()
+}
+
+// ServeParticipant runs a participant's connection until it closes.
+// Rejoining keeps the participant's score.
+func (s *Service) ServeParticipant(pin string, userID uint, conn *websocket.Conn) {
+	c := newClient(conn)
+	var user models.User
+	if err := s.db.Select("id, username").First(&user, userID).Error; err != nil {
+		c.push(ErrorMsg{Type: MsgError, Error: "user not found"})
+		c.push(closeAfterFlush{})
+		return
+	}
+	sess := s.hub.get(pin)
+	if sess == nil || !sess.attachPlayer(userID, user.Username, c) {
+		c.push(ErrorMsg{Type: MsgError, Error: ErrSessionNotFound.Error()})
+		c.push(closeAfterFlush{})
+		return
+	}
+	c.readLoop(func(msg ClientMsg) { sess.handleAnswer(userID, c, msg) })
+	sess.detach(c)
+	c.close()
+}
@@ -0,0 +1,135 @@
+package live_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"quizapi/internal/live"
+	"quizapi/internal/models"
+	"quizapi/internal/quizzes"
+	"quizapi/internal/testutil"
+)
+
+const host = 1
+
+func ptr[T any](v T) *T { return &v }
+
+// conn is a test client that decodes server messages into a map.
+type conn struct {
+	t  *testing.T
+	ws *websocket.Conn
+}
+
+func dial(t *testing.T, srv *httptest.Server, path string) *conn {
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+path, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { ws.Close() })
+	return &conn{t: t, ws: ws}
+}
+
+func (c *conn) send(msg any) { require.NoError(c.t, c.ws.WriteJSON(msg)) }
+
+// next returns the next message of the given type, skipping others.
+func (c *conn) next(typ string) map[string]any {
+	for {
+		c.ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var msg map[string]any
+		require.NoError(c.t, c.ws.ReadJSON(&msg))
+		if msg["type"] == typ {
+			return msg
+		}
+	}
+}
+
+func TestLiveSession(t *testing.T) {
+	d := testutil.DB(t)
+	for id, name := range map[uint]string{host: "host", 2: "ada", 3: "bob"} {
+		require.NoError(t, d.Create(&models.User{ID: id, Username: name, PasswordHash: "x", Role: models.RoleUser}).Error)
+	}
+	qsvc := quizzes.NewService(d)
+	qz, err := qsvc.CreateQuiz("Workshop")
+	require.NoError(t, err)
+	for _, text := range []string{"First?", "Second?"} {
+		_, err = qsvc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{Text: text, Type: "single",
+			Options: []quizzes.CreateQuestionOption{{Text: "yes", IsCorrect: ptr(true)}, {Text: "no", IsCorrect: ptr(false)}}})
+		require.NoError(t, err)
+	}
+
+	svc := live.NewService(d)
+	sess, err := svc.Create(qz.ID, host)
+	require.NoError(t, err)
+	require.Len(t, sess.PIN, 6)
+	require.ErrorIs(t, svc.CanHost(sess.PIN, 2), live.ErrNotHost)
+
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		if r.URL.Path == "/host" {
+			svc.ServeHost(sess.PIN, ws)
+			return
+		}
+		user, _ := strconv.Atoi(r.URL.Query().Get("user"))
+		svc.ServeParticipant(sess.PIN, uint(user), ws)
+	}))
+	defer srv.Close()
+
+	h := dial(t, srv, "/host")
+	h.next(live.MsgLobby)
+	ada := dial(t, srv, "/join?user=2")
+	ada.next(live.MsgLobby)
+	bob := dial(t, srv, "/join?user=3")
+	require.Equal(t, []any{"ada", "bob"}, bob.next(live.MsgLobby)["players"])
+
+	// answers are refused until the host opens a question
+	ada.send(map[string]any{"type": live.MsgAnswer, "question_id": 1, "selected_option_id": 1})
+	require.Equal(t, "no question is open", ada.next(live.MsgError)["error"])
+
+	h.send(map[string]any{"type": live.MsgNext})
+	q := ada.next(live.MsgQuestion)["question"].(map[string]any)
+	bob.next(live.MsgQuestion)
+	qid := q["id"].(float64)
+	opts := q["options"].([]any)
+	yes, no := opts[0].(map[string]any)["id"], opts[1].(map[string]any)["id"]
+
+	// answers go through the usual validation
+	ada.send(map[string]any{"type": live.MsgAnswer, "question_id": qid, "selected_option_id": 999})
+	require.Contains(t, ada.next(live.MsgError)["error"], "invalid for question")
+
+	ada.send(map[string]any{"type": live.MsgAnswer, "question_id": qid, "selected_option_id": yes})
+	ada.next(live.MsgAccepted)
+	ada.send(map[string]any{"type": live.MsgAnswer, "question_id": qid, "selected_option_id": no})
+	require.Equal(t, "question already answered", ada.next(live.MsgError)["error"])
+	require.EqualValues(t, 1, h.next(live.MsgAnswered)["answered"])
+
+	// the question closes by itself once everyone has answered
+	bob.send(map[string]any{"type": live.MsgAnswer, "question_id": qid, "selected_option_id": no})
+	res := h.next(live.MsgResults)
+	require.Equal(t, []any{yes}, res["correct_option_ids"])
+	board := res["leaderboard"].([]any)
+	require.Equal(t, "ada", board[0].(map[string]any)["username"])
+	require.EqualValues(t, 1, board[0].(map[string]any)["score"])
+	require.EqualValues(t, 0, board[1].(map[string]any)["score"])
+
+	// the host can close a question early; late answers are refused
+	h.send(map[string]any{"type": live.MsgNext})
+	q = bob.next(live.MsgQuestion)["question"].(map[string]any)
+	h.send(map[string]any{"type": live.MsgClose})
+	bob.next(live.MsgResults)
+	bob.send(map[string]any{"type": live.MsgAnswer, "question_id": q["id"], "selected_option_id": 1})
+	require.Equal(t, "no question is open", bob.next(live.MsgError)["error"])
+
+	h.send(map[string]any{"type": live.MsgNext})
+	ended := ada.next(live.MsgEnded)
+	require.Len(t, ended["leaderboard"], 2)
+	require.Eventually(t, func() bool { return svc.CanJoin(sess.PIN) != nil }, time.Second, 10*time.Millisecond)
+}
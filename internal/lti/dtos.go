@@ -0,0 +1,137 @@
+package lti
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type CreatePlatformReq struct {
+	Name         string `json:"name" validate:"max=200"`
+	Issuer       string `json:"issuer" validate:"required,url,max=255"`
+	ClientID     string `json:"client_id" validate:"required,max=255"`
+	DeploymentID string `json:"deployment_id" validate:"max=255"`
+	AuthLoginURL string `json:"auth_login_url" validate:"required,url,max=2048"`
+	AuthTokenURL string `json:"auth_token_url" validate:"required,url,max=2048"`
+	KeySetURL    string `json:"key_set_url" validate:"required,url,max=2048"`
+}
+
+// LoginReq holds the third-party initiated login parameters the platform
+// sends to /lti/login.
+type LoginReq struct {
+	Issuer          string `form:"iss"`
+	LoginHint       string `form:"login_hint"`
+	TargetLinkURI   string `form:"target_link_uri"`
+	LTIMessageHint  string `form:"lti_message_hint"`
+	ClientID        string `form:"client_id"`
+	LTIDeploymentID string `form:"lti_deployment_id"`
+}
+
+// LaunchResp is returned after a successful launch. For deep linking
+// requests QuizID is empty and DeepLinkSession must be passed to
+// POST /lti/deep-link together with the chosen quiz.
+type LaunchResp struct {
+	Token           string `json:"token"`
+	UserID          uint   `json:"user_id"`
+	MessageType     string `json:"message_type"`
+	QuizID          *uint  `json:"quiz_id,omitempty"`
+	DeepLinkSession string `json:"deep_link_session,omitempty"`
+}
+
+type DeepLinkReq struct {
+	Session string `json:"session" validate:"required"`
+	QuizID  uint   `json:"quiz_id" validate:"required"`
+	Title   string `json:"title" validate:"max=200"`
+}
+
+// DeepLinkResp carries the signed response; the browser must POST it as
+// the form field "JWT" to ReturnURL.
+type DeepLinkResp struct {
+	ReturnURL string `json:"return_url"`
+	JWT       string `json:"jwt"`
+}
+
+// Values from the LTI 1.3, Deep Linking 2.0 and AGS 2.0 specs.
+const (
+	msgResource  = "LtiResourceLinkRequest"
+	msgDeepLink  = "LtiDeepLinkingRequest"
+	msgDLResp    = "LtiDeepLinkingResponse"
+	ltiVersion   = "1.3.0"
+	scopeScore   = "https://purl.imsglobal.org/spec/lti-ags/scope/score"
+	customQuizID = "quiz_id"
+)
+
+type resourceLink struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+}
+
+type agsEndpoint struct {
+	Scope     []string `json:"scope"`
+	LineItems string   `json:"lineitems,omitempty"`
+	LineItem  string   `json:"lineitem,omitempty"`
+}
+
+type deepLinkSettings struct {
+	ReturnURL   string   `json:"deep_link_return_url"`
+	AcceptTypes []string `json:"accept_types"`
+	Data        string   `json:"data,omitempty"`
+}
+
+// launchClaims is the subset of the platform id_token this tool uses.
+type launchClaims struct {
+	jwt.RegisteredClaims
+	Nonce            string            `json:"nonce"`
+	AZP              string            `json:"azp,omitempty"`
+	Name             string            `json:"name,omitempty"`
+	MessageType      string            `json:"https://purl.imsglobal.org/spec/lti/claim/message_type"`
+	Version          string            `json:"https://purl.imsglobal.org/spec/lti/claim/version"`
+	DeploymentID     string            `json:"https://purl.imsglobal.org/spec/lti/claim/deployment_id"`
+	TargetLinkURI    string            `json:"https://purl.imsglobal.org/spec/lti/claim/target_link_uri"`
+	ResourceLink     *resourceLink     `json:"https://purl.imsglobal.org/spec/lti/claim/resource_link,omitempty"`
+	Roles            []string          `json:"https://purl.imsglobal.org/spec/lti/claim/roles"`
+	Custom           map[string]any    `json:"https://purl.imsglobal.org/spec/lti/claim/custom,omitempty"`
+	AGS              *agsEndpoint      `json:"https://purl.imsglobal.org/spec/lti-ags/claim/endpoint,omitempty"`
+	DeepLinkSettings *deepLinkSettings `json:"https://purl.imsglobal.org/spec/lti-dl/claim/deep_linking_settings,omitempty"`
+}
+
+// sessionClaims is the tool-signed deep linking session handed back to the
+// client between the launch and the quiz selection.
+type sessionClaims struct {
+	jwt.RegisteredClaims
+	PlatformID   uint   `json:"pid"`
+	DeploymentID string `json:"deployment_id"`
+	ReturnURL    string `json:"return_url"`
+	Data         string `json:"data,omitempty"`
+}
+
+type contentItem struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title,omitempty"`
+	URL      string            `json:"url"`
+	Custom   map[string]string `json:"custom,omitempty"`
+	LineItem *lineItem         `json:"lineItem,omitempty"`
+}
+
+type lineItem struct {
+	Label        string  `json:"label"`
+	ScoreMaximum float64 `json:"scoreMaximum"`
+}
+
+type deepLinkResponseClaims struct {
+	jwt.RegisteredClaims
+	Nonce        string        `json:"nonce"`
+	MessageType  string        `json:"https://purl.imsglobal.org/spec/lti/claim/message_type"`
+	Version      string        `json:"https://purl.imsglobal.org/spec/lti/claim/version"`
+	DeploymentID string        `json:"https://purl.imsglobal.org/spec/lti/claim/deployment_id"`
+	Data         string        `json:"https://purl.imsglobal.org/spec/lti-dl/claim/data,omitempty"`
+	ContentItems []contentItem `json:"https://purl.imsglobal.org/spec/lti-dl/claim/content_items"`
+}
+
+// score is an AGS score publish body.
+type score struct {
+	UserID           string  `json:"userId"`
+	ScoreGiven       float64 `json:"scoreGiven"`
+	ScoreMaximum     float64 `json:"scoreMaximum"`
+	ActivityProgress string  `json:"activityProgress"`
+	GradingProgress  string  `json:"gradingProgress"`
+	Timestamp        string  `json:"timestamp"`
+}
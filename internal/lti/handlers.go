@@ -0,0 +1,116 @@
+package lti
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type Handler struct {
+	svc *Service
+	val *validator.Validate
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc, val: validator.New()}
+}
+
+func (h *Handler) CreatePlatform(c *gin.Context) {
+	var req CreatePlatformReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	p, err := h.svc.CreatePlatform(req)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "could not register platform: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, p)
+}
+
+func (h *Handler) ListPlatforms(c *gin.Context) {
+	ps, err := h.svc.ListPlatforms()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ps)
+}
+
+func (h *Handler) JWKS(c *gin.Context) {
+	c.JSON(http.StatusOK, h.svc.Keys().JWKS())
+}
+
+// Login accepts the login initiation as GET query or POST form.
+func (h *Handler) Login(c *gin.Context) {
+	var req LoginReq
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	redirect, err := h.svc.Login(req)
+	if err != nil {
+		writeErr(c, err)
+		return
+	}
+	c.Redirect(http.StatusFound, redirect)
+}
+
+// Launch receives the platform's form_post with id_token and state.
+func (h *Handler) Launch(c *gin.Context) {
+	if e := c.PostForm("error"); e != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": e, "error_description": c.PostForm("error_description")})
+		return
+	}
+	state, idToken := c.PostForm("state"), c.PostForm("id_token")
+	if state == "" || idToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "state and id_token are required"})
+		return
+	}
+	resp, err := h.svc.Launch(c.Request.Context(), state, idToken)
+	if err != nil {
+		writeErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) DeepLink(c *gin.Context) {
+	var req DeepLinkReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := h.svc.DeepLink(req)
+	if err != nil {
+		writeErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func writeErr(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrUnknownPlatform):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalidLaunch):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrQuizNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrNotInstructor):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,178 @@
+package lti
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// KeySet is the tool's RSA signing key, published at /lti/jwks so
+// platforms can verify deep linking responses and client assertions.
+type KeySet struct {
+	key *rsa.PrivateKey
+	kid string
+}
+
+// LoadKeySet reads a PEM (PKCS#1 or PKCS#8) RSA private key. With an empty
+// path a fresh key is generated; it is lost on restart, so platforms that
+// cache the JWKS will reject messages until they refetch it.
+func LoadKeySet(path string) (*KeySet, error) {
+	if path == "" {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		return NewKeySet(key), nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("lti key: no PEM block found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return NewKeySet(key), nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("lti key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("lti key: not an RSA key")
+	}
+	return NewKeySet(key), nil
+}
+
+// NewKeySet wraps key; the key ID is derived from the public key.
+func NewKeySet(key *rsa.PrivateKey) *KeySet {
+	der := x509.MarshalPKCS1PublicKey(&key.PublicKey)
+	sum := sha256.Sum256(der)
+	return &KeySet{key: key, kid: base64.RawURLEncoding.EncodeToString(sum[:12])}
+}
+
+func (k *KeySet) Public() *rsa.PublicKey { return &k.key.PublicKey }
+
+// JWKS returns the public key as a JSON Web Key Set.
+func (k *KeySet) JWKS() map[string]any {
+	pub := k.key.PublicKey
+	return map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"use": "sig",
+		"alg": "RS256",
+		"kid": k.kid,
+		"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}}}
+}
+
+const (
+	jwksTTL        = time.Hour
+	jwksMinRefresh = time.Minute // unknown kids can't force more fetches than this
+)
+
+type cachedKeys struct {
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+}
+
+// keyCache fetches and caches platform key sets by URL.
+type keyCache struct {
+	client *http.Client
+	mu     sync.Mutex
+	sets   map[string]cachedKeys
+}
+
+func newKeyCache(client *http.Client) *keyCache {
+	return &keyCache{client: client, sets: map[string]cachedKeys{}}
+}
+
+// key returns the platform key with the given kid, refetching the set when
+// it is stale or the kid is unknown (platforms rotate keys).
+func (c *keyCache) key(ctx context.Context, url, kid string) (*rsa.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	set, ok := c.sets[url]
+	age := time.Since(set.fetched)
+	if ok && age < jwksTTL {
+		if k := pick(set.keys, kid); k != nil {
+			return k, nil
+		}
+		if age < jwksMinRefresh {
+			return nil, fmt.Errorf("unknown key %q", kid)
+		}
+	}
+	keys, err := c.fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	c.sets[url] = cachedKeys{keys: keys, fetched: time.Now()}
+	if k := pick(keys, kid); k != nil {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+func (c *keyCache) fetch(ctx context.Context, url string) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch key set: status %d", resp.StatusCode)
+	}
+	var body struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode key set: %w", err)
+	}
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range body.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, err1 := base64.RawURLEncoding.DecodeString(k.N)
+		e, err2 := base64.RawURLEncoding.DecodeString(k.E)
+		if err1 != nil || err2 != nil || len(e) > 4 {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	return keys, nil
+}
+
+// pick returns the key for kid, or the only key when the token has no kid.
+func pick(keys map[string]*rsa.PublicKey, kid string) *rsa.PublicKey {
+	if kid == "" && len(keys) == 1 {
+		for _, k := range keys {
+			return k
+		}
+	}
+	return keys[kid]
+}
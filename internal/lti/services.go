@@ -0,0 +1,666 @@
+package lti
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"quizapi/internal/events"
+	"quizapi/internal/models"
+	"quizapi/internal/retry"
+)
+
+var (
+	ErrUnknownPlatform = errors.New("unknown LTI platform")
+	ErrInvalidLaunch   = errors.New("invalid LTI launch")
+	ErrNotInstructor   = errors.New("deep linking requires an instructor role")
+	ErrQuizNotFound    = errors.New("quiz not found")
+)
+
+const (
+	loginStateTTL = 10 * time.Minute
+	sessionTTL    = 30 * time.Minute
+	sessionAud    = "quizapi-lti-deep-link"
+)
+
+// Score passback retries.
+const (
+	maxAttempts  = 8
+	baseBackoff  = 30 * time.Second
+	maxBackoff   = 6 * time.Hour
+	claimLease   = 2 * time.Minute // how long a worker owns a claimed passback
+	pollInterval = 2 * time.Second
+	batchSize    = 20
+)
+
+// Roles allowed to pick quizzes through deep linking.
+var instructorRoles = []string{
+	"http://purl.imsglobal.org/vocab/lis/v2/membership#Instructor",
+	"http://purl.imsglobal.org/vocab/lis/v2/membership#ContentDeveloper",
+	"http://purl.imsglobal.org/vocab/lis/v2/institution/person#Administrator",
+	"http://purl.imsglobal.org/vocab/lis/v2/system/person#Administrator",
+}
+
+// TokenIssuer returns an API token for a launched user.
+type TokenIssuer func(*models.User) (string, error)
+
+// Service implements the tool side of LTI 1.3: OIDC login, launch
+// validation, deep linking and AGS score passback.
+type Service struct {
+	db        *gorm.DB
+	keys      *KeySet
+	publicURL string
+	issue     TokenIssuer
+	client    *http.Client
+	platform  *keyCache
+	now       func() time.Time
+
+	mu     sync.Mutex
+	tokens map[uint]accessToken // AGS tokens by platform
+}
+
+type accessToken struct {
+	value   string
+	expires time.Time
+}
+
+func NewService(db *gorm.DB, keys *KeySet, publicURL string, issue TokenIssuer) *Service {
+	client := &http.Client{Timeout: 10 * time.Second}
+	return &Service{
+		db:        db,
+		keys:      keys,
+		publicURL: strings.TrimRight(publicURL, "/"),
+		issue:     issue,
+		client:    client,
+		platform:  newKeyCache(client),
+		now:       time.Now,
+		tokens:    map[uint]accessToken{},
+	}
+}
+
+// WithClock overrides the time source used for passback retries (used by tests).
+func (s *Service) WithClock(now func() time.Time) *Service {
+	s.now = now
+	return s
+}
+
+func (s *Service) Keys() *KeySet { return s.keys }
+
+func (s *Service) launchURL() string { return s.publicURL + "/lti/launch" }
+
+func (s *Service) CreatePlatform(req CreatePlatformReq) (*models.LTIPlatform, error) {
+	p := models.LTIPlatform{
+		Name:         req.Name,
+		Issuer:       req.Issuer,
+		ClientID:     req.ClientID,
+		DeploymentID: req.DeploymentID,
+		AuthLoginURL: req.AuthLoginURL,
+		AuthTokenURL: req.AuthTokenURL,
+		KeySetURL:    req.KeySetURL,
+	}
+	return &p, s.db.Create(&p).Error
+}
+
+func (s *Service) ListPlatforms() ([]models.LTIPlatform, error) {
+	var out []models.LTIPlatform
+	return out, s.db.Order("id").Find(&out).Error
+}
+
+// Login handles third-party initiated login: it stores a state and nonce
+// and returns the platform authorization URL to redirect the browser to.
+func (s *Service) Login(req LoginReq) (string, error) {
+	if req.Issuer == "" || req.LoginHint == "" {
+		return "", fmt.Errorf("%w: iss and login_hint are required", ErrInvalidLaunch)
+	}
+	q := s.db.Where("issuer = ?", req.Issuer)
+	if req.ClientID != "" {
+		q = q.Where("client_id = ?", req.ClientID)
+	}
+	var platforms []models.LTIPlatform
+	if err := q.Limit(2).Find(&platforms).Error; err != nil {
+		return "", err
+	}
+	if len(platforms) != 1 {
+		// several registrations for one issuer need client_id to disambiguate
+		return "", ErrUnknownPlatform
+	}
+	p := platforms[0]
+
+	st := models.LTILoginState{
+		State:      rand.Text(),
+		Nonce:      rand.Text(),
+		PlatformID: p.ID,
+		ExpiresAt:  time.Now().Add(loginStateTTL),
+	}
+	if err := s.db.Create(&st).Error; err != nil {
+		return "", err
+	}
+	// opportunistic cleanup; abandoned logins are never consumed
+	s.db.Where("expires_at < ?", time.Now()).Delete(&models.LTILoginState{})
+
+	u, err := url.Parse(p.AuthLoginURL)
+	if err != nil {
+		return "", err
+	}
+	v := u.Query()
+	v.Set("scope", "openid")
+	v.Set("response_type", "id_token")
+	v.Set("response_mode", "form_post")
+	v.Set("prompt", "none")
+	v.Set("client_id", p.ClientID)
+	v.Set("redirect_uri", s.launchURL())
+	v.Set("login_hint", req.LoginHint)
+	v.Set("state", st.State)
+	v.Set("nonce", st.Nonce)
+	if req.LTIMessageHint != "" {
+		v.Set("lti_message_hint", req.LTIMessageHint)
+	}
+	u.RawQuery = v.Encode()
+	return u.String(), nil
+}
+
+// Launch validates the id_token posted by the platform, maps its subject to
+// a local user and returns an API token for them. Resource link launches
+// must carry the quiz in the custom "quiz_id" claim, which deep linking sets.
+func (s *Service) Launch(ctx context.Context, state, idToken string) (*LaunchResp, error) {
+	var st models.LTILoginState
+	if err := s.db.Where("state = ?", state).Limit(1).Find(&st).Error; err != nil {
+		return nil, err
+	}
+	// the delete doubles as the single-use check when launches race
+	res := s.db.Where("state = ?", state).Delete(&models.LTILoginState{})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if st.State == "" || res.RowsAffected == 0 || st.ExpiresAt.Before(time.Now()) {
+		return nil, fmt.Errorf("%w: unknown or expired state", ErrInvalidLaunch)
+	}
+	var p models.LTIPlatform
+	if err := s.db.First(&p, st.PlatformID).Error; err != nil {
+		return nil, ErrUnknownPlatform
+	}
+
+	claims, err := s.verify(ctx, p, idToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLaunch, err)
+	}
+	if claims.Nonce != st.Nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidLaunch)
+	}
+
+	user, err := s.mapUser(p, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	token, err := s.issue(user)
+	if err != nil {
+		return nil, err
+	}
+	out := &LaunchResp{Token: token, UserID: user.ID, MessageType: claims.MessageType}
+
+	switch claims.MessageType {
+	case msgResource:
+		quizID, err := customQuiz(claims.Custom)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidLaunch, err)
+		}
+		if err := s.db.First(&models.Quiz{}, quizID).Error; err != nil {
+			return nil, fmt.Errorf("%w: quiz %d not found", ErrInvalidLaunch, quizID)
+		}
+		out.QuizID = &quizID
+		if err := s.saveGradeLink(p, quizID, user.ID, claims); err != nil {
+			return nil, err
+		}
+	case msgDeepLink:
+		if claims.DeepLinkSettings == nil || claims.DeepLinkSettings.ReturnURL == "" {
+			return nil, fmt.Errorf("%w: missing deep linking settings", ErrInvalidLaunch)
+		}
+		if !slices.ContainsFunc(claims.Roles, func(r string) bool { return slices.Contains(instructorRoles, r) }) {
+			return nil, ErrNotInstructor
+		}
+		out.DeepLinkSession, err = s.sign(sessionClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Audience:  jwt.ClaimStrings{sessionAud},
+				Subject:   strconv.FormatUint(uint64(user.ID), 10),
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(sessionTTL)),
+			},
+			PlatformID:   p.ID,
+			DeploymentID: claims.DeploymentID,
+			ReturnURL:    claims.DeepLinkSettings.ReturnURL,
+			Data:         claims.DeepLinkSettings.Data,
+		})
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported message type %q", ErrInvalidLaunch, claims.MessageType)
+	}
+	return out, nil
+}
+
+// verify checks the id_token signature against the platform key set and
+// the claims the spec requires of every launch.
+func (s *Service) verify(ctx context.Context, p models.LTIPlatform, raw string) (*launchClaims, error) {
+	claims := &launchClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return s.platform.key(ctx, p.KeySetURL, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(p.Issuer),
+		jwt.WithAudience(p.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(claims.Audience) > 1 && claims.AZP != p.ClientID {
+		return nil, errors.New("azp does not match client_id")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("missing sub")
+	}
+	if claims.Version != ltiVersion {
+		return nil, fmt.Errorf("unsupported LTI version %q", claims.Version)
+	}
+	if claims.DeploymentID == "" || (p.DeploymentID != "" && claims.DeploymentID != p.DeploymentID) {
+		return nil, errors.New("deployment_id not allowed")
+	}
+	return claims, nil
+}
+
+// mapUser returns the local user for a platform subject, creating one on
+// first launch. LTI users get an unusable password and can only sign in
+// through their platform.
+func (s *Service) mapUser(p models.LTIPlatform, subject string) (*models.User, error) {
+	var user models.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var link models.LTIUser
+		res := tx.Where("platform_id = ? AND subject = ?", p.ID, subject).Limit(1).Find(&link)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 1 {
+			return tx.First(&user, link.UserID).Error
+		}
+		sum := sha256.Sum256([]byte(subject))
+		user = models.User{
+			Username:     fmt.Sprintf("lti-%d-%s", p.ID, hex.EncodeToString(sum[:8])),
+			PasswordHash: "!",
+			Role:         models.RoleUser,
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.LTIUser{PlatformID: p.ID, Subject: subject, UserID: user.ID}).Error
+	})
+	return &user, err
+}
+
+func (s *Service) saveGradeLink(p models.LTIPlatform, quizID, userID uint, c *launchClaims) error {
+	if c.AGS == nil || c.AGS.LineItem == "" || !slices.Contains(c.AGS.Scope, scopeScore) {
+		return nil // platform doesn't accept scores for this link
+	}
+	link := models.LTIGradeLink{
+		PlatformID:  p.ID,
+		QuizID:      quizID,
+		UserID:      userID,
+		Subject:     c.Subject,
+		LineItemURL: c.AGS.LineItem,
+	}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "platform_id"}, {Name: "quiz_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"subject", "line_item_url", "updated_at"}),
+	}).Create(&link).Error
+}
+
+// DeepLink builds the signed LtiDeepLinkingResponse that places quizID in
+// the platform as a gradable resource link.
+func (s *Service) DeepLink(req DeepLinkReq) (*DeepLinkResp, error) {
+	var sess sessionClaims
+	_, err := jwt.ParseWithClaims(req.Session, &sess, func(*jwt.Token) (any, error) {
+		return s.keys.Public(), nil
+	}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithAudience(sessionAud), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLaunch, err)
+	}
+	var p models.LTIPlatform
+	if err := s.db.First(&p, sess.PlatformID).Error; err != nil {
+		return nil, ErrUnknownPlatform
+	}
+	var quiz models.Quiz
+	if err := s.db.First(&quiz, req.QuizID).Error; err != nil {
+		return nil, ErrQuizNotFound
+	}
+	var total int64
+	if err := s.db.Model(&models.Question{}).Where("quiz_id = ?", quiz.ID).Count(&total).Error; err != nil {
+		return nil, err
+	}
+	title := req.Title
+	if title == "" {
+		title = quiz.Title
+	}
+
+	now := time.Now()
+	signed, err := s.sign(deepLinkResponseClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    p.ClientID,
+			Audience:  jwt.ClaimStrings{p.Issuer},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+		},
+		Nonce:        rand.Text(),
+		MessageType:  msgDLResp,
+		Version:      ltiVersion,
+		DeploymentID: sess.DeploymentID,
+		Data:         sess.Data,
+		ContentItems: []contentItem{{
+			Type:     "ltiResourceLink",
+			Title:    title,
+			URL:      s.launchURL(),
+			Custom:   map[string]string{customQuizID: strconv.FormatUint(uint64(quiz.ID), 10)},
+			LineItem: &lineItem{Label: title, ScoreMaximum: float64(max(total, 1))},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &DeepLinkResp{ReturnURL: sess.ReturnURL, JWT: signed}, nil
+}
+
+// Record queues a graded submission's score for every line item linked to
+// the user and quiz, inside tx, the transaction that graded it. When a
+// submission is invalidated, the user's best remaining valid submission
+// is sent instead (or zero if none is left). Passbacks still waiting for
+// the same links are superseded, since the platform only keeps the newest
+// score. The worker (see Run) posts them, so submitting never waits on
+// the LMS.
+func (s *Service) Record(tx *gorm.DB, e events.Event) error {
+	data, ok := e.Data.(events.SubmissionData)
+	if !ok || data.UserID == 0 {
+		return nil
+	}
+	switch e.Type {
+	case events.SubmissionGraded:
+	case events.SubmissionInvalidated:
+		var best models.Submission
+		res := tx.Where("quiz_id = ? AND user_id = ? AND invalidated_at IS NULL", e.QuizID, data.UserID).
+			Order("marks DESC, id DESC").Limit(1).Find(&best)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			data.Marks = 0
+		} else {
+			data = events.Submission(e.Type, &best).Data.(events.SubmissionData)
+		}
+	default:
+		return nil
+	}
+	var links []models.LTIGradeLink
+	if err := tx.Where("quiz_id = ? AND user_id = ?", e.QuizID, data.UserID).Find(&links).Error; err != nil {
+		return err
+	}
+	for _, link := range links {
+		if err := tx.Model(&models.LTIPassback{}).
+			Where("link_id = ? AND status = ?", link.ID, models.DeliveryPending).
+			Updates(map[string]any{"status": models.DeliveryFailed, "last_error": "superseded by a newer score"}).Error; err != nil {
+			return err
+		}
+		pb := models.LTIPassback{
+			LinkID:        link.ID,
+			SubmissionID:  data.SubmissionID,
+			ScoreGiven:    max(data.Marks, 0),
+			ScoreMaximum:  max(data.MaxMarks, 1),
+			ScoredAt:      e.Time,
+			Status:        models.DeliveryPending,
+			NextAttemptAt: s.now(),
+		}
+		if err := tx.Create(&pb).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Publish does nothing: passbacks were queued by Record (see events.Record).
+func (s *Service) Publish(events.Event) {}
+
+// Run posts due passbacks until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	t := time.NewTicker(pollInterval)
+	defer t.Stop()
+	for {
+		if _, err := s.SendDue(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			log.Printf("lti: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// SendDue claims and attempts one batch of due passbacks and returns how
+// many it attempted. Claims work like webhook deliveries: a conditional
+// update bumps NextAttemptAt by a lease, so several workers can share the
+// queue.
+func (s *Service) SendDue(ctx context.Context) (int, error) {
+	now := s.now()
+	var due []models.LTIPassback
+	if err := s.db.Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+		Order("next_attempt_at").Limit(batchSize).Find(&due).Error; err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, pb := range due {
+		if ctx.Err() != nil {
+			return n, ctx.Err()
+		}
+		res := s.db.Model(&models.LTIPassback{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", pb.ID, models.DeliveryPending, pb.NextAttemptAt).
+			Update("next_attempt_at", now.Add(claimLease))
+		if res.Error != nil {
+			return n, res.Error
+		}
+		if res.RowsAffected == 0 {
+			continue // another worker has it
+		}
+		if err := s.attempt(ctx, pb); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// attempt posts one passback and records the outcome on it and its link.
+func (s *Service) attempt(ctx context.Context, pb models.LTIPassback) error {
+	var link models.LTIGradeLink
+	if err := s.db.First(&link, pb.LinkID).Error; err != nil {
+		return err
+	}
+	err := s.postScore(ctx, link, pb)
+	now := s.now()
+	upd := map[string]any{"attempts": pb.Attempts + 1}
+	linkUpd := map[string]any{"last_error": ""}
+	switch {
+	case err == nil:
+		upd["status"] = models.DeliverySucceeded
+		upd["sent_at"] = now
+		upd["last_error"] = ""
+		linkUpd["last_sent_at"] = now
+	case pb.Attempts+1 >= maxAttempts:
+		upd["status"] = models.DeliveryFailed
+		upd["last_error"] = err.Error()
+		linkUpd["last_error"] = err.Error()
+	default:
+		upd["next_attempt_at"] = now.Add(retry.Backoff(pb.Attempts+1, baseBackoff, maxBackoff))
+		upd["last_error"] = err.Error()
+		linkUpd["last_error"] = err.Error()
+	}
+	if err := s.db.Model(&models.LTIPassback{}).Where("id = ?", pb.ID).Updates(upd).Error; err != nil {
+		return err
+	}
+	return s.db.Model(&models.LTIGradeLink{}).Where("id = ?", link.ID).UpdateColumns(linkUpd).Error
+}
+
+func (s *Service) postScore(ctx context.Context, link models.LTIGradeLink, pb models.LTIPassback) error {
+	var p models.LTIPlatform
+	if err := s.db.First(&p, link.PlatformID).Error; err != nil {
+		return ErrUnknownPlatform
+	}
+	token, err := s.accessToken(ctx, p)
+	if err != nil {
+		return err
+	}
+	target, err := url.Parse(link.LineItemURL)
+	if err != nil {
+		return err
+	}
+	target.Path = strings.TrimRight(target.Path, "/") + "/scores"
+
+	body, err := json.Marshal(score{
+		UserID:           link.Subject,
+		ScoreGiven:       pb.ScoreGiven,
+		ScoreMaximum:     pb.ScoreMaximum,
+		ActivityProgress: "Completed",
+		GradingProgress:  "FullyGraded",
+		Timestamp:        pb.ScoredAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.ims.lis.v1.score+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if resp.StatusCode == http.StatusUnauthorized {
+			s.mu.Lock()
+			delete(s.tokens, p.ID)
+			s.mu.Unlock()
+		}
+		return fmt.Errorf("platform responded %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// accessToken gets (and caches) an AGS token with the client credentials
+// grant, authenticating with a JWT signed by the tool key.
+func (s *Service) accessToken(ctx context.Context, p models.LTIPlatform) (string, error) {
+	s.mu.Lock()
+	tok, ok := s.tokens[p.ID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(tok.expires) {
+		return tok.value, nil
+	}
+
+	now := time.Now()
+	assertion, err := s.sign(jwt.RegisteredClaims{
+		Issuer:    p.ClientID,
+		Subject:   p.ClientID,
+		Audience:  jwt.ClaimStrings{p.AuthTokenURL},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+		ID:        rand.Text(),
+	})
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type":            {"client_credentials"},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {assertion},
+		"scope":                 {scopeScore},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.AuthTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint responded %d", resp.StatusCode)
+	}
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decode token response: %w", err)
+	}
+	if body.AccessToken == "" {
+		return "", errors.New("token endpoint returned no access_token")
+	}
+	ttl := time.Duration(body.ExpiresIn) * time.Second
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	s.mu.Lock()
+	// refresh a little early so a token never expires mid-request
+	s.tokens[p.ID] = accessToken{value: body.AccessToken, expires: now.Add(ttl * 9 / 10)}
+	s.mu.Unlock()
+	return body.AccessToken, nil
+}
+
+// --- helpers ---
+
+func (s *Service) sign(claims jwt.Claims) (string, error) {
+	t := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	t.Header["kid"] = s.keys.kid
+	return t.SignedString(s.keys.key)
+}
+
+// customQuiz reads the quiz ID from the custom claim; platforms may send
+// custom values as strings or numbers.
+func customQuiz(custom map[string]any) (uint, error) {
+	switch v := custom[customQuizID].(type) {
+	case string:
+		id, err := strconv.ParseUint(v, 10, 0)
+		if err != nil || id == 0 {
+			return 0, fmt.Errorf("invalid custom quiz_id %q", v)
+		}
+		return uint(id), nil
+	case float64:
+		if v < 1 || v != float64(uint(v)) {
+			return 0, fmt.Errorf("invalid custom quiz_id %v", v)
+		}
+		return uint(v), nil
+	default:
+		return 0, errors.New("missing custom quiz_id")
+	}
+}
@@ -0,0 +1,255 @@
+package lti_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"quizapi/internal/events"
+	"quizapi/internal/lti"
+	"quizapi/internal/models"
+	"quizapi/internal/testutil"
+)
+
+// fakePlatform is a minimal LMS: it serves a key set, issues AGS tokens to
+// clients that present a valid assertion and records posted scores.
+type fakePlatform struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	toolKey  *rsa.PublicKey
+	mu       sync.Mutex
+	scores   []map[string]any
+	scoreURL string
+	failures int // score posts to reject before accepting
+}
+
+func newFakePlatform(t *testing.T, toolKey *rsa.PublicKey) *fakePlatform {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	fp := &fakePlatform{key: key, toolKey: toolKey}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(lti.NewKeySet(key).JWKS())
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_, err := jwt.Parse(r.FormValue("client_assertion"), func(*jwt.Token) (any, error) { return toolKey, nil },
+			jwt.WithAudience(fp.URL+"/token"), jwt.WithIssuer("tool-client"))
+		if err != nil || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "ags-token", "expires_in": 3600})
+	})
+	mux.HandleFunc("/lineitems/7/scores", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ags-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		fp.mu.Lock()
+		if fp.failures > 0 {
+			fp.failures--
+			fp.mu.Unlock()
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fp.scores = append(fp.scores, body)
+		fp.scoreURL = r.URL.String()
+		fp.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	})
+	fp.Server = httptest.NewServer(mux)
+	t.Cleanup(fp.Close)
+	return fp
+}
+
+func (fp *fakePlatform) idToken(t *testing.T, nonce string, claims jwt.MapClaims) string {
+	now := time.Now()
+	base := jwt.MapClaims{
+		"iss":   fp.URL,
+		"aud":   "tool-client",
+		"sub":   "lms-user-1",
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": nonce,
+		"https://purl.imsglobal.org/spec/lti/claim/version":       "1.3.0",
+		"https://purl.imsglobal.org/spec/lti/claim/deployment_id": "dep-1",
+	}
+	for k, v := range claims {
+		base[k] = v
+	}
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, base)
+	tok.Header["kid"] = lti.NewKeySet(fp.key).JWKS()["keys"].([]map[string]string)[0]["kid"]
+	signed, err := tok.SignedString(fp.key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestLaunchAndScorePassback(t *testing.T) {
+	d := testutil.DB(t)
+	quiz := models.Quiz{Title: "Go basics"}
+	require.NoError(t, d.Create(&quiz).Error)
+
+	keys, err := lti.LoadKeySet("")
+	require.NoError(t, err)
+	svc := lti.NewService(d, keys, "https://tool.test", func(u *models.User) (string, error) { return "api-token", nil })
+	fp := newFakePlatform(t, keys.Public())
+
+	_, err = svc.CreatePlatform(lti.CreatePlatformReq{
+		Issuer: fp.URL, ClientID: "tool-client", DeploymentID: "dep-1",
+		AuthLoginURL: fp.URL + "/auth", AuthTokenURL: fp.URL + "/token", KeySetURL: fp.URL + "/jwks",
+	})
+	require.NoError(t, err)
+
+	login := func() url.Values {
+		redirect, err := svc.Login(lti.LoginReq{Issuer: fp.URL, LoginHint: "hint"})
+		require.NoError(t, err)
+		u, err := url.Parse(redirect)
+		require.NoError(t, err)
+		require.Equal(t, "https://tool.test/lti/launch", u.Query().Get("redirect_uri"))
+		return u.Query()
+	}
+
+	// a token signed with the wrong nonce is rejected
+	q := login()
+	_, err = svc.Launch(context.Background(), q.Get("state"), fp.idToken(t, "other", nil))
+	require.ErrorIs(t, err, lti.ErrInvalidLaunch)
+
+	q = login()
+	idToken := fp.idToken(t, q.Get("nonce"), jwt.MapClaims{
+		"https://purl.imsglobal.org/spec/lti/claim/message_type": "LtiResourceLinkRequest",
+		"https://purl.imsglobal.org/spec/lti/claim/custom":       map[string]any{"quiz_id": "1"},
+		"https://purl.imsglobal.org/spec/lti-ags/claim/endpoint": map[string]any{
+			"scope":    []string{"https://purl.imsglobal.org/spec/lti-ags/scope/score"},
+			"lineitem": fp.URL + "/lineitems/7?course=3",
+		},
+	})
+	resp, err := svc.Launch(context.Background(), q.Get("state"), idToken)
+	require.NoError(t, err)
+	require.Equal(t, "api-token", resp.Token)
+	require.Equal(t, quiz.ID, *resp.QuizID)
+
+	// state is single use
+	_, err = svc.Launch(context.Background(), q.Get("state"), idToken)
+	require.ErrorIs(t, err, lti.ErrInvalidLaunch)
+
+	// a second launch maps to the same user
+	q = login()
+	again, err := svc.Launch(context.Background(), q.Get("state"), fp.idToken(t, q.Get("nonce"), jwt.MapClaims{
+		"https://purl.imsglobal.org/spec/lti/claim/message_type": "LtiResourceLinkRequest",
+		"https://purl.imsglobal.org/spec/lti/claim/custom":       map[string]any{"quiz_id": "1"},
+	}))
+	require.NoError(t, err)
+	require.Equal(t, resp.UserID, again.UserID)
+
+	// scores are queued with the grading transaction and retried until the
+	// platform accepts them
+	now := time.Now()
+	svc.WithClock(func() time.Time { return now })
+	fp.failures = 1
+	graded := events.New(events.SubmissionGraded, quiz.ID,
+		events.SubmissionData{SubmissionID: 5, UserID: resp.UserID, Score: 3, Total: 4, Marks: 3, MaxMarks: 4})
+	require.NoError(t, d.Transaction(func(tx *gorm.DB) error { return events.Record(tx, svc, graded) }))
+	n, err := svc.SendDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Empty(t, fp.scores)
+	var pb models.LTIPassback
+	require.NoError(t, d.First(&pb).Error)
+	require.Equal(t, models.DeliveryPending, pb.Status)
+	require.Contains(t, pb.LastError, "503")
+
+	n, err = svc.SendDue(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, n, "not due yet")
+	now = now.Add(time.Hour)
+	n, err = svc.SendDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.NoError(t, d.First(&pb).Error)
+	require.Equal(t, models.DeliverySucceeded, pb.Status)
+	require.Len(t, fp.scores, 1)
+	require.Equal(t, "/lineitems/7/scores?course=3", fp.scoreURL)
+	require.Equal(t, "lms-user-1", fp.scores[0]["userId"])
+	require.EqualValues(t, 3, fp.scores[0]["scoreGiven"])
+	require.EqualValues(t, 4, fp.scores[0]["scoreMaximum"])
+	require.Equal(t, "FullyGraded", fp.scores[0]["gradingProgress"])
+
+	// invalidating the best submission re-posts the best remaining one
+	kept := models.Submission{QuizID: quiz.ID, UserID: resp.UserID, Score: 2, Total: 4, Marks: 2, MaxMarks: 4}
+	voided := models.Submission{QuizID: quiz.ID, UserID: resp.UserID, Score: 3, Total: 4, Marks: 3, MaxMarks: 4,
+		InvalidatedAt: &now, InvalidationReason: "copied"}
+	require.NoError(t, d.Create(&kept).Error)
+	require.NoError(t, d.Create(&voided).Error)
+	require.NoError(t, d.Transaction(func(tx *gorm.DB) error {
+		return events.Record(tx, svc, events.Submission(events.SubmissionInvalidated, &voided))
+	}))
+	n, err = svc.SendDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Len(t, fp.scores, 2)
+	require.EqualValues(t, 2, fp.scores[1]["scoreGiven"])
+}
+
+func TestDeepLink(t *testing.T) {
+	d := testutil.DB(t)
+	quiz := models.Quiz{Title: "Go basics"}
+	require.NoError(t, d.Create(&quiz).Error)
+	keys, err := lti.LoadKeySet("")
+	require.NoError(t, err)
+	svc := lti.NewService(d, keys, "https://tool.test", func(u *models.User) (string, error) { return "api-token", nil })
+	fp := newFakePlatform(t, keys.Public())
+	_, err = svc.CreatePlatform(lti.CreatePlatformReq{
+		Issuer: fp.URL, ClientID: "tool-client",
+		AuthLoginURL: fp.URL + "/auth", AuthTokenURL: fp.URL + "/token", KeySetURL: fp.URL + "/jwks",
+	})
+	require.NoError(t, err)
+
+	launch := func(roles []string) (*lti.LaunchResp, error) {
+		redirect, err := svc.Login(lti.LoginReq{Issuer: fp.URL, LoginHint: "hint"})
+		require.NoError(t, err)
+		u, _ := url.Parse(redirect)
+		return svc.Launch(context.Background(), u.Query().Get("state"), fp.idToken(t, u.Query().Get("nonce"), jwt.MapClaims{
+			"https://purl.imsglobal.org/spec/lti/claim/message_type": "LtiDeepLinkingRequest",
+			"https://purl.imsglobal.org/spec/lti/claim/roles":        roles,
+			"https://purl.imsglobal.org/spec/lti-dl/claim/deep_linking_settings": map[string]any{
+				"deep_link_return_url": fp.URL + "/dl-return",
+				"data":                 "opaque",
+			},
+		}))
+	}
+	_, err = launch([]string{"http://purl.imsglobal.org/vocab/lis/v2/membership#Learner"})
+	require.ErrorIs(t, err, lti.ErrNotInstructor)
+
+	resp, err := launch([]string{"http://purl.imsglobal.org/vocab/lis/v2/membership#Instructor"})
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.DeepLinkSession)
+
+	dl, err := svc.DeepLink(lti.DeepLinkReq{Session: resp.DeepLinkSession, QuizID: quiz.ID})
+	require.NoError(t, err)
+	require.Equal(t, fp.URL+"/dl-return", dl.ReturnURL)
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(dl.JWT, claims, func(*jwt.Token) (any, error) { return keys.Public(), nil },
+		jwt.WithAudience(fp.URL), jwt.WithIssuer("tool-client"))
+	require.NoError(t, err)
+	require.Equal(t, "LtiDeepLinkingResponse", claims["https://purl.imsglobal.org/spec/lti/claim/message_type"])
+	require.Equal(t, "opaque", claims["https://purl.imsglobal.org/spec/lti-dl/claim/data"])
+	items := claims["https://purl.imsglobal.org/spec/lti-dl/claim/content_items"].([]any)
+	require.Len(t, items, 1)
+	item := items[0].(map[string]any)
+	require.Equal(t, "https://tool.test/lti/launch", item["url"])
+	require.Equal(t, map[string]any{"quiz_id": "1"}, item["custom"])
+}
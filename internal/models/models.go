@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Role string
 
@@ -10,18 +14,128 @@ const (
 )
 
 type User struct {
-	ID           uint      `gorm:"primaryKey" json:"id"`
-	Username     string    `gorm:"type:varchar(100);uniqueIndex;not null" json:"username"`
-	PasswordHash string    `gorm:"type:varchar(100);not null" json:"-"`
-	Role         Role      `gorm:"type:varchar(16);not null;default:'user'" json:"role"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	Username     string `gorm:"type:varchar(100);uniqueIndex;not null" json:"username"`
+	PasswordHash string `gorm:"type:varchar(100);not null" json:"-"`
+	Role         Role   `gorm:"type:varchar(16);not null;default:'user'" json:"role"`
+	// LeaderboardOptOut hides the user from public leaderboards.
+	LeaderboardOptOut bool      `gorm:"not null;default:false" json:"leaderboard_opt_out"`
+	CreatedAt         time.Time `json:"created_at"`
 }
 
 type Quiz struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Title       string     `gorm:"type:varchar(200);not null" json:"title"`
+	PublishedAt *time.Time `json:"published_at"`
+	// OpensAt and ClosesAt bound when the quiz can be taken (nil = unbounded).
+	// They are stored in UTC; clients send RFC 3339 times with an offset.
+	OpensAt  *time.Time `json:"opens_at"`
+	ClosesAt *time.Time `json:"closes_at"`
+	// PassPercent is the score needed for a certificate; nil disables them.
+	PassPercent *int `json:"pass_percent"`
+	// CertificateTemplate is a text/template for the certificate PDF body;
+	// empty uses the default.
+	CertificateTemplate string `gorm:"type:text" json:"-"`
+	// AdaptiveLength turns on adaptive mode: questions are served one at a
+	// time, each picked to match the learner's running ability estimate,
+	// and the attempt ends after this many. Nil serves the fixed form.
+	AdaptiveLength *int `json:"adaptive_length"`
+	// AdaptiveTargetSE ends an adaptive attempt early once the ability
+	// estimate's standard error is at most this; nil always runs to length.
+	AdaptiveTargetSE *float64 `json:"adaptive_target_se"`
+	// Marking rules. A right answer earns 1 mark and a wrong one WrongMark
+	// (0 unless negative marking is on). With ConfidenceMarking, learners
+	// rate their confidence in each answer, and that sets the stakes instead
+	// (see Quiz.Marks).
+	WrongMark         float64 `gorm:"not null;default:0" json:"wrong_mark"`
+	ConfidenceMarking bool    `gorm:"not null;default:false" json:"confidence_marking"`
+	// RequireComplete rejects submissions that leave questions unanswered.
+	RequireComplete bool `gorm:"not null;default:false" json:"require_complete"`
+	// SimilarityThreshold turns on plagiarism checks for text answers:
+	// pairs at least this similar (0–1) are flagged. Nil disables them.
+	SimilarityThreshold *float64 `json:"similarity_threshold"`
+	// Visibility controls who finds and takes the quiz (see QuizVisibility).
+	Visibility QuizVisibility `gorm:"type:varchar(16);not null;default:public" json:"visibility"`
+	CreatedAt  time.Time      `json:"created_at"`
+	Questions  []Question     `json:"-"`
+	Sections   []Section      `json:"-"`
+}
+
+// Confidence is how sure a learner is of an answer under confidence-based
+// marking.
+type Confidence string
+
+const (
+	ConfidenceLow    Confidence = "low"
+	ConfidenceMedium Confidence = "medium"
+	ConfidenceHigh   Confidence = "high"
+)
+
+// confidenceMarks holds the marks for a right and a wrong answer at each
+// confidence level, following Gardner-Medwin's certainty-based marking.
+var confidenceMarks = map[Confidence][2]float64{
+	ConfidenceLow:    {1, 0},
+	ConfidenceMedium: {2, -2},
+	ConfidenceHigh:   {3, -6},
+}
+
+// HasMarkingRules reports whether q marks answers other than 1 for right
+// and 0 for wrong.
+func (q *Quiz) HasMarkingRules() bool { return q.WrongMark != 0 || q.ConfidenceMarking }
+
+// Marks returns what a graded answer earns under q's marking rules. c only
+// matters under confidence-based marking, where a missing confidence counts
+// as low.
+func (q *Quiz) Marks(correct bool, c *Confidence) float64 {
+	if q.ConfidenceMarking {
+		level := ConfidenceLow
+		if c != nil {
+			level = *c
+		}
+		if correct {
+			return confidenceMarks[level][0]
+		}
+		return confidenceMarks[level][1]
+	}
+	if correct {
+		return 1
+	}
+	return q.WrongMark
+}
+
+// MaxMarks is the most a single question can earn under q's rules.
+func (q *Quiz) MaxMarks() float64 {
+	if q.ConfidenceMarking {
+		return confidenceMarks[ConfidenceHigh][0]
+	}
+	return 1
+}
+
+// QuizExtension gives one user a different availability window for a
+// quiz, e.g. as an accommodation. Nil bounds fall back to the quiz's own.
+type QuizExtension struct {
 	ID        uint       `gorm:"primaryKey" json:"id"`
-	Title     string     `gorm:"type:varchar(200);not null" json:"title"`
+	QuizID    uint       `gorm:"uniqueIndex:idx_quiz_extension;not null" json:"quiz_id"`
+	UserID    uint       `gorm:"uniqueIndex:idx_quiz_extension;not null" json:"user_id"`
+	OpensAt   *time.Time `json:"opens_at"`
+	ClosesAt  *time.Time `json:"closes_at"`
+	Reason    string     `gorm:"type:varchar(255)" json:"reason"`
 	CreatedAt time.Time  `json:"created_at"`
-	Questions []Question `json:"-"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Section groups questions inside a quiz. Questions without a section
+// are served after all sections.
+type Section struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	QuizID       uint   `gorm:"index;not null" json:"quiz_id"`
+	Title        string `gorm:"type:varchar(200);not null" json:"title"`
+	Instructions string `gorm:"type:text" json:"instructions"`
+	Position     int    `gorm:"not null;default:0" json:"position"`
+	// TimeLimitSeconds is advisory for clients; nil means untimed.
+	TimeLimitSeconds *int `json:"time_limit_seconds"`
+	// PickCount serves a random N of the section's questions; nil serves all.
+	PickCount *int `json:"pick_count"`
 }
 
 type QuestionType string
@@ -32,38 +146,534 @@ const (
 	QText     QuestionType = "text"
 )
 
+// Question and option text, and explanations, are Markdown source.
+// They are rendered to sanitized HTML on read (see internal/richtext).
 type Question struct {
-	ID        uint         `gorm:"primaryKey" json:"id"`
-	QuizID    uint         `gorm:"index;not null" json:"quiz_id"`
-	Text      string       `gorm:"type:text;not null" json:"text"`
-	Type      QuestionType `gorm:"type:varchar(16);not null" json:"type"`
-	WordLimit *int         `json:"word_limit"`
-	Options   []Option     `gorm:"constraint:OnDelete:CASCADE" json:"options"`
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	QuizID      uint         `gorm:"index;not null" json:"quiz_id"`
+	SectionID   *uint        `gorm:"index" json:"section_id"`
+	Position    int          `gorm:"not null;default:0" json:"position"`
+	Text        string       `gorm:"type:text;not null" json:"text"`
+	Type        QuestionType `gorm:"type:varchar(16);not null" json:"type"`
+	WordLimit   *int         `json:"word_limit"`
+	Explanation string       `gorm:"type:text" json:"explanation"`
+	Options     []Option     `gorm:"constraint:OnDelete:CASCADE" json:"options"`
+	// Difficulty is on the same logit scale as ability estimates: 0 is
+	// average, around ±3 very easy or very hard. Only adaptive quizzes use it.
+	Difficulty float64 `gorm:"not null;default:0" json:"difficulty"`
+
+	// Set when the question was added from the question bank. Unpinned
+	// questions follow the bank entry's latest version; pinned ones stay on
+	// BankVersionID.
+	BankQuestionID *uint `gorm:"index" json:"bank_question_id,omitempty"`
+	BankVersionID  *uint `json:"bank_version_id,omitempty"`
+	BankPinned     bool  `gorm:"not null;default:false" json:"bank_pinned"`
+
+	// RevisionID points at the immutable snapshot of the current content.
+	RevisionID *uint `json:"revision_id,omitempty"`
+}
+
+// QuestionRevision is an immutable snapshot of a question's content.
+// A new revision is recorded whenever the question is created or edited.
+type QuestionRevision struct {
+	ID          uint             `gorm:"primaryKey" json:"id"`
+	QuestionID  uint             `gorm:"uniqueIndex:idx_question_revision;not null" json:"question_id"`
+	Version     int              `gorm:"uniqueIndex:idx_question_revision;not null" json:"version"`
+	Text        string           `gorm:"type:text;not null" json:"text"`
+	Type        QuestionType     `gorm:"type:varchar(16);not null" json:"type"`
+	WordLimit   *int             `json:"word_limit"`
+	Explanation string           `gorm:"type:text" json:"explanation"`
+	Options     []RevisionOption `gorm:"type:text;serializer:json" json:"options"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// RevisionOption is an option as it was at a given revision. Key is the
+// option's lineage (see Option.Key) so options can be matched across edits.
+type RevisionOption struct {
+	ID        uint   `json:"id"`
+	Key       uint   `json:"key"`
+	Text      string `json:"text"`
+	IsCorrect bool   `json:"is_correct"`
 }
 
 type Option struct {
 	ID         uint   `gorm:"primaryKey" json:"id"`
 	QuestionID uint   `gorm:"index;not null" json:"question_id"`
-	Text       string `gorm:"type:varchar(300);not null" json:"text"`
+	Position   int    `gorm:"not null;default:0" json:"position"`
+	Text       string `gorm:"type:text;not null" json:"text"`
 	IsCorrect  bool   `gorm:"not null" json:"-"` // never expose in public JSON
+	// Options are soft-deleted when their question's content is replaced so
+	// AnswerOption rows from past submissions keep pointing at real data.
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	// OriginID links an edited option to the one it replaced (nil for originals).
+	OriginID *uint `json:"-"`
+	// BankKey is the Key of the bank option this option was copied from
+	// (nil for options not from the bank). It survives reordering.
+	BankKey *uint `json:"-"`
+}
+
+// Key identifies an option across edits: the ID of the first option in its lineage.
+func (o Option) Key() uint {
+	if o.OriginID != nil {
+		return *o.OriginID
+	}
+	return o.ID
+}
+
+// Attempt tracks a user working on a quiz, from first fetching the
+// questions until a submission is made.
+type Attempt struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	QuizID       uint       `gorm:"index:idx_attempt_quiz_user;not null" json:"quiz_id"`
+	UserID       uint       `gorm:"index:idx_attempt_quiz_user;not null" json:"user_id"`
+	StartedAt    time.Time  `gorm:"not null" json:"started_at"`
+	SubmittedAt  *time.Time `json:"submitted_at"`
+	SubmissionID *uint      `json:"submission_id"`
+	// PickedQuestionIDs are the questions drawn for sections with a pick
+	// count, so a resumed attempt, and its score, use the same draw.
+	PickedQuestionIDs []uint `gorm:"type:text;serializer:json" json:"-"`
+}
+
+// DraftAnswer is an answer saved during an attempt, before the attempt is
+// submitted. There is at most one per question; saving again replaces it.
+type DraftAnswer struct {
+	ID                uint        `gorm:"primaryKey" json:"-"`
+	AttemptID         uint        `gorm:"uniqueIndex:idx_draft_answer;not null" json:"attempt_id"`
+	QuestionID        uint        `gorm:"uniqueIndex:idx_draft_answer;not null" json:"question_id"`
+	SelectedOptionID  *uint       `json:"selected_option_id,omitempty"`
+	SelectedOptionIDs []uint      `gorm:"type:text;serializer:json" json:"selected_option_ids,omitempty"`
+	TextAnswer        *string     `gorm:"type:text" json:"text_answer,omitempty"`
+	Confidence        *Confidence `gorm:"type:varchar(8)" json:"confidence,omitempty"`
+	UpdatedAt         time.Time   `json:"updated_at"`
+}
+
+// AdaptiveItem is a question served during an adaptive attempt. Items are
+// served in ID order and at most one is unanswered at a time.
+type AdaptiveItem struct {
+	ID         uint      `gorm:"primaryKey" json:"-"`
+	AttemptID  uint      `gorm:"uniqueIndex:idx_adaptive_item;not null" json:"attempt_id"`
+	QuestionID uint      `gorm:"uniqueIndex:idx_adaptive_item;not null" json:"question_id"`
+	Correct    *bool     `json:"correct"` // nil until answered
+	ServedAt   time.Time `json:"served_at"`
 }
 
 type Submission struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	QuizID    uint      `gorm:"index;not null" json:"quiz_id"`
-	CreatedAt time.Time `json:"created_at"`
-	Answers   []Answer  `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	ID     uint `gorm:"primaryKey" json:"id"`
+	QuizID uint `gorm:"index;not null" json:"quiz_id"`
+	UserID uint `gorm:"index" json:"user_id"` // 0 for submissions made before users were tracked
+	Score  int  `gorm:"not null;default:0" json:"score"`
+	Total  int  `gorm:"not null;default:0" json:"total"`
+	// Skipped counts the gradable questions left unanswered. They are
+	// included in Total and score 0.
+	Skipped int `gorm:"not null;default:0" json:"skipped"`
+	// Marks and MaxMarks are the score under the quiz's marking rules, and
+	// equal Score and Total when it has none.
+	Marks    float64 `gorm:"not null;default:0" json:"marks"`
+	MaxMarks float64 `gorm:"not null;default:0" json:"max_marks"`
+	// Percent is Marks/MaxMarks as a whole percentage, stored for SQL aggregates.
+	Percent int `gorm:"not null;default:0" json:"percent"`
+	// DurationSeconds is the time from attempt start to submit, if known.
+	DurationSeconds *int `json:"duration_seconds"`
+	// Ability and AbilitySE are the final ability estimate of an adaptive
+	// attempt and its standard error; nil for fixed-form submissions.
+	Ability   *float64 `json:"ability,omitempty"`
+	AbilitySE *float64 `json:"ability_se,omitempty"`
+	// InvalidatedAt is set when an admin voids the submission, e.g. for
+	// cheating. It then no longer counts for leaderboards or certificates.
+	InvalidatedAt      *time.Time `json:"invalidated_at,omitempty"`
+	InvalidatedBy      *uint      `json:"invalidated_by,omitempty"`
+	InvalidationReason string     `gorm:"type:varchar(500);not null;default:''" json:"invalidation_reason,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	Answers            []Answer   `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// Percent returns marks/max as a whole percentage, rounded down. It is 0
+// when max is 0 or marks are negative.
+func Percent(marks, max float64) int {
+	if max <= 0 || marks <= 0 {
+		return 0
+	}
+	return int(marks * 100 / max)
 }
 
 type Answer struct {
-	ID           uint           `gorm:"primaryKey" json:"id"`
-	SubmissionID uint           `gorm:"index;not null" json:"submission_id"`
-	QuestionID   uint           `gorm:"index;not null" json:"question_id"`
-	TextAnswer   *string        `json:"text_answer"`
-	Options      []AnswerOption `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	ID           uint `gorm:"primaryKey" json:"id"`
+	SubmissionID uint `gorm:"index;not null" json:"submission_id"`
+	QuestionID   uint `gorm:"index;not null" json:"question_id"`
+	// BankVersionID records which bank version was answered, if any.
+	BankVersionID *uint `json:"bank_version_id,omitempty"`
+	// RevisionID is the question revision the learner saw; GradedRevisionID
+	// is the answer key last used to grade it (they differ after a re-grade).
+	RevisionID       *uint          `json:"revision_id,omitempty"`
+	GradedRevisionID *uint          `json:"graded_revision_id,omitempty"`
+	IsCorrect        *bool          `json:"is_correct"` // nil for answers that aren't auto-graded
+	TextAnswer       *string        `json:"text_answer"`
+	Confidence       *Confidence    `gorm:"type:varchar(8)" json:"confidence,omitempty"`
+	Marks            *float64       `json:"marks"` // nil while ungraded
+	Options          []AnswerOption `gorm:"constraint:OnDelete:CASCADE" json:"-"`
 }
 
 type AnswerOption struct {
 	AnswerID uint `gorm:"primaryKey"`
 	OptionID uint `gorm:"primaryKey"`
 }
+
+// Attachment is an uploaded file (currently images) referenced from Markdown.
+type Attachment struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Filename    string    `gorm:"type:varchar(255);not null" json:"filename"`
+	ContentType string    `gorm:"type:varchar(100);not null" json:"content_type"`
+	Size        int64     `gorm:"not null" json:"size"`
+	StorageKey  string    `gorm:"type:varchar(255);uniqueIndex;not null" json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// --- Question bank ---
+
+type Tag struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Name string `gorm:"type:varchar(64);uniqueIndex;not null" json:"name"`
+}
+
+// BankQuestion is a reusable question that lives outside any quiz.
+// Its content is stored as immutable versions.
+type BankQuestion struct {
+	ID             uint                  `gorm:"primaryKey" json:"id"`
+	Difficulty     int                   `gorm:"not null;default:3" json:"difficulty"` // 1 (easy) .. 5 (hard)
+	CurrentVersion int                   `gorm:"not null" json:"current_version"`
+	Tags           []Tag                 `gorm:"many2many:bank_question_tags" json:"tags"`
+	Versions       []BankQuestionVersion `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+type BankQuestionVersion struct {
+	ID             uint         `gorm:"primaryKey" json:"id"`
+	BankQuestionID uint         `gorm:"uniqueIndex:idx_bank_version;not null" json:"bank_question_id"`
+	Version        int          `gorm:"uniqueIndex:idx_bank_version;not null" json:"version"`
+	Text           string       `gorm:"type:text;not null" json:"text"`
+	Type           QuestionType `gorm:"type:varchar(16);not null" json:"type"`
+	WordLimit      *int         `json:"word_limit"`
+	Explanation    string       `gorm:"type:text" json:"explanation"`
+	Options        []BankOption `gorm:"foreignKey:VersionID;constraint:OnDelete:CASCADE" json:"options"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+// BankOption is only served through admin endpoints, so IsCorrect is exposed.
+type BankOption struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	VersionID uint   `gorm:"index;not null" json:"version_id"`
+	Position  int    `gorm:"not null;default:0" json:"position"`
+	Text      string `gorm:"type:text;not null" json:"text"`
+	IsCorrect bool   `gorm:"not null" json:"is_correct"`
+	// OriginID links an option to the one it replaced in the previous
+	// version (nil for new options).
+	OriginID *uint `json:"-"`
+}
+
+// Key identifies a bank option across versions: the ID of the first
+// option in its lineage.
+func (o BankOption) Key() uint {
+	if o.OriginID != nil {
+		return *o.OriginID
+	}
+	return o.ID
+}
+
+// --- Leaderboards ---
+
+// LeaderboardEntry is a user's best submission for a quiz. More marks
+// win, so the quiz's marking rules apply; ties go to the shorter
+// completion time.
+type LeaderboardEntry struct {
+	ID           uint      `gorm:"primaryKey" json:"-"`
+	QuizID       uint      `gorm:"uniqueIndex:idx_leaderboard_quiz_user;index:idx_leaderboard_rank,priority:1;not null" json:"quiz_id"`
+	UserID       uint      `gorm:"uniqueIndex:idx_leaderboard_quiz_user;not null" json:"user_id"`
+	BestMarks    float64   `gorm:"index:idx_leaderboard_rank,priority:2;not null;default:0" json:"best_marks"`
+	BestScore    int       `gorm:"not null" json:"best_score"`
+	BestPercent  int       `gorm:"not null" json:"best_percent"`
+	BestDuration *int      `json:"best_duration_seconds"`
+	SubmissionID uint      `gorm:"not null" json:"submission_id"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// GlobalScore aggregates a user's best per-quiz submissions across all
+// quizzes: Marks (which ranks) and Points, the right answers in them.
+type GlobalScore struct {
+	UserID    uint      `gorm:"primaryKey" json:"user_id"`
+	Marks     float64   `gorm:"index;not null;default:0" json:"marks"`
+	Points    int       `gorm:"not null;default:0" json:"points"`
+	Duration  int       `gorm:"not null;default:0" json:"duration_seconds"`
+	Quizzes   int       `gorm:"not null;default:0" json:"quizzes"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// --- Webhooks ---
+
+// WebhookSubscription receives HMAC-signed event payloads at URL.
+// Events is a comma-separated list of event types, or "*" for all.
+type WebhookSubscription struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	URL       string    `gorm:"type:varchar(2048);not null" json:"url"`
+	Secret    string    `gorm:"type:varchar(128);not null" json:"-"`
+	Events    string    `gorm:"type:varchar(512);not null" json:"events"`
+	Active    bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event queued for one subscription. Rows double as
+// the delivery log; NextAttemptAt drives retries with exponential backoff.
+type WebhookDelivery struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	SubscriptionID uint           `gorm:"index;not null" json:"subscription_id"`
+	EventType      string         `gorm:"type:varchar(64);not null" json:"event_type"`
+	Payload        string         `gorm:"type:text;not null" json:"payload"`
+	Status         DeliveryStatus `gorm:"type:varchar(16);index:idx_delivery_due,priority:1;not null" json:"status"`
+	Attempts       int            `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time      `gorm:"index:idx_delivery_due,priority:2;not null" json:"next_attempt_at"`
+	ResponseStatus *int           `json:"response_status"`
+	LastError      string         `gorm:"type:text" json:"last_error"`
+	DeliveredAt    *time.Time     `json:"delivered_at"`
+	RedeliveryOf   *uint          `json:"redelivery_of,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+// --- LTI 1.3 ---
+
+// LTIPlatform is an LMS registered to launch this tool. Issuer and
+// ClientID identify it in id_tokens; the URLs come from its registration.
+type LTIPlatform struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	Name     string `gorm:"type:varchar(200)" json:"name"`
+	Issuer   string `gorm:"type:varchar(255);uniqueIndex:idx_lti_platform;not null" json:"issuer"`
+	ClientID string `gorm:"type:varchar(255);uniqueIndex:idx_lti_platform;not null" json:"client_id"`
+	// DeploymentID, when set, restricts launches to a single deployment.
+	DeploymentID string    `gorm:"type:varchar(255)" json:"deployment_id"`
+	AuthLoginURL string    `gorm:"type:varchar(2048);not null" json:"auth_login_url"`
+	AuthTokenURL string    `gorm:"type:varchar(2048);not null" json:"auth_token_url"`
+	KeySetURL    string    `gorm:"type:varchar(2048);not null" json:"key_set_url"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// LTIUser maps a platform's subject to a local user.
+type LTIUser struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	PlatformID uint   `gorm:"uniqueIndex:idx_lti_subject;not null" json:"platform_id"`
+	Subject    string `gorm:"type:varchar(255);uniqueIndex:idx_lti_subject;not null" json:"subject"`
+	UserID     uint   `gorm:"index;not null" json:"user_id"`
+	CreatedAt  time.Time
+}
+
+// LTILoginState holds the state and nonce of an OIDC login until the
+// matching launch arrives.
+type LTILoginState struct {
+	State      string    `gorm:"type:varchar(64);primaryKey"`
+	Nonce      string    `gorm:"type:varchar(64);not null"`
+	PlatformID uint      `gorm:"not null"`
+	ExpiresAt  time.Time `gorm:"index;not null"`
+}
+
+// LTIGradeLink remembers where to post a user's score for a quiz, taken
+// from the AGS claim of their last launch.
+type LTIGradeLink struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	PlatformID  uint       `gorm:"uniqueIndex:idx_lti_grade_link;not null" json:"platform_id"`
+	QuizID      uint       `gorm:"uniqueIndex:idx_lti_grade_link;not null" json:"quiz_id"`
+	UserID      uint       `gorm:"uniqueIndex:idx_lti_grade_link;index;not null" json:"user_id"`
+	Subject     string     `gorm:"type:varchar(255);not null" json:"subject"`
+	LineItemURL string     `gorm:"type:varchar(2048);not null" json:"line_item_url"`
+	LastSentAt  *time.Time `json:"last_sent_at"`
+	LastError   string     `gorm:"type:text" json:"last_error"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// LTIPassback is a score queued for one grade link. It is written in the
+// transaction that graded the submission and posted by the LTI worker,
+// which retries failures with exponential backoff. ScoredAt is sent as the
+// AGS timestamp so the platform keeps the newest score.
+type LTIPassback struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	LinkID        uint           `gorm:"index;not null" json:"link_id"`
+	SubmissionID  uint           `gorm:"not null" json:"submission_id"`
+	ScoreGiven    float64        `gorm:"not null" json:"score_given"`
+	ScoreMaximum  float64        `gorm:"not null" json:"score_maximum"`
+	ScoredAt      time.Time      `gorm:"not null" json:"scored_at"`
+	Status        DeliveryStatus `gorm:"type:varchar(16);index:idx_lti_passback_due,priority:1;not null" json:"status"`
+	Attempts      int            `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time      `gorm:"index:idx_lti_passback_due,priority:2;not null" json:"next_attempt_at"`
+	LastError     string         `gorm:"type:text" json:"last_error"`
+	SentAt        *time.Time     `json:"sent_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+// --- Certificates ---
+
+// Certificate is proof that a submission met its quiz's pass threshold.
+// Recipient and quiz title are copied at issue time so the document stays
+// the same if either is renamed.
+type Certificate struct {
+	ID           uint       `gorm:"primaryKey" json:"-"`
+	Code         string     `gorm:"type:varchar(32);uniqueIndex;not null" json:"code"`
+	QuizID       uint       `gorm:"index;not null" json:"quiz_id"`
+	UserID       uint       `gorm:"index;not null" json:"user_id"`
+	SubmissionID uint       `gorm:"index;not null" json:"submission_id"`
+	Recipient    string     `gorm:"type:varchar(100);not null" json:"recipient"`
+	QuizTitle    string     `gorm:"type:varchar(200);not null" json:"quiz_title"`
+	Percent      int        `gorm:"not null" json:"percent"`
+	IssuedAt     time.Time  `gorm:"not null" json:"issued_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	RevokeReason string     `gorm:"type:varchar(255)" json:"revoke_reason,omitempty"`
+}
+
+// --- Cohorts ---
+
+// Cohort is an admin-managed group of users that quizzes can be assigned to.
+type Cohort struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"type:varchar(100);uniqueIndex;not null" json:"name"`
+	Description string    `gorm:"type:text" json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type CohortMember struct {
+	CohortID  uint      `gorm:"primaryKey" json:"cohort_id"`
+	UserID    uint      `gorm:"primaryKey;index" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// QuizAssignment assigns a quiz to a cohort. A quiz with at least one
+// assignment is only visible to members of its cohorts (and admins).
+type QuizAssignment struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	QuizID    uint       `gorm:"uniqueIndex:idx_quiz_assignment;not null" json:"quiz_id"`
+	CohortID  uint       `gorm:"uniqueIndex:idx_quiz_assignment;index;not null" json:"cohort_id"`
+	DueAt     *time.Time `json:"due_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// --- Practice ---
+
+// PracticeCard is a question in a user's personal review deck, scheduled
+// with SM-2. Cards are added for questions the user got wrong in a
+// submission; practice answers only move the schedule.
+type PracticeCard struct {
+	ID          uint    `gorm:"primaryKey" json:"-"`
+	UserID      uint    `gorm:"uniqueIndex:idx_practice_card;index:idx_practice_due,priority:1;not null" json:"user_id"`
+	QuestionID  uint    `gorm:"uniqueIndex:idx_practice_card;not null" json:"question_id"`
+	EaseFactor  float64 `gorm:"not null;default:2.5" json:"ease_factor"`
+	Repetitions int     `gorm:"not null;default:0" json:"repetitions"` // correct reviews in a row
+	// IntervalDays is the gap between the last review and DueAt.
+	IntervalDays   int        `gorm:"not null;default:0" json:"interval_days"`
+	DueAt          time.Time  `gorm:"index:idx_practice_due,priority:2;not null" json:"due_at"`
+	LastReviewedAt *time.Time `json:"last_reviewed_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// --- Integrity ---
+
+// IntegrityEventType is a kind of suspicious client-side behavior.
+type IntegrityEventType string
+
+const (
+	EventTabSwitch      IntegrityEventType = "tab_switch"
+	EventFocusLoss      IntegrityEventType = "focus_loss"
+	EventCopy           IntegrityEventType = "copy"
+	EventPaste          IntegrityEventType = "paste"
+	EventFullscreenExit IntegrityEventType = "fullscreen_exit"
+)
+
+// IntegrityEvent is a proctoring signal reported by the client during an
+// attempt. OccurredAt is the client's clock; ReceivedAt is the server's.
+type IntegrityEvent struct {
+	ID         uint               `gorm:"primaryKey" json:"id"`
+	AttemptID  uint               `gorm:"index;not null" json:"attempt_id"`
+	Type       IntegrityEventType `gorm:"type:varchar(32);not null" json:"type"`
+	OccurredAt time.Time          `gorm:"not null" json:"occurred_at"`
+	// DurationMs is how long the learner was away, for tab switches and
+	// focus losses that the client timed.
+	DurationMs *int      `json:"duration_ms,omitempty"`
+	Detail     string    `gorm:"type:varchar(255);not null;default:''" json:"detail,omitempty"`
+	ReceivedAt time.Time `gorm:"not null" json:"received_at"`
+}
+
+// --- Plagiarism ---
+
+// TextSignature is the MinHash signature of a text answer, written once
+// the plagiarism job has compared the answer with its question's others.
+// MinHash is empty for answers too short to judge.
+type TextSignature struct {
+	AnswerID   uint     `gorm:"primaryKey;autoIncrement:false" json:"answer_id"`
+	QuizID     uint     `gorm:"index;not null" json:"quiz_id"`
+	QuestionID uint     `gorm:"index;not null" json:"question_id"`
+	MinHash    []uint32 `gorm:"type:text;serializer:json" json:"-"`
+}
+
+type FlagStatus string
+
+const (
+	FlagPending   FlagStatus = "pending"
+	FlagConfirmed FlagStatus = "confirmed"
+	FlagDismissed FlagStatus = "dismissed"
+)
+
+// SimilarityFlag marks two text answers to the same question that are
+// suspiciously alike. AnswerAID is the lower answer ID.
+type SimilarityFlag struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	QuizID     uint       `gorm:"index;not null" json:"quiz_id"`
+	QuestionID uint       `gorm:"not null" json:"question_id"`
+	AnswerAID  uint       `gorm:"uniqueIndex:idx_similarity_pair;not null" json:"answer_a_id"`
+	AnswerBID  uint       `gorm:"uniqueIndex:idx_similarity_pair;not null" json:"answer_b_id"`
+	Similarity float64    `gorm:"not null" json:"similarity"` // Jaccard similarity of word shingles
+	Status     FlagStatus `gorm:"type:varchar(16);not null;default:pending" json:"status"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// --- Access codes ---
+
+// QuizVisibility controls who finds and takes a quiz. Public quizzes are
+// listed for everyone; unlisted ones can be taken by anyone who has the
+// link but are only listed for admins; code-protected ones need an access
+// grant, which users get by redeeming an access code, and are only listed
+// for users who have one. Cohort assignments restrict all three further.
+type QuizVisibility string
+
+const (
+	VisibilityPublic   QuizVisibility = "public"
+	VisibilityUnlisted QuizVisibility = "unlisted"
+	VisibilityCode     QuizVisibility = "code"
+)
+
+// AccessCode lets users unlock a code-protected quiz. MaxUses and
+// ExpiresAt are optional limits; revoked codes can't be redeemed, but the
+// grants they gave stay.
+type AccessCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	QuizID    uint       `gorm:"index;not null" json:"quiz_id"`
+	Code      string     `gorm:"type:varchar(32);uniqueIndex;not null" json:"code"`
+	MaxUses   *int       `json:"max_uses"`
+	Uses      int        `gorm:"not null;default:0" json:"uses"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// AccessGrant lets a user take a code-protected quiz. AccessCodeID is the
+// code they redeemed, or nil when an admin granted access directly.
+type AccessGrant struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	QuizID       uint      `gorm:"uniqueIndex:idx_access_grant;not null" json:"quiz_id"`
+	UserID       uint      `gorm:"uniqueIndex:idx_access_grant;not null" json:"user_id"`
+	AccessCodeID *uint     `json:"access_code_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,46 @@
+package plagiarism
+
+import "quizapi/internal/models"
+
+// SettingsReq sets a quiz's similarity threshold, the Jaccard similarity
+// (0–1) of word shingles at which two answers are flagged. A null
+// threshold turns plagiarism checks off.
+type SettingsReq struct {
+	Threshold *float64 `json:"threshold" validate:"omitempty,gte=0.1,lte=1"`
+}
+
+type ReviewReq struct {
+	Status models.FlagStatus `json:"status" validate:"required,oneof=pending confirmed dismissed"`
+}
+
+// Span is a byte range of an answer's text.
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// AnswerView is one side of a flagged pair. Passages are the spans also
+// found in the other answer; Highlighted is the text as HTML with those
+// spans wrapped in <mark>.
+type AnswerView struct {
+	AnswerID     uint   `json:"answer_id"`
+	SubmissionID uint   `json:"submission_id"`
+	UserID       uint   `json:"user_id"`
+	Username     string `json:"username"`
+	Text         string `json:"text"`
+	Highlighted  string `json:"highlighted"`
+	Passages     []Span `json:"passages"`
+}
+
+type FlagView struct {
+	models.SimilarityFlag
+	A AnswerView `json:"a"`
+	B AnswerView `json:"b"`
+}
+
+type FlagsResp struct {
+	Flags        []FlagView `json:"flags"`
+	TotalRecords int64      `json:"total_records"`
+	Page         int        `json:"page"`
+	Limit        int        `json:"limit"`
+}
@@ -0,0 +1,227 @@
+package plagiarism
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"quizapi/internal/models"
+)
+
+var ErrFlagNotFound = errors.New("flag not found")
+
+const (
+	pollInterval = 30 * time.Second
+	batchSize    = 50
+)
+
+// Service flags similar text answers. A background job (see Run) signs
+// each new text answer of a quiz with checks turned on and compares it
+// with the question's earlier answers; admins review the flagged pairs.
+type Service struct{ db *gorm.DB }
+
+func NewService(db *gorm.DB) *Service { return &Service{db: db} }
+
+// UpdateSettings sets the quiz's similarity threshold. The quiz's answers
+// are checked again under the new threshold; flags already raised stay.
+func (s *Service) UpdateSettings(quizID uint, req SettingsReq) (*models.Quiz, error) {
+	var quiz models.Quiz
+	if err := s.db.First(&quiz, quizID).Error; err != nil {
+		return nil, fmt.Errorf("quiz %d not found", quizID)
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&quiz).Update("similarity_threshold", req.Threshold).Error; err != nil {
+			return err
+		}
+		return tx.Where("quiz_id = ?", quizID).Delete(&models.TextSignature{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	quiz.SimilarityThreshold = req.Threshold
+	return &quiz, nil
+}
+
+// Run checks new text answers until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	t := time.NewTicker(pollInterval)
+	defer t.Stop()
+	for {
+		for {
+			n, err := s.CheckPending(ctx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("plagiarism: %v", err)
+			}
+			if err != nil || n < batchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// pending is a text answer that hasn't been checked yet.
+type pending struct {
+	ID         uint
+	QuizID     uint
+	QuestionID uint
+	UserID     uint
+	TextAnswer string
+	Threshold  float64
+}
+
+// CheckPending checks one batch of unsigned text answers and returns how
+// many it checked.
+func (s *Service) CheckPending(ctx context.Context) (int, error) {
+	var rows []pending
+	if err := s.db.Table("answers a").
+		Select("a.id, s.quiz_id, a.question_id, s.user_id, a.text_answer, q.similarity_threshold AS threshold").
+		Joins("JOIN submissions s ON s.id = a.submission_id").
+		Joins("JOIN quizzes q ON q.id = s.quiz_id").
+		Joins("LEFT JOIN text_signatures t ON t.answer_id = a.id").
+		Where("a.text_answer IS NOT NULL AND q.similarity_threshold IS NOT NULL AND t.answer_id IS NULL").
+		Order("a.id").Limit(batchSize).Scan(&rows).Error; err != nil {
+		return 0, err
+	}
+	for i, r := range rows {
+		if ctx.Err() != nil {
+			return i, ctx.Err()
+		}
+		if err := s.db.Transaction(func(tx *gorm.DB) error { return check(tx, r) }); err != nil {
+			return i, fmt.Errorf("answer %d: %w", r.ID, err)
+		}
+	}
+	return len(rows), nil
+}
+
+// check signs the answer and flags each earlier answer to the question,
+// by someone else, whose similarity reaches the threshold.
+func check(tx *gorm.DB, a pending) error {
+	ws := words(a.TextAnswer)
+	hs := shingles(ws)
+	sig := models.TextSignature{AnswerID: a.ID, QuizID: a.QuizID, QuestionID: a.QuestionID}
+	if len(ws) >= minWords {
+		sig.MinHash = signature(hs)
+	}
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&sig)
+	if res.Error != nil || res.RowsAffected == 0 || sig.MinHash == nil {
+		return res.Error // another worker got here first, or too short to judge
+	}
+
+	var others []models.TextSignature
+	if err := tx.Where("question_id = ? AND answer_id <> ?", a.QuestionID, a.ID).Find(&others).Error; err != nil {
+		return err
+	}
+	var candidates []uint
+	for _, o := range others {
+		if len(o.MinHash) == numHashes && estimate(sig.MinHash, o.MinHash) >= a.Threshold-candidateMargin {
+			candidates = append(candidates, o.AnswerID)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	var texts []pending
+	if err := tx.Table("answers a").Select("a.id, s.user_id, a.text_answer").
+		Joins("JOIN submissions s ON s.id = a.submission_id").
+		Where("a.id IN ?", candidates).Scan(&texts).Error; err != nil {
+		return err
+	}
+	for _, o := range texts {
+		if a.UserID != 0 && o.UserID == a.UserID {
+			continue // learners may reuse their own earlier answers
+		}
+		sim := jaccard(hs, shingles(words(o.TextAnswer)))
+		if sim < a.Threshold {
+			continue
+		}
+		f := models.SimilarityFlag{
+			QuizID: a.QuizID, QuestionID: a.QuestionID,
+			AnswerAID: min(a.ID, o.ID), AnswerBID: max(a.ID, o.ID),
+			Similarity: round3(sim), Status: models.FlagPending,
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&f).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flags returns a page of the quiz's flags, most similar first, with the
+// matched passages of both answers highlighted. status filters by review
+// status when set.
+func (s *Service) Flags(quizID uint, status models.FlagStatus, page, limit int) (*FlagsResp, error) {
+	out := &FlagsResp{Flags: []FlagView{}, Page: page, Limit: limit}
+	q := s.db.Model(&models.SimilarityFlag{}).Where("quiz_id = ?", quizID)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if err := q.Session(&gorm.Session{}).Count(&out.TotalRecords).Error; err != nil {
+		return nil, err
+	}
+	var flags []models.SimilarityFlag
+	if err := q.Order("similarity desc, id").Offset((page - 1) * limit).Limit(limit).Find(&flags).Error; err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, 2*len(flags))
+	for _, f := range flags {
+		ids = append(ids, f.AnswerAID, f.AnswerBID)
+	}
+	var answers []struct {
+		ID           uint
+		SubmissionID uint
+		UserID       uint
+		Username     string
+		TextAnswer   string
+	}
+	if err := s.db.Table("answers a").
+		Select("a.id, a.submission_id, s.user_id, u.username, a.text_answer").
+		Joins("JOIN submissions s ON s.id = a.submission_id").
+		Joins("LEFT JOIN users u ON u.id = s.user_id").
+		Where("a.id IN ?", ids).Scan(&answers).Error; err != nil {
+		return nil, err
+	}
+	views := make(map[uint]AnswerView, len(answers))
+	for _, a := range answers {
+		views[a.ID] = AnswerView{
+			AnswerID: a.ID, SubmissionID: a.SubmissionID, UserID: a.UserID, Username: a.Username, Text: a.TextAnswer,
+		}
+	}
+	for _, f := range flags {
+		a, b := views[f.AnswerAID], views[f.AnswerBID]
+		a.Passages, a.Highlighted = passages(a.Text, shingles(words(b.Text)))
+		b.Passages, b.Highlighted = passages(b.Text, shingles(words(a.Text)))
+		out.Flags = append(out.Flags, FlagView{SimilarityFlag: f, A: a, B: b})
+	}
+	return out, nil
+}
+
+// Review records a grader's verdict on a flag.
+func (s *Service) Review(quizID, flagID uint, req ReviewReq) (*models.SimilarityFlag, error) {
+	var f models.SimilarityFlag
+	if err := s.db.Where("id = ? AND quiz_id = ?", flagID, quizID).First(&f).Error; err != nil {
+		return nil, ErrFlagNotFound
+	}
+	var reviewed *time.Time
+	if req.Status != models.FlagPending {
+		now := time.Now()
+		reviewed = &now
+	}
+	if err := s.db.Model(&f).Updates(map[string]any{"status": req.Status, "reviewed_at": reviewed}).Error; err != nil {
+		return nil, err
+	}
+	f.Status, f.ReviewedAt = req.Status, reviewed
+	return &f, nil
+}
+
+func round3(x float64) float64 { return math.Round(x*1000) / 1000 }
@@ -0,0 +1,71 @@
+package plagiarism_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"quizapi/internal/models"
+	"quizapi/internal/plagiarism"
+	"quizapi/internal/testutil"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestCheckPending_FlagsCopiedAnswers(t *testing.T) {
+	d := testutil.DB(t)
+	quiz := models.Quiz{Title: "Essays"}
+	require.NoError(t, d.Create(&quiz).Error)
+	q := models.Question{QuizID: quiz.ID, Text: "Explain goroutines", Type: models.QText}
+	require.NoError(t, d.Create(&q).Error)
+
+	original := "Goroutines are lightweight threads managed by the Go runtime. They start with a small stack " +
+		"that grows as needed, so a program can run many thousands of them at once."
+	answer := func(userID uint, text string) uint {
+		sub := models.Submission{QuizID: quiz.ID, UserID: userID}
+		require.NoError(t, d.Create(&sub).Error)
+		a := models.Answer{SubmissionID: sub.ID, QuestionID: q.ID, TextAnswer: &text}
+		require.NoError(t, d.Create(&a).Error)
+		return a.ID
+	}
+	first := answer(1, original)
+	answer(1, original) // the same learner again: not plagiarism
+	copied := answer(2, "I think "+strings.Replace(original, "at once.", "at the same time!", 1))
+	answer(3, "A goroutine is a function running concurrently with other goroutines in the same address "+
+		"space; channels let them communicate instead of sharing memory behind locks.")
+	answer(4, "Cheap threads.")
+
+	svc := plagiarism.NewService(d)
+	n, err := svc.CheckPending(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, n) // checks are off until a threshold is set
+
+	_, err = svc.UpdateSettings(quiz.ID, plagiarism.SettingsReq{Threshold: ptr(0.5)})
+	require.NoError(t, err)
+	n, err = svc.CheckPending(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+
+	flags, err := svc.Flags(quiz.ID, models.FlagPending, 1, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, flags.TotalRecords) // both of learner 1's answers match learner 2's
+	f := flags.Flags[0]
+	require.Equal(t, first, f.AnswerAID)
+	require.Equal(t, copied, f.AnswerBID)
+	require.Greater(t, f.Similarity, 0.7)
+	require.Less(t, f.Similarity, 1.0)
+
+	b := f.B
+	require.Len(t, b.Passages, 1)
+	require.Equal(t, "Goroutines are lightweight", b.Text[b.Passages[0].Start:b.Passages[0].Start+26])
+	require.True(t, strings.HasPrefix(b.Highlighted, "I think <mark>Goroutines are"))
+	require.True(t, strings.HasSuffix(b.Highlighted, "many thousands of them at</mark> the same time!"))
+
+	_, err = svc.Review(quiz.ID, f.ID, plagiarism.ReviewReq{Status: models.FlagConfirmed})
+	require.NoError(t, err)
+	flags, err = svc.Flags(quiz.ID, models.FlagPending, 1, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, flags.TotalRecords)
+}
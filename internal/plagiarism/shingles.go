@@ -0,0 +1,166 @@
+package plagiarism
+
+import (
+	"hash/fnv"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// --- Shingling and MinHash ---
+//
+// An answer is reduced to its overlapping runs of shingleSize words
+// (shingles), ignoring case and punctuation. Two answers' similarity is the
+// Jaccard index of their shingle sets: shared shingles over all shingles.
+// Comparing sets directly is too slow to do against every other answer, so
+// each answer also gets a MinHash signature: for each of numHashes hash
+// functions, the smallest hash of any of its shingles. The share of
+// positions where two signatures agree estimates their Jaccard index, and
+// only pairs whose estimate comes close to the threshold are compared
+// exactly.
+
+const (
+	shingleSize = 5
+	numHashes   = 128
+	// minWords skips short answers, which legitimately coincide.
+	minWords = 15
+	// candidateMargin is how far below the threshold a MinHash estimate
+	// may fall and still be checked exactly (about 3 standard errors).
+	candidateMargin = 0.15
+)
+
+// word is a normalized word and its byte range in the original text.
+type word struct {
+	text       string
+	start, end int
+}
+
+// words splits text into lower-cased runs of letters and digits.
+func words(text string) []word {
+	var out []word
+	start := -1
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			out = append(out, word{strings.ToLower(text[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		out = append(out, word{strings.ToLower(text[start:]), start, len(text)})
+	}
+	return out
+}
+
+// shingles hashes every run of shingleSize words; element i is the run
+// starting at word i.
+func shingles(ws []word) []uint64 {
+	if len(ws) < shingleSize {
+		return nil
+	}
+	out := make([]uint64, 0, len(ws)-shingleSize+1)
+	for i := 0; i+shingleSize <= len(ws); i++ {
+		h := fnv.New64a()
+		for _, w := range ws[i : i+shingleSize] {
+			h.Write([]byte(w.text))
+			h.Write([]byte{' '})
+		}
+		out = append(out, h.Sum64())
+	}
+	return out
+}
+
+// signature computes the MinHash signature of a shingle set. The hash
+// functions are h1 + i·h2 over the two halves of each shingle's hash,
+// which behaves like independent hashes for this purpose.
+func signature(hs []uint64) []uint32 {
+	sig := make([]uint32, numHashes)
+	for i := range sig {
+		sig[i] = ^uint32(0)
+	}
+	for _, h := range hs {
+		h1, h2 := uint32(h), uint32(h>>32)|1
+		for i := range sig {
+			sig[i] = min(sig[i], h1+uint32(i)*h2)
+		}
+	}
+	return sig
+}
+
+// estimate is the share of positions where two signatures agree.
+func estimate(a, b []uint32) float64 {
+	n := 0
+	for i := range a {
+		if a[i] == b[i] {
+			n++
+		}
+	}
+	return float64(n) / float64(len(a))
+}
+
+// jaccard is the exact Jaccard index of two shingle sets.
+func jaccard(a, b []uint64) float64 {
+	sa, sb := set(a), set(b)
+	shared := 0
+	for h := range sa {
+		if _, ok := sb[h]; ok {
+			shared++
+		}
+	}
+	union := len(sa) + len(sb) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+func set(hs []uint64) map[uint64]struct{} {
+	out := make(map[uint64]struct{}, len(hs))
+	for _, h := range hs {
+		out[h] = struct{}{}
+	}
+	return out
+}
+
+// passages finds the parts of text covered by shingles that also occur in
+// other, merging overlapping shingles into one span. It returns the spans
+// and text as HTML-escaped markup with each span wrapped in <mark>.
+func passages(text string, other []uint64) ([]Span, string) {
+	ws := words(text)
+	shared := set(other)
+	covered := make([]bool, len(ws))
+	for i, h := range shingles(ws) {
+		if _, ok := shared[h]; ok {
+			for j := i; j < i+shingleSize; j++ {
+				covered[j] = true
+			}
+		}
+	}
+	spans := []Span{}
+	for i := 0; i < len(ws); i++ {
+		if !covered[i] {
+			continue
+		}
+		j := i
+		for j+1 < len(ws) && covered[j+1] {
+			j++
+		}
+		spans = append(spans, Span{Start: ws[i].start, End: ws[j].end})
+		i = j
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, sp := range spans {
+		b.WriteString(html.EscapeString(text[last:sp.Start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[sp.Start:sp.End]))
+		b.WriteString("</mark>")
+		last = sp.End
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return spans, b.String()
+}
@@ -0,0 +1,36 @@
+package practice
+
+import (
+	"time"
+
+	"quizapi/internal/models"
+	"quizapi/internal/quizzes"
+)
+
+// NextResp is the next card to review. Question is null when nothing is
+// due; NextDueAt then says when the next card will be.
+type NextResp struct {
+	Question  *quizzes.PublicQuestion `json:"question"`
+	QuizID    uint                    `json:"quiz_id,omitempty"`
+	Due       int                     `json:"due"` // cards due now, including this one
+	NextDueAt *time.Time              `json:"next_due_at,omitempty"`
+}
+
+// AnswerReq answers a practice question. Quality optionally rates how easy
+// a correct answer felt, on SM-2's scale (3 hard, 4 good, 5 easy); it
+// defaults to 4 and is ignored for wrong answers.
+type AnswerReq struct {
+	SelectedOptionID  *uint  `json:"selected_option_id"`
+	SelectedOptionIDs []uint `json:"selected_option_ids"`
+	Quality           *int   `json:"quality" validate:"omitempty,min=3,max=5"`
+}
+
+// AnswerResp reveals the answer key, since practice is for learning, and
+// the card's new schedule.
+type AnswerResp struct {
+	Correct          bool                `json:"correct"`
+	CorrectOptionIDs []uint              `json:"correct_option_ids"`
+	Explanation      string              `json:"explanation"`
+	ExplanationHTML  string              `json:"explanation_html"`
+	Card             models.PracticeCard `json:"card"`
+}
@@ -0,0 +1,54 @@
+package practice
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type Handler struct {
+	svc *Service
+	val *validator.Validate
+}
+
+func NewHandler(svc *Service) *Handler { return &Handler{svc: svc, val: validator.New()} }
+
+func (h *Handler) Next(c *gin.Context) {
+	out, err := h.svc.Next(c.GetUint("userID"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *Handler) Answer(c *gin.Context) {
+	questionID, err := strconv.Atoi(c.Param("questionID"))
+	if err != nil || questionID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid questionID"})
+		return
+	}
+	var req AnswerReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	out, err := h.svc.Answer(c.GetUint("userID"), uint(questionID), req, time.Now())
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrCardNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
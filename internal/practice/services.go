@@ -0,0 +1,200 @@
+package practice
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"quizapi/internal/models"
+	"quizapi/internal/quizzes"
+	"quizapi/internal/richtext"
+)
+
+var ErrCardNotFound = errors.New("question is not in your practice deck")
+
+// SM-2 parameters. A wrong answer counts as quality 1 of 5.
+const (
+	startEase    = 2.5
+	minEase      = 1.3
+	wrongQuality = 1
+	rightQuality = 4
+)
+
+// Service runs practice decks. Practice answers never create submissions,
+// so they don't affect scores, leaderboards or certificates. Practice
+// reveals the answer key, so a question is only practiced once its quiz
+// has closed for the user; until then they could practice the key and
+// resubmit.
+type Service struct{ db *gorm.DB }
+
+func NewService(db *gorm.DB) *Service { return &Service{db: db} }
+
+// Next brings the user's deck up to date and returns the card that has
+// been due the longest.
+func (s *Service) Next(userID uint, now time.Time) (*NextResp, error) {
+	if err := s.sync(userID, now); err != nil {
+		return nil, err
+	}
+	deck := func() *gorm.DB {
+		return s.db.Model(&models.PracticeCard{}).Scopes(closedFor(userID, now)).
+			Where("practice_cards.user_id = ?", userID)
+	}
+	due := deck().Where("practice_cards.due_at <= ?", now)
+	var n int64
+	if err := due.Session(&gorm.Session{}).Count(&n).Error; err != nil {
+		return nil, err
+	}
+	out := &NextResp{Due: int(n)}
+	if n == 0 {
+		var next models.PracticeCard
+		res := deck().Order("practice_cards.due_at").Limit(1).Find(&next)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			out.NextDueAt = &next.DueAt
+		}
+		return out, nil
+	}
+
+	var card models.PracticeCard
+	if err := due.Order("practice_cards.due_at, practice_cards.id").First(&card).Error; err != nil {
+		return nil, err
+	}
+	var q models.Question
+	if err := s.db.Preload("Options", quizzes.ByPosition).First(&q, card.QuestionID).Error; err != nil {
+		return nil, err
+	}
+	pq := quizzes.ToPublicQuestion(q)
+	out.Question, out.QuizID = &pq, q.QuizID
+	return out, nil
+}
+
+// Answer grades a practice answer to a question in the user's deck and
+// reschedules the card. Cards can be reviewed before they are due, but not
+// while the question's quiz still accepts the user's submissions.
+func (s *Service) Answer(userID, questionID uint, req AnswerReq, now time.Time) (*AnswerResp, error) {
+	var card models.PracticeCard
+	if err := s.db.Scopes(closedFor(userID, now)).
+		Where("practice_cards.user_id = ? AND practice_cards.question_id = ?", userID, questionID).
+		First(&card).Error; err != nil {
+		return nil, ErrCardNotFound
+	}
+	var q models.Question
+	if err := s.db.Preload("Options", quizzes.ByPosition).First(&q, questionID).Error; err != nil {
+		return nil, err
+	}
+	gradable, correct, err := quizzes.Grade(q, quizzes.SubmitAnswer{
+		QuestionID:        questionID,
+		SelectedOptionID:  req.SelectedOptionID,
+		SelectedOptionIDs: req.SelectedOptionIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !gradable {
+		return nil, errors.New("only choice questions can be practiced")
+	}
+
+	quality := wrongQuality
+	if correct {
+		quality = rightQuality
+		if req.Quality != nil {
+			quality = *req.Quality
+		}
+	}
+	review(&card, quality, now)
+	if err := s.db.Model(&card).Updates(map[string]any{
+		"ease_factor": card.EaseFactor, "repetitions": card.Repetitions, "interval_days": card.IntervalDays,
+		"due_at": card.DueAt, "last_reviewed_at": card.LastReviewedAt,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	out := &AnswerResp{
+		Correct:          correct,
+		CorrectOptionIDs: []uint{},
+		Explanation:      q.Explanation,
+		ExplanationHTML:  richtext.Render(q.Explanation),
+		Card:             card,
+	}
+	for _, o := range q.Options {
+		if o.IsCorrect {
+			out.CorrectOptionIDs = append(out.CorrectOptionIDs, o.ID)
+		}
+	}
+	return out, nil
+}
+
+// sync adds a card, due now, for each choice question the user answered
+// wrongly in a submission and has no card for yet, once its quiz has
+// closed for them. Text answers are left out since practice answers to
+// them can't be graded.
+func (s *Service) sync(userID uint, now time.Time) error {
+	var ids []uint
+	err := s.db.Model(&models.Answer{}).Distinct().
+		Joins("JOIN submissions ON submissions.id = answers.submission_id").
+		Joins("JOIN questions ON questions.id = answers.question_id").
+		Scopes(closedQuiz(userID, now)).
+		Where("submissions.user_id = ? AND answers.is_correct = ?", userID, false).
+		Where("questions.type IN ?", []models.QuestionType{models.QSingle, models.QMultiple}).
+		Where("NOT EXISTS (SELECT 1 FROM practice_cards pc WHERE pc.user_id = ? AND pc.question_id = answers.question_id)", userID).
+		Pluck("answers.question_id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	cards := make([]models.PracticeCard, len(ids))
+	for i, id := range ids {
+		cards[i] = models.PracticeCard{UserID: userID, QuestionID: id, EaseFactor: startEase, DueAt: now}
+	}
+	return s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&cards).Error
+}
+
+// closedQuiz restricts a query joined with questions to those whose quiz
+// no longer accepts userID's submissions at now: its closing time, or the
+// one set by the user's extension, has passed. Quizzes without a closing
+// time never close.
+func closedQuiz(userID uint, now time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN quizzes ON quizzes.id = questions.quiz_id").
+			Joins("LEFT JOIN quiz_extensions qe ON qe.quiz_id = quizzes.id AND qe.user_id = ?", userID).
+			Where("COALESCE(qe.closes_at, quizzes.closes_at) <= ?", now)
+	}
+}
+
+// closedFor restricts a practice card query to cards whose quiz has closed
+// for userID (see closedQuiz).
+func closedFor(userID uint, now time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN questions ON questions.id = practice_cards.question_id").
+			Scopes(closedQuiz(userID, now))
+	}
+}
+
+// review applies SM-2 for an answer of the given quality (0-5): a lapse
+// (below 3) starts the card over at one day, otherwise the interval goes
+// 1 day, 6 days, then grows by the ease factor. The ease factor moves with
+// quality and never drops below minEase.
+func review(c *models.PracticeCard, quality int, now time.Time) {
+	if quality < 3 {
+		c.Repetitions, c.IntervalDays = 0, 1
+	} else {
+		c.Repetitions++
+		switch c.Repetitions {
+		case 1:
+			c.IntervalDays = 1
+		case 2:
+			c.IntervalDays = 6
+		default:
+			c.IntervalDays = int(math.Round(float64(c.IntervalDays) * c.EaseFactor))
+		}
+	}
+	d := float64(5 - quality)
+	ease := c.EaseFactor + 0.1 - d*(0.08+d*0.02)
+	c.EaseFactor = math.Round(max(ease, minEase)*100) / 100
+	c.DueAt = now.AddDate(0, 0, c.IntervalDays)
+	c.LastReviewedAt = &now
+}
@@ -0,0 +1,138 @@
+package practice_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"quizapi/internal/models"
+	"quizapi/internal/practice"
+	"quizapi/internal/quizzes"
+	"quizapi/internal/testutil"
+)
+
+const learner = 42
+
+func ptr[T any](v T) *T { return &v }
+
+func TestPracticeDeck_SM2Schedule(t *testing.T) {
+	d := testutil.DB(t)
+	qsvc := quizzes.NewService(d)
+	qz, err := qsvc.CreateQuiz("Capitals")
+	require.NoError(t, err)
+	for _, text := range []string{"Capital of France?", "Capital of Spain?"} {
+		_, err = qsvc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{Text: text, Type: "single",
+			Options: []quizzes.CreateQuestionOption{{Text: "right", IsCorrect: ptr(true)}, {Text: "wrong", IsCorrect: ptr(false)}}})
+		require.NoError(t, err)
+	}
+	pub, err := qsvc.GetPublicQuestions(qz.ID, learner)
+	require.NoError(t, err)
+	missed, known := pub.Questions[0], pub.Questions[1]
+	_, _, err = qsvc.SubmitAndScore(qz.ID, learner, quizzes.SubmitReq{Answers: []quizzes.SubmitAnswer{
+		{QuestionID: missed.ID, SelectedOptionID: &missed.Options[1].ID},
+		{QuestionID: known.ID, SelectedOptionID: &known.Options[0].ID},
+	}})
+	require.NoError(t, err)
+
+	// practice starts once the quiz has closed
+	closes := time.Now().Add(-time.Minute)
+	_, err = qsvc.SetSchedule(qz.ID, quizzes.ScheduleReq{ClosesAt: &closes})
+	require.NoError(t, err)
+	svc := practice.NewService(d)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// only the missed question is in the deck
+	next, err := svc.Next(learner, now)
+	require.NoError(t, err)
+	require.Equal(t, 1, next.Due)
+	require.Equal(t, missed.ID, next.Question.ID)
+	_, err = svc.Answer(learner, known.ID, practice.AnswerReq{SelectedOptionID: &known.Options[0].ID}, now)
+	require.ErrorIs(t, err, practice.ErrCardNotFound)
+
+	right := practice.AnswerReq{SelectedOptionID: &missed.Options[0].ID}
+	res, err := svc.Answer(learner, missed.ID, right, now)
+	require.NoError(t, err)
+	require.True(t, res.Correct)
+	require.Equal(t, []uint{missed.Options[0].ID}, res.CorrectOptionIDs)
+	require.Equal(t, 1, res.Card.IntervalDays)
+
+	next, err = svc.Next(learner, now)
+	require.NoError(t, err)
+	require.Nil(t, next.Question)
+	require.Equal(t, now.AddDate(0, 0, 1), next.NextDueAt.UTC())
+
+	// intervals grow 1, 6, then by the ease factor; a lapse starts over
+	now = now.AddDate(0, 0, 1)
+	res, err = svc.Answer(learner, missed.ID, right, now)
+	require.NoError(t, err)
+	require.Equal(t, 6, res.Card.IntervalDays)
+	now = now.AddDate(0, 0, 6)
+	res, err = svc.Answer(learner, missed.ID, practice.AnswerReq{SelectedOptionID: &missed.Options[0].ID, Quality: ptr(5)}, now)
+	require.NoError(t, err)
+	require.Equal(t, 15, res.Card.IntervalDays)
+	require.Equal(t, 2.6, res.Card.EaseFactor)
+
+	res, err = svc.Answer(learner, missed.ID, practice.AnswerReq{SelectedOptionID: &missed.Options[1].ID}, now)
+	require.NoError(t, err)
+	require.False(t, res.Correct)
+	require.Equal(t, 1, res.Card.IntervalDays)
+	require.Equal(t, 0, res.Card.Repetitions)
+	require.Less(t, res.Card.EaseFactor, 2.6)
+
+	// practice never creates submissions
+	var subs int64
+	require.NoError(t, d.Model(&models.Submission{}).Count(&subs).Error)
+	require.EqualValues(t, 1, subs)
+}
+
+func TestPracticeDeck_WithholdsOpenQuizzes(t *testing.T) {
+	d := testutil.DB(t)
+	qsvc := quizzes.NewService(d)
+	qz, err := qsvc.CreateQuiz("Capitals")
+	require.NoError(t, err)
+	_, err = qsvc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{Text: "Capital of France?", Type: "single",
+		Options: []quizzes.CreateQuestionOption{{Text: "right", IsCorrect: ptr(true)}, {Text: "wrong", IsCorrect: ptr(false)}}})
+	require.NoError(t, err)
+	require.NoError(t, d.Create(&models.User{ID: learner, Username: "ada", PasswordHash: "x", Role: models.RoleUser}).Error)
+	closes := time.Now().Add(time.Hour)
+	_, err = qsvc.SetSchedule(qz.ID, quizzes.ScheduleReq{ClosesAt: &closes})
+	require.NoError(t, err)
+	pub, err := qsvc.GetPublicQuestions(qz.ID, learner)
+	require.NoError(t, err)
+	q := pub.Questions[0]
+	_, _, err = qsvc.SubmitAndScore(qz.ID, learner, quizzes.SubmitReq{Answers: []quizzes.SubmitAnswer{
+		{QuestionID: q.ID, SelectedOptionID: &q.Options[1].ID},
+	}})
+	require.NoError(t, err)
+
+	svc := practice.NewService(d)
+	right := practice.AnswerReq{SelectedOptionID: &q.Options[0].ID}
+	expectHidden := func(at time.Time) {
+		t.Helper()
+		next, err := svc.Next(learner, at)
+		require.NoError(t, err)
+		require.Zero(t, next.Due)
+		require.Nil(t, next.Question)
+		_, err = svc.Answer(learner, q.ID, right, at)
+		require.ErrorIs(t, err, practice.ErrCardNotFound)
+	}
+
+	// the learner could still resubmit, so the key stays hidden
+	expectHidden(time.Now())
+
+	// an extension keeps the quiz open for them past its closing time
+	after := closes.Add(time.Hour)
+	ext := after.Add(time.Hour)
+	_, err = qsvc.GrantExtension(qz.ID, learner, quizzes.ExtensionReq{ClosesAt: &ext})
+	require.NoError(t, err)
+	expectHidden(after)
+
+	later := ext.Add(time.Minute)
+	next, err := svc.Next(learner, later)
+	require.NoError(t, err)
+	require.Equal(t, q.ID, next.Question.ID)
+	res, err := svc.Answer(learner, q.ID, right, later)
+	require.NoError(t, err)
+	require.Equal(t, []uint{q.Options[0].ID}, res.CorrectOptionIDs)
+}
@@ -0,0 +1,26 @@
+package qti
+
+import "quizapi/internal/models"
+
+// ImportReport lists what an import created and what it left out.
+// Skipped items are reported rather than dropped so authors can fix or
+// re-create them by hand.
+type ImportReport struct {
+	Imported []ImportedItem `json:"imported"`
+	Skipped  []SkippedItem  `json:"skipped"`
+}
+
+type ImportedItem struct {
+	File       string              `json:"file"`
+	Identifier string              `json:"identifier"`
+	QuestionID uint                `json:"question_id"`
+	Type       models.QuestionType `json:"type"`
+	// Warnings note content that was simplified on the way in.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type SkippedItem struct {
+	File       string `json:"file"`
+	Identifier string `json:"identifier,omitempty"`
+	Reason     string `json:"reason"`
+}
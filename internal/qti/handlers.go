@@ -0,0 +1,94 @@
+package qti
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// Import appends the items of an uploaded QTI 2.1 zip (multipart field
+// "file") to the quiz and reports imported and skipped items.
+func (h *Handler) Import(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	fh, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "multipart field 'file' required"})
+		return
+	}
+	if fh.Size > MaxPackageSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file exceeds %d bytes", MaxPackageSize)})
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+
+	report, err := h.svc.Import(uint(quizID), f, fh.Size)
+	switch {
+	case errors.Is(err, ErrQuizNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrBadPackage):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// Export downloads the quiz as a QTI 2.1 zip.
+func (h *Handler) Export(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	// Export loads everything before writing, so until the first write
+	// errors can still be reported as JSON.
+	w := &lazyHeaders{ResponseWriter: c.Writer, name: fmt.Sprintf("quiz-%d-qti.zip", quizID)}
+	err = h.svc.Export(uint(quizID), w)
+	switch {
+	case err == nil:
+	case w.started:
+		log.Printf("qti export quiz %d: %v", quizID, err)
+	case errors.Is(err, ErrQuizNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// lazyHeaders sets the download headers on the first write.
+type lazyHeaders struct {
+	gin.ResponseWriter
+	name    string
+	started bool
+}
+
+func (w *lazyHeaders) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, w.name))
+	}
+	return w.ResponseWriter.Write(p)
+}
@@ -0,0 +1,424 @@
+package qti
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"quizapi/internal/models"
+	"quizapi/internal/quizzes"
+)
+
+const (
+	// MaxPackageSize bounds uploaded packages.
+	MaxPackageSize = 20 << 20
+	maxEntrySize   = 5 << 20
+	maxItems       = 1000
+	// QTI describes text answers by expected length in characters while
+	// questions carry a word limit; this converts between the two.
+	charsPerWord = 6
+	maxWordLimit = 300
+)
+
+var (
+	ErrQuizNotFound = errors.New("quiz not found")
+	ErrBadPackage   = errors.New("invalid QTI package")
+)
+
+type Service struct{ db *gorm.DB }
+
+func NewService(db *gorm.DB) *Service { return &Service{db: db} }
+
+// candidate is an item converted to a question, ready to insert.
+type candidate struct {
+	file, ident string
+	q           models.Question
+	opts        []quizzes.CreateQuestionOption
+	warnings    []string
+}
+
+// Import reads a QTI 2.1 content package and appends every supported
+// assessmentItem to the quiz. Items are taken in manifest order, or in
+// archive order when the package has no manifest. Supported items are
+// inserted in one transaction; the rest are listed in the report.
+func (s *Service) Import(quizID uint, r io.ReaderAt, size int64) (*ImportReport, error) {
+	if err := s.db.First(&models.Quiz{}, quizID).Error; err != nil {
+		return nil, ErrQuizNotFound
+	}
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadPackage, err)
+	}
+	files, report, err := itemFiles(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	var cands []candidate
+	for _, f := range files {
+		root, err := readXML(f)
+		if err != nil {
+			report.Skipped = append(report.Skipped, SkippedItem{File: f.Name, Reason: err.Error()})
+			continue
+		}
+		if root.Name != "assessmentItem" {
+			continue // tests, metadata and other XML in the package
+		}
+		c, reason := convert(root)
+		c.file, c.ident = f.Name, root.Attr["identifier"]
+		if reason == "" {
+			if err := quizzes.ValidateQuestion(c.q.Type, c.q.WordLimit, c.opts); err != nil {
+				reason = err.Error()
+			}
+		}
+		if reason != "" {
+			report.Skipped = append(report.Skipped, SkippedItem{File: c.file, Identifier: c.ident, Reason: reason})
+			continue
+		}
+		cands = append(cands, c)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, c := range cands {
+			q := c.q
+			q.QuizID = quizID
+			if err := quizzes.InsertQuestion(tx, &q, c.opts); err != nil {
+				return err
+			}
+			report.Imported = append(report.Imported, ImportedItem{
+				File: c.file, Identifier: c.ident, QuestionID: q.ID, Type: q.Type, Warnings: c.warnings,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// itemFiles lists the item files to read. Resources the manifest names but
+// the archive lacks are reported as skipped.
+func itemFiles(zr *zip.Reader) ([]*zip.File, *ImportReport, error) {
+	report := &ImportReport{Imported: []ImportedItem{}, Skipped: []SkippedItem{}}
+	byName := map[string]*zip.File{}
+	var manifestFile *zip.File
+	var xmlFiles []*zip.File
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name := path.Clean(f.Name)
+		byName[name] = f
+		if path.Base(name) == "imsmanifest.xml" {
+			if manifestFile == nil || len(name) < len(manifestFile.Name) {
+				manifestFile = f
+			}
+		} else if strings.EqualFold(path.Ext(name), ".xml") {
+			xmlFiles = append(xmlFiles, f)
+		}
+	}
+
+	files := xmlFiles
+	if manifestFile != nil {
+		root, err := readXML(manifestFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: imsmanifest.xml: %v", ErrBadPackage, err)
+		}
+		base := path.Dir(path.Clean(manifestFile.Name))
+		files = nil
+		root.walk(func(e *elem) bool {
+			if e.Name != "resource" {
+				return true
+			}
+			if !strings.HasPrefix(e.Attr["type"], "imsqti_item_xmlv2p") {
+				return false
+			}
+			href := path.Join(base, e.Attr["href"])
+			if f, ok := byName[href]; ok {
+				files = append(files, f)
+			} else {
+				report.Skipped = append(report.Skipped, SkippedItem{
+					File: href, Identifier: e.Attr["identifier"], Reason: "file listed in manifest is missing",
+				})
+			}
+			return false
+		})
+	}
+	if len(files) > maxItems {
+		return nil, nil, fmt.Errorf("%w: more than %d items", ErrBadPackage, maxItems)
+	}
+	return files, report, nil
+}
+
+func readXML(f *zip.File) (*elem, error) {
+	if f.UncompressedSize64 > maxEntrySize {
+		return nil, fmt.Errorf("file exceeds %d bytes", maxEntrySize)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	root, err := parseTree(io.LimitReader(rc, maxEntrySize))
+	if err != nil {
+		return nil, fmt.Errorf("malformed XML: %v", err)
+	}
+	return root, nil
+}
+
+// supported maps the interactions this API can represent to question types
+// (choice interactions are refined by cardinality).
+var supported = map[string]bool{"choiceInteraction": true, "extendedTextInteraction": true}
+
+// convert maps an assessmentItem onto a question. A non-empty reason means
+// the item cannot be represented.
+func convert(item *elem) (candidate, string) {
+	var c candidate
+	if item.Attr["adaptive"] == "true" {
+		return c, "adaptive items are not supported"
+	}
+	body := item.child("itemBody")
+	if body == nil {
+		return c, "missing itemBody"
+	}
+
+	var interactions []*elem
+	var media bool
+	body.walk(func(e *elem) bool {
+		switch {
+		case strings.HasSuffix(e.Name, "Interaction"):
+			interactions = append(interactions, e)
+			return false
+		case e.Name == "img" || e.Name == "object" || e.Name == "math":
+			media = true
+		}
+		return true
+	})
+	switch {
+	case len(interactions) == 0:
+		return c, "item has no interaction"
+	case len(interactions) > 1:
+		return c, fmt.Sprintf("items with %d interactions are not supported", len(interactions))
+	case !supported[interactions[0].Name]:
+		return c, "unsupported interaction: " + interactions[0].Name
+	}
+	it := interactions[0]
+	if media {
+		c.warnings = append(c.warnings, "images, media and math were not imported")
+	}
+
+	text := body.text(func(e *elem) bool { return strings.HasSuffix(e.Name, "Interaction") })
+	if p := it.child("prompt"); p != nil {
+		text = strings.TrimSpace(text + "\n\n" + p.text(nil))
+	}
+	if text == "" {
+		return c, "item has no question text"
+	}
+	var fb []string
+	for _, m := range item.all("modalFeedback") {
+		if t := m.text(nil); t != "" {
+			fb = append(fb, t)
+		}
+	}
+	c.q = models.Question{Text: text, Explanation: strings.Join(fb, "\n\n")}
+
+	switch it.Name {
+	case "extendedTextInteraction":
+		c.q.Type = models.QText
+		limit := maxWordLimit
+		if n, err := strconv.Atoi(it.Attr["expectedLength"]); err == nil && n > 0 {
+			limit = min(max((n+charsPerWord-1)/charsPerWord, 1), maxWordLimit)
+		} else {
+			c.warnings = append(c.warnings, fmt.Sprintf("no expectedLength; word limit set to %d", maxWordLimit))
+		}
+		c.q.WordLimit = &limit
+	case "choiceInteraction":
+		decl := responseDecl(item, it.Attr["responseIdentifier"])
+		if decl == nil {
+			return c, "missing responseDeclaration for " + it.Attr["responseIdentifier"]
+		}
+		correct := correctValues(decl)
+		if len(correct) == 0 {
+			return c, "choice item has no correct response"
+		}
+		c.q.Type = models.QMultiple
+		if decl.Attr["cardinality"] == "single" {
+			c.q.Type = models.QSingle
+		}
+		seen := map[string]bool{}
+		for _, ch := range it.all("simpleChoice") {
+			id := ch.Attr["identifier"]
+			isCorrect := correct[id]
+			seen[id] = true
+			c.opts = append(c.opts, quizzes.CreateQuestionOption{
+				Text:      ch.text(func(e *elem) bool { return e.Name == "feedbackInline" }),
+				IsCorrect: &isCorrect,
+			})
+		}
+		for id := range correct {
+			if !seen[id] {
+				return c, fmt.Sprintf("correct response %q is not a choice", id)
+			}
+		}
+		if it.Attr["shuffle"] == "true" {
+			c.warnings = append(c.warnings, "choice shuffling is not imported")
+		}
+	}
+	return c, ""
+}
+
+func responseDecl(item *elem, ident string) *elem {
+	for _, d := range item.all("responseDeclaration") {
+		if d.Attr["identifier"] == ident {
+			return d
+		}
+	}
+	return nil
+}
+
+// correctValues reads correctResponse, falling back to mapping entries
+// with a positive value (items scored by map_response).
+func correctValues(decl *elem) map[string]bool {
+	out := map[string]bool{}
+	if cr := decl.child("correctResponse"); cr != nil {
+		for _, v := range cr.all("value") {
+			if t := strings.TrimSpace(v.text(nil)); t != "" {
+				out[t] = true
+			}
+		}
+	}
+	if len(out) > 0 {
+		return out
+	}
+	if m := decl.child("mapping"); m != nil {
+		for _, e := range m.all("mapEntry") {
+			if v, err := strconv.ParseFloat(e.Attr["mappedValue"], 64); err == nil && v > 0 {
+				out[e.Attr["mapKey"]] = true
+			}
+		}
+	}
+	return out
+}
+
+// Export writes the quiz as a QTI 2.1 content package: one item file per
+// question, an assessmentTest in question order and imsmanifest.xml.
+// Everything is loaded before the first byte is written, so an error
+// means w is untouched.
+func (s *Service) Export(quizID uint, w io.Writer) error {
+	var quiz models.Quiz
+	if err := s.db.First(&quiz, quizID).Error; err != nil {
+		return ErrQuizNotFound
+	}
+	var qs []models.Question
+	if err := s.db.Scopes(quizzes.ByPosition).Preload("Options", quizzes.ByPosition).
+		Where("quiz_id = ?", quizID).Find(&qs).Error; err != nil {
+		return err
+	}
+
+	docs := map[string]any{}
+	var names []string
+	man := manifest{Xmlns: nsCP, Identifier: fmt.Sprintf("QUIZ%d_MANIFEST", quiz.ID)}
+	test := assessmentTest{
+		Xmlns: nsQTI, Identifier: fmt.Sprintf("QUIZ%d", quiz.ID), Title: quiz.Title,
+		Part: testPart{
+			Identifier: "PART1", NavigationMode: "nonlinear", SubmissionMode: "simultaneous",
+			Section: testSection{Identifier: "SECTION1", Title: quiz.Title, Visible: true},
+		},
+	}
+	testRes := resource{Identifier: "TEST", Type: typeTest, Href: "assessmentTest.xml", Files: []file{{Href: "assessmentTest.xml"}}}
+	for _, q := range qs {
+		ident := fmt.Sprintf("Q%d", q.ID)
+		name := "items/" + ident + ".xml"
+		docs[name] = toItem(ident, q)
+		names = append(names, name)
+		test.Part.Section.ItemRefs = append(test.Part.Section.ItemRefs, itemRef{Identifier: ident, Href: name})
+		man.Resources = append(man.Resources, resource{Identifier: ident, Type: typeItem, Href: name, Files: []file{{Href: name}}})
+		testRes.Dependencies = append(testRes.Dependencies, dependency{IdentifierRef: ident})
+	}
+	man.Resources = append(man.Resources, testRes)
+	docs["assessmentTest.xml"] = test
+	docs["imsmanifest.xml"] = man
+	names = append([]string{"imsmanifest.xml", "assessmentTest.xml"}, names...)
+
+	zw := zip.NewWriter(w)
+	for _, name := range names {
+		body, err := xml.MarshalIndent(docs[name], "", "  ")
+		if err != nil {
+			return err
+		}
+		fw, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(fw, io.MultiReader(strings.NewReader(xml.Header), bytes.NewReader(body))); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func toItem(ident string, q models.Question) assessmentItem {
+	item := assessmentItem{
+		Xmlns:      nsQTI,
+		Identifier: ident,
+		Title:      title(q.Text),
+		Response:   responseDeclaration{Identifier: respIdent, Cardinality: "single", BaseType: "identifier"},
+		Outcomes:   []outcomeDeclaration{{Identifier: outcomeSco, Cardinality: "single", BaseType: "float"}},
+	}
+	for _, p := range strings.Split(q.Text, "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			item.Body.Paragraphs = append(item.Body.Paragraphs, p)
+		}
+	}
+	switch q.Type {
+	case models.QText:
+		item.Response.BaseType = "string"
+		et := &extendedTextInteraction{ResponseIdentifier: respIdent}
+		if q.WordLimit != nil {
+			et.ExpectedLength = *q.WordLimit * charsPerWord
+		}
+		item.Body.ExtendedText = et
+	default:
+		ci := &choiceInteraction{ResponseIdentifier: respIdent, MaxChoices: 1}
+		if q.Type == models.QMultiple {
+			item.Response.Cardinality = "multiple"
+			ci.MaxChoices = 0 // unlimited
+		}
+		for _, o := range q.Options {
+			id := fmt.Sprintf("C%d", o.ID)
+			ci.Choices = append(ci.Choices, simpleChoice{Identifier: id, Text: o.Text})
+			if o.IsCorrect {
+				item.Response.Correct = append(item.Response.Correct, id)
+			}
+		}
+		item.Body.Choice = ci
+		item.Processing = &responseProcessing{Template: tmplMatch}
+	}
+	if q.Explanation != "" {
+		// kept as modal feedback so the explanation survives a round trip;
+		// the standard templates never set FEEDBACK, so players won't show it
+		item.Outcomes = append(item.Outcomes, outcomeDeclaration{Identifier: outcomeFB, Cardinality: "single", BaseType: "identifier"})
+		item.ModalFeedbacks = []modalFeedback{{
+			OutcomeIdentifier: outcomeFB, ShowHide: "show", Identifier: "EXPLANATION", Text: q.Explanation,
+		}}
+	}
+	return item
+}
+
+// title shortens question text to a single-line item title.
+func title(text string) string {
+	t := strings.Join(strings.Fields(text), " ")
+	if r := []rune(t); len(r) > 80 {
+		return string(r[:77]) + "..."
+	}
+	return t
+}
@@ -0,0 +1,161 @@
+package qti_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"quizapi/internal/models"
+	"quizapi/internal/qti"
+	"quizapi/internal/quizzes"
+	"quizapi/internal/testutil"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func zipOf(t *testing.T, files map[string]string) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return bytes.NewReader(buf.Bytes())
+}
+
+const choiceItem = `<?xml version="1.0" encoding="UTF-8"?>
+<assessmentItem xmlns="http://www.imsglobal.org/xsd/imsqti_v2p1" identifier="choice1" title="Capital" adaptive="false" timeDependent="false">
+  <responseDeclaration identifier="RESPONSE" cardinality="single" baseType="identifier">
+    <correctResponse><value>B</value></correctResponse>
+  </responseDeclaration>
+  <itemBody>
+    <p>Which city is the <b>capital</b> of France?</p>
+    <choiceInteraction responseIdentifier="RESPONSE" shuffle="false" maxChoices="1">
+      <simpleChoice identifier="A">Lyon</simpleChoice>
+      <simpleChoice identifier="B">Paris</simpleChoice>
+    </choiceInteraction>
+  </itemBody>
+  <modalFeedback outcomeIdentifier="FEEDBACK" identifier="X" showHide="show">Paris has been the capital since 987.</modalFeedback>
+</assessmentItem>`
+
+const multiItem = `<assessmentItem identifier="multi1" title="Primes">
+  <responseDeclaration identifier="RESPONSE" cardinality="multiple" baseType="identifier">
+    <mapping defaultValue="0"><mapEntry mapKey="p2" mappedValue="1"/><mapEntry mapKey="p3" mappedValue="1"/><mapEntry mapKey="p4" mappedValue="-1"/></mapping>
+  </responseDeclaration>
+  <itemBody>
+    <choiceInteraction responseIdentifier="RESPONSE" maxChoices="0">
+      <prompt>Select the primes.</prompt>
+      <simpleChoice identifier="p2">2</simpleChoice>
+      <simpleChoice identifier="p3">3</simpleChoice>
+      <simpleChoice identifier="p4">4</simpleChoice>
+    </choiceInteraction>
+  </itemBody>
+</assessmentItem>`
+
+const essayItem = `<assessmentItem identifier="essay1" title="Essay">
+  <responseDeclaration identifier="RESPONSE" cardinality="single" baseType="string"/>
+  <itemBody><p>Explain goroutines.</p><extendedTextInteraction responseIdentifier="RESPONSE" expectedLength="600"/></itemBody>
+</assessmentItem>`
+
+const matchItem = `<assessmentItem identifier="match1" title="Match">
+  <itemBody><matchInteraction responseIdentifier="RESPONSE"/></itemBody>
+</assessmentItem>`
+
+func TestImport_MapsSupportedAndReportsTheRest(t *testing.T) {
+	d := testutil.DB(t)
+	quiz := models.Quiz{Title: "Imported"}
+	require.NoError(t, d.Create(&quiz).Error)
+
+	pkg := zipOf(t, map[string]string{
+		"imsmanifest.xml": `<manifest xmlns="http://www.imsglobal.org/xsd/imscp_v1p1" identifier="M"><resources>
+  <resource identifier="r1" type="imsqti_item_xmlv2p1" href="items/choice1.xml"/>
+  <resource identifier="r2" type="imsqti_item_xmlv2p1" href="items/multi1.xml"/>
+  <resource identifier="r3" type="imsqti_item_xmlv2p1" href="items/essay1.xml"/>
+  <resource identifier="r4" type="imsqti_item_xmlv2p1" href="items/match1.xml"/>
+  <resource identifier="r5" type="imsqti_item_xmlv2p1" href="items/gone.xml"/>
+</resources></manifest>`,
+		"items/choice1.xml": choiceItem,
+		"items/multi1.xml":  multiItem,
+		"items/essay1.xml":  essayItem,
+		"items/match1.xml":  matchItem,
+	})
+	report, err := qti.NewService(d).Import(quiz.ID, pkg, pkg.Size())
+	require.NoError(t, err)
+
+	require.Len(t, report.Imported, 3)
+	require.Equal(t, []string{"choice1", "multi1", "essay1"},
+		[]string{report.Imported[0].Identifier, report.Imported[1].Identifier, report.Imported[2].Identifier})
+	require.Len(t, report.Skipped, 2)
+	reasons := map[string]string{}
+	for _, s := range report.Skipped {
+		reasons[s.File] = s.Reason
+	}
+	require.Equal(t, "unsupported interaction: matchInteraction", reasons["items/match1.xml"])
+	require.Equal(t, "file listed in manifest is missing", reasons["items/gone.xml"])
+
+	var qs []models.Question
+	require.NoError(t, d.Scopes(quizzes.ByPosition).Preload("Options", quizzes.ByPosition).
+		Where("quiz_id = ?", quiz.ID).Find(&qs).Error)
+	require.Len(t, qs, 3)
+
+	require.Equal(t, models.QSingle, qs[0].Type)
+	require.Equal(t, "Which city is the capital of France?", qs[0].Text)
+	require.Equal(t, "Paris has been the capital since 987.", qs[0].Explanation)
+	require.Equal(t, "Paris", qs[0].Options[1].Text)
+	require.True(t, qs[0].Options[1].IsCorrect)
+
+	require.Equal(t, models.QMultiple, qs[1].Type)
+	require.Equal(t, "Select the primes.", qs[1].Text)
+	require.Equal(t, []bool{true, true, false},
+		[]bool{qs[1].Options[0].IsCorrect, qs[1].Options[1].IsCorrect, qs[1].Options[2].IsCorrect})
+
+	require.Equal(t, models.QText, qs[2].Type)
+	require.Equal(t, 100, *qs[2].WordLimit)
+}
+
+func TestExport_RoundTrips(t *testing.T) {
+	d := testutil.DB(t)
+	svc := quizzes.NewService(d)
+	src, err := svc.CreateQuiz("Go")
+	require.NoError(t, err)
+	_, err = svc.AddQuestion(src.ID, quizzes.CreateQuestionReq{
+		Text: "Which are Go keywords?\n\nPick all.", Type: "multiple", Explanation: "See the spec.",
+		Options: []quizzes.CreateQuestionOption{
+			{Text: "defer", IsCorrect: ptr(true)},
+			{Text: "a < b & c", IsCorrect: ptr(false)},
+			{Text: "select", IsCorrect: ptr(true)},
+		},
+	})
+	require.NoError(t, err)
+	_, err = svc.AddQuestion(src.ID, quizzes.CreateQuestionReq{Text: "Describe channels.", Type: "text", WordLimit: ptr(50)})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	q := qti.NewService(d)
+	require.NoError(t, q.Export(src.ID, &buf))
+
+	dst, err := svc.CreateQuiz("Copy")
+	require.NoError(t, err)
+	report, err := q.Import(dst.ID, bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Empty(t, report.Skipped)
+	require.Len(t, report.Imported, 2)
+
+	var qs []models.Question
+	require.NoError(t, d.Scopes(quizzes.ByPosition).Preload("Options", quizzes.ByPosition).
+		Where("quiz_id = ?", dst.ID).Find(&qs).Error)
+	require.Equal(t, "Which are Go keywords?\n\nPick all.", qs[0].Text)
+	require.Equal(t, models.QMultiple, qs[0].Type)
+	require.Equal(t, "See the spec.", qs[0].Explanation)
+	require.Equal(t, "a < b & c", qs[0].Options[1].Text)
+	require.Equal(t, []bool{true, false, true},
+		[]bool{qs[0].Options[0].IsCorrect, qs[0].Options[1].IsCorrect, qs[0].Options[2].IsCorrect})
+	require.Equal(t, models.QText, qs[1].Type)
+	require.Equal(t, 50, *qs[1].WordLimit)
+}
@@ -0,0 +1,258 @@
+package qti
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+const (
+	nsQTI      = "http://www.imsglobal.org/xsd/imsqti_v2p1"
+	nsCP       = "http://www.imsglobal.org/xsd/imscp_v1p1"
+	typeItem   = "imsqti_item_xmlv2p1"
+	typeTest   = "imsqti_test_xmlv2p1"
+	tmplMatch  = "http://www.imsglobal.org/question/qti_v2p1/rptemplates/match_correct"
+	respIdent  = "RESPONSE"
+	outcomeSco = "SCORE"
+	outcomeFB  = "FEEDBACK"
+)
+
+// elem is an order-preserving XML tree. Item bodies are mixed content, so
+// text and child elements must stay interleaved to rebuild the prompt.
+type elem struct {
+	Name     string
+	Attr     map[string]string
+	Children []any // *elem or string
+}
+
+func parseTree(r io.Reader) (*elem, error) {
+	dec := xml.NewDecoder(r)
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	var stack []*elem
+	var root *elem
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			e := &elem{Name: t.Name.Local, Attr: map[string]string{}}
+			for _, a := range t.Attr {
+				e.Attr[a.Name.Local] = a.Value
+			}
+			if len(stack) > 0 {
+				p := stack[len(stack)-1]
+				p.Children = append(p.Children, e)
+			} else if root == nil {
+				root = e
+			}
+			stack = append(stack, e)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				p := stack[len(stack)-1]
+				p.Children = append(p.Children, string(t))
+			}
+		}
+	}
+	if root == nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return root, nil
+}
+
+func (e *elem) child(name string) *elem {
+	for _, c := range e.Children {
+		if ce, ok := c.(*elem); ok && ce.Name == name {
+			return ce
+		}
+	}
+	return nil
+}
+
+func (e *elem) all(name string) []*elem {
+	var out []*elem
+	for _, c := range e.Children {
+		if ce, ok := c.(*elem); ok && ce.Name == name {
+			out = append(out, ce)
+		}
+	}
+	return out
+}
+
+// walk visits e and its descendants depth first; returning false skips
+// the element's children.
+func (e *elem) walk(fn func(*elem) bool) {
+	if !fn(e) {
+		return
+	}
+	for _, c := range e.Children {
+		if ce, ok := c.(*elem); ok {
+			ce.walk(fn)
+		}
+	}
+}
+
+// blockElems end a paragraph when rendering text.
+var blockElems = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "pre": true, "blockquote": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "prompt": true,
+	"table": true, "tr": true, "ul": true, "ol": true,
+}
+
+// text flattens e to plain paragraphs separated by blank lines, skipping
+// any child for which skip returns true.
+func (e *elem) text(skip func(*elem) bool) string {
+	var paras []string
+	var cur strings.Builder
+	flush := func() {
+		if p := strings.Join(strings.Fields(cur.String()), " "); p != "" {
+			paras = append(paras, p)
+		}
+		cur.Reset()
+	}
+	var visit func(*elem)
+	visit = func(n *elem) {
+		for _, c := range n.Children {
+			switch v := c.(type) {
+			case string:
+				cur.WriteString(v)
+			case *elem:
+				if skip != nil && skip(v) {
+					continue
+				}
+				if blockElems[v.Name] {
+					flush()
+					visit(v)
+					flush()
+				} else {
+					visit(v)
+				}
+			}
+		}
+	}
+	visit(e)
+	flush()
+	return strings.Join(paras, "\n\n")
+}
+
+// --- export documents ---
+
+type manifest struct {
+	XMLName    xml.Name   `xml:"manifest"`
+	Xmlns      string     `xml:"xmlns,attr"`
+	Identifier string     `xml:"identifier,attr"`
+	Resources  []resource `xml:"resources>resource"`
+}
+
+type resource struct {
+	Identifier   string       `xml:"identifier,attr"`
+	Type         string       `xml:"type,attr"`
+	Href         string       `xml:"href,attr"`
+	Files        []file       `xml:"file"`
+	Dependencies []dependency `xml:"dependency,omitempty"`
+}
+
+type file struct {
+	Href string `xml:"href,attr"`
+}
+
+type dependency struct {
+	IdentifierRef string `xml:"identifierref,attr"`
+}
+
+type assessmentItem struct {
+	XMLName        xml.Name             `xml:"assessmentItem"`
+	Xmlns          string               `xml:"xmlns,attr"`
+	Identifier     string               `xml:"identifier,attr"`
+	Title          string               `xml:"title,attr"`
+	Adaptive       bool                 `xml:"adaptive,attr"`
+	TimeDependent  bool                 `xml:"timeDependent,attr"`
+	Response       responseDeclaration  `xml:"responseDeclaration"`
+	Outcomes       []outcomeDeclaration `xml:"outcomeDeclaration"`
+	Body           itemBody             `xml:"itemBody"`
+	Processing     *responseProcessing  `xml:"responseProcessing,omitempty"`
+	ModalFeedbacks []modalFeedback      `xml:"modalFeedback,omitempty"`
+}
+
+type responseDeclaration struct {
+	Identifier  string   `xml:"identifier,attr"`
+	Cardinality string   `xml:"cardinality,attr"`
+	BaseType    string   `xml:"baseType,attr"`
+	Correct     []string `xml:"correctResponse>value,omitempty"`
+}
+
+type outcomeDeclaration struct {
+	Identifier  string `xml:"identifier,attr"`
+	Cardinality string `xml:"cardinality,attr"`
+	BaseType    string `xml:"baseType,attr"`
+}
+
+type itemBody struct {
+	Paragraphs   []string                 `xml:"p"`
+	Choice       *choiceInteraction       `xml:"choiceInteraction,omitempty"`
+	ExtendedText *extendedTextInteraction `xml:"extendedTextInteraction,omitempty"`
+}
+
+type choiceInteraction struct {
+	ResponseIdentifier string         `xml:"responseIdentifier,attr"`
+	Shuffle            bool           `xml:"shuffle,attr"`
+	MaxChoices         int            `xml:"maxChoices,attr"`
+	Choices            []simpleChoice `xml:"simpleChoice"`
+}
+
+type simpleChoice struct {
+	Identifier string `xml:"identifier,attr"`
+	Text       string `xml:",chardata"`
+}
+
+type extendedTextInteraction struct {
+	ResponseIdentifier string `xml:"responseIdentifier,attr"`
+	ExpectedLength     int    `xml:"expectedLength,attr,omitempty"`
+}
+
+type responseProcessing struct {
+	Template string `xml:"template,attr"`
+}
+
+type modalFeedback struct {
+	OutcomeIdentifier string `xml:"outcomeIdentifier,attr"`
+	ShowHide          string `xml:"showHide,attr"`
+	Identifier        string `xml:"identifier,attr"`
+	Text              string `xml:",chardata"`
+}
+
+type assessmentTest struct {
+	XMLName    xml.Name `xml:"assessmentTest"`
+	Xmlns      string   `xml:"xmlns,attr"`
+	Identifier string   `xml:"identifier,attr"`
+	Title      string   `xml:"title,attr"`
+	Part       testPart `xml:"testPart"`
+}
+
+type testPart struct {
+	Identifier     string      `xml:"identifier,attr"`
+	NavigationMode string      `xml:"navigationMode,attr"`
+	SubmissionMode string      `xml:"submissionMode,attr"`
+	Section        testSection `xml:"assessmentSection"`
+}
+
+type testSection struct {
+	Identifier string    `xml:"identifier,attr"`
+	Title      string    `xml:"title,attr"`
+	Visible    bool      `xml:"visible,attr"`
+	ItemRefs   []itemRef `xml:"assessmentItemRef"`
+}
+
+type itemRef struct {
+	Identifier string `xml:"identifier,attr"`
+	Href       string `xml:"href,attr"`
+}
@@ -0,0 +1,274 @@
+package quizzes
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+
+	"quizapi/internal/models"
+)
+
+// --- Adaptive mode ---
+//
+// Ability is estimated with a Rasch (one-parameter IRT) model: a learner
+// of ability θ answers a question of difficulty b correctly with
+// probability 1/(1+e^(b-θ)). After each answer the estimate is the mean of
+// the posterior over θ given a standard normal prior (EAP), which stays
+// finite when every answer so far is right or wrong; its standard error is
+// the posterior's standard deviation. The next question is the unserved
+// one whose difficulty is closest to θ, where it tells the most about the
+// learner. Only choice questions are served since text answers aren't
+// auto-graded.
+
+var (
+	ErrAdaptive           = errors.New("quiz is adaptive; take it one question at a time")
+	ErrNotAdaptive        = errors.New("quiz is not adaptive")
+	ErrNotCurrentQuestion = errors.New("question is not the one being asked")
+	ErrNoAdaptiveItems    = errors.New("quiz has no choice questions to serve")
+)
+
+// ability is an ability estimate and its standard error.
+type ability struct{ theta, se float64 }
+
+// response is an adaptive item joined with its question's difficulty.
+type response struct {
+	QuestionID uint
+	Difficulty float64
+	Correct    *bool
+}
+
+const (
+	abilityRange = 4.0  // θ is estimated on [-abilityRange, abilityRange]
+	abilityStep  = 0.05 // grid step for the posterior
+)
+
+// SetAdaptive turns adaptive mode on or off. Attempts in progress carry on
+// under the new settings.
+func (s *Service) SetAdaptive(quizID uint, req AdaptiveReq) (*models.Quiz, error) {
+	if req.Length == nil && req.TargetSE != nil {
+		return nil, errors.New("target_se requires a length")
+	}
+	var q models.Quiz
+	if err := s.db.First(&q, quizID).Error; err != nil {
+		return nil, fmt.Errorf("quiz %d not found", quizID)
+	}
+	if err := s.db.Model(&q).Updates(map[string]any{
+		"adaptive_length": req.Length, "adaptive_target_se": req.TargetSE,
+	}).Error; err != nil {
+		return nil, err
+	}
+	q.AdaptiveLength, q.AdaptiveTargetSE = req.Length, req.TargetSE
+	return &q, nil
+}
+
+// NextAdaptive starts or resumes the user's adaptive attempt and returns
+// the question to answer now. Asking again without answering returns the
+// same question.
+func (s *Service) NextAdaptive(quizID, userID uint) (*AdaptiveState, error) {
+	quiz, err := s.checkAccess(quizID, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if quiz.AdaptiveLength == nil {
+		return nil, ErrNotAdaptive
+	}
+	att, err := s.startAttempt(quizID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.advance(quiz, att)
+}
+
+// AnswerAdaptive grades the answer to the current question, updates the
+// ability estimate and serves the next question. When the attempt reaches
+// its length or target standard error it is submitted, and the state
+// carries the score instead of a question.
+func (s *Service) AnswerAdaptive(quizID, userID uint, a SubmitAnswer) (*AdaptiveState, error) {
+	quiz, err := s.checkAccess(quizID, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if quiz.AdaptiveLength == nil {
+		return nil, ErrNotAdaptive
+	}
+	att, err := s.startAttempt(quizID, userID)
+	if err != nil {
+		return nil, err
+	}
+	var item models.AdaptiveItem
+	res := s.db.Where("attempt_id = ? AND correct IS NULL", att.ID).Order("id desc").Limit(1).Find(&item)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 || item.QuestionID != a.QuestionID {
+		return nil, ErrNotCurrentQuestion
+	}
+	var q models.Question
+	if err := s.db.Preload("Options").Where("id = ? AND quiz_id = ?", a.QuestionID, quizID).
+		First(&q).Error; err != nil {
+		return nil, fmt.Errorf("question %d does not belong to quiz", a.QuestionID)
+	}
+	g, err := grade(q, a)
+	if err != nil {
+		return nil, err
+	}
+	if quiz.ConfidenceMarking && a.Confidence == nil {
+		return nil, fmt.Errorf("question %d needs a confidence (low, medium or high)", q.ID)
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := upsertDraft(tx, att.ID, q, a, g); err != nil {
+			return err
+		}
+		return tx.Model(&item).Update("correct", g.correct).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.advance(quiz, att)
+}
+
+// advance returns the attempt's pending question, serving a new one if
+// there is none, or submits the attempt once it is over.
+func (s *Service) advance(quiz *models.Quiz, att *models.Attempt) (*AdaptiveState, error) {
+	rs, err := s.responses(att.ID)
+	if err != nil {
+		return nil, err
+	}
+	state := &AdaptiveState{AttemptID: att.ID, Length: *quiz.AdaptiveLength}
+	served := make(map[uint]bool, len(rs))
+	var answered []response
+	var pending *uint
+	for _, r := range rs {
+		served[r.QuestionID] = true
+		if r.Correct == nil {
+			pending = &r.QuestionID
+			continue
+		}
+		answered = append(answered, r)
+	}
+	state.Answered = len(answered)
+	if pending != nil {
+		return state, s.serve(state, *pending)
+	}
+
+	ab := estimate(answered)
+	var pool []models.Question
+	if err := s.db.Scopes(ByPosition).Select("id, difficulty").
+		Where("quiz_id = ? AND type IN ?", quiz.ID, []models.QuestionType{models.QSingle, models.QMultiple}).
+		Find(&pool).Error; err != nil {
+		return nil, err
+	}
+	pool = slices.DeleteFunc(pool, func(q models.Question) bool { return served[q.ID] })
+
+	done := len(answered) >= *quiz.AdaptiveLength || len(pool) == 0 ||
+		(quiz.AdaptiveTargetSE != nil && len(answered) > 0 && ab.se <= *quiz.AdaptiveTargetSE)
+	if done {
+		if len(answered) == 0 {
+			return nil, ErrNoAdaptiveItems
+		}
+		sub, score, err := s.finalize(att, ab)
+		if err != nil {
+			return nil, err
+		}
+		state.SubmissionID, state.Score = &sub.ID, score
+		return state, nil
+	}
+
+	next := pickNext(pool, ab.theta)
+	item := models.AdaptiveItem{AttemptID: att.ID, QuestionID: next.ID, ServedAt: time.Now()}
+	if err := s.db.Create(&item).Error; err != nil {
+		return nil, err
+	}
+	return state, s.serve(state, next.ID)
+}
+
+// serve puts question questionID into state.
+func (s *Service) serve(state *AdaptiveState, questionID uint) error {
+	var q models.Question
+	if err := s.db.Preload("Options", ByPosition).First(&q, questionID).Error; err != nil {
+		return err
+	}
+	pq := ToPublicQuestion(q)
+	state.Question = &pq
+	return nil
+}
+
+// responses lists the attempt's adaptive items in the order served.
+func (s *Service) responses(attemptID uint) ([]response, error) {
+	var rs []response
+	err := s.db.Table("adaptive_items").
+		Select("adaptive_items.question_id, questions.difficulty, adaptive_items.correct").
+		Joins("JOIN questions ON questions.id = adaptive_items.question_id").
+		Where("adaptive_items.attempt_id = ?", attemptID).Order("adaptive_items.id").Scan(&rs).Error
+	return rs, err
+}
+
+// attemptAbility estimates ability from the attempt's answered adaptive
+// items; nil if it has none (a fixed-form attempt).
+func (s *Service) attemptAbility(attemptID uint) (*ability, error) {
+	rs, err := s.responses(attemptID)
+	if err != nil {
+		return nil, err
+	}
+	rs = slices.DeleteFunc(rs, func(r response) bool { return r.Correct == nil })
+	if len(rs) == 0 {
+		return nil, nil
+	}
+	return estimate(rs), nil
+}
+
+// estimate computes the EAP ability estimate for answered responses. The
+// posterior is kept in log space so long attempts don't underflow.
+func estimate(rs []response) *ability {
+	n := int(2*abilityRange/abilityStep) + 1
+	xs, logw := make([]float64, n), make([]float64, n)
+	maxLog := math.Inf(-1)
+	for i := range n {
+		x := -abilityRange + float64(i)*abilityStep
+		lw := -x * x / 2
+		for _, r := range rs {
+			p := 1 / (1 + math.Exp(r.Difficulty-x))
+			if *r.Correct {
+				lw += math.Log(p)
+			} else {
+				lw += math.Log(1 - p)
+			}
+		}
+		xs[i], logw[i] = x, lw
+		maxLog = max(maxLog, lw)
+	}
+	var norm, sum, sumSq float64
+	for i, x := range xs {
+		w := math.Exp(logw[i] - maxLog)
+		norm += w
+		sum += w * x
+		sumSq += w * x * x
+	}
+	mean := sum / norm
+	sd := math.Sqrt(max(sumSq/norm-mean*mean, 0))
+	return &ability{theta: round3(mean), se: round3(sd)}
+}
+
+// pickNext returns the question whose difficulty is closest to theta,
+// choosing at random among equally close ones so learners with the same
+// answers don't all see the same questions.
+func pickNext(pool []models.Question, theta float64) models.Question {
+	var best []models.Question
+	bestDist := math.Inf(1)
+	for _, q := range pool {
+		switch d := math.Abs(q.Difficulty - theta); {
+		case d < bestDist:
+			best, bestDist = []models.Question{q}, d
+		case d == bestDist:
+			best = append(best, q)
+		}
+	}
+	return best[rand.IntN(len(best))]
+}
+
+func round3(x float64) float64 { return math.Round(x*1000) / 1000 }
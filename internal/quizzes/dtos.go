@@ -1,22 +1,116 @@
 package quizzes
 
-import "quizapi/internal/models"
+import (
+	"time"
+
+	"quizapi/internal/models"
+)
 
 type CreateQuizReq struct {
 	Title string `json:"title" validate:"required,min=1,max=200"`
 }
 
+// ScheduleReq replaces a quiz's availability window; null leaves that side open.
+type ScheduleReq struct {
+	OpensAt  *time.Time `json:"opens_at"`
+	ClosesAt *time.Time `json:"closes_at"`
+}
+
+// ExtensionReq overrides the window for one user. Omitted bounds keep the
+// quiz's own.
+type ExtensionReq struct {
+	OpensAt  *time.Time `json:"opens_at"`
+	ClosesAt *time.Time `json:"closes_at"`
+	Reason   string     `json:"reason" validate:"max=255"`
+}
+
+// AdaptiveReq configures adaptive mode. A null length turns it off.
+type AdaptiveReq struct {
+	Length   *int     `json:"length" validate:"omitempty,min=1"`
+	TargetSE *float64 `json:"target_se" validate:"omitempty,gt=0"`
+}
+
+// MarkingReq sets a quiz's marking rules. wrong_mark is the (negative)
+// mark for a wrong answer; confidence switches to confidence-based marking.
+type MarkingReq struct {
+	WrongMark  float64 `json:"wrong_mark" validate:"min=-1,max=0"`
+	Confidence bool    `json:"confidence"`
+}
+
+// SubmissionPolicyReq sets whether every question must be answered.
+type SubmissionPolicyReq struct {
+	RequireComplete *bool `json:"require_complete" validate:"required"`
+}
+
+// Text fields accept Markdown; images are referenced by their /attachments URL.
 type CreateQuestionOption struct {
-	Text      string `json:"text" validate:"required,min=1,max=300"`
+	Text      string `json:"text" validate:"required,min=1,max=5000"`
 	IsCorrect *bool  `json:"is_correct"`
 }
 
 type CreateQuestionReq struct {
-	Text      string                 `json:"text" validate:"required,min=1"`
-	Type      string                 `json:"type" validate:"required,oneof=single multiple text"`
-	WordLimit *int                   `json:"word_limit"`
-	Options   []CreateQuestionOption `json:"options"`
+	Text        string                 `json:"text" validate:"required,min=1"`
+	Type        string                 `json:"type" validate:"required,oneof=single multiple text"`
+	SectionID   *uint                  `json:"section_id"`
+	WordLimit   *int                   `json:"word_limit"`
+	Explanation string                 `json:"explanation"`
+	Difficulty  *float64               `json:"difficulty" validate:"omitempty,min=-6,max=6"`
+	Options     []CreateQuestionOption `json:"options"`
+}
+
+// UpdateQuestionOption references an existing option by ID; options
+// without an ID are new.
+type UpdateQuestionOption struct {
+	ID *uint `json:"id"`
+	CreateQuestionOption
+}
+
+type UpdateQuestionReq struct {
+	Text        string `json:"text" validate:"required,min=1"`
+	Type        string `json:"type" validate:"required,oneof=single multiple text"`
+	WordLimit   *int   `json:"word_limit"`
+	Explanation string `json:"explanation"`
+	// Difficulty is left unchanged when omitted.
+	Difficulty *float64               `json:"difficulty" validate:"omitempty,min=-6,max=6"`
+	Options    []UpdateQuestionOption `json:"options" validate:"dive"`
+}
+
+type CreateSectionReq struct {
+	Title        string `json:"title" validate:"required,min=1,max=200"`
+	Instructions string `json:"instructions"`
+	// TimeLimitSeconds is advisory: it is shown to clients to display a
+	// countdown, but the server does not enforce it on submit.
+	TimeLimitSeconds *int `json:"time_limit_seconds" validate:"omitempty,min=1"`
+	PickCount        *int `json:"pick_count" validate:"omitempty,min=1"`
+}
+
+// UpdateSectionReq replaces a section's settings; omitting
+// time_limit_seconds or pick_count clears them.
+type UpdateSectionReq struct {
+	Title        string `json:"title" validate:"required,min=1,max=200"`
+	Instructions string `json:"instructions"`
+	// TimeLimitSeconds is advisory, as in CreateSectionReq.
+	TimeLimitSeconds *int `json:"time_limit_seconds" validate:"omitempty,min=1"`
+	PickCount        *int `json:"pick_count" validate:"omitempty,min=1"`
+}
+
+type ReorderSectionsReq struct {
+	SectionIDs []uint `json:"section_ids" validate:"required,min=1"`
 }
+
+// MoveQuestionReq moves a question into a section; a null section_id
+// takes it out of any section.
+type MoveQuestionReq struct {
+	SectionID *uint `json:"section_id"`
+}
+
+// ReorderQuestionsReq sets the order of every question in a quiz and,
+// optionally, the order of options per question (keyed by question ID).
+type ReorderQuestionsReq struct {
+	QuestionIDs  []uint          `json:"question_ids" validate:"omitempty,dive,min=1"`
+	OptionOrders map[uint][]uint `json:"option_orders" validate:"dive,min=1,dive,min=1"`
+}
+
 type ListQuizzesResp struct {
 	Quizzes      []models.Quiz `json:"quizzes"`
 	TotalRecords int64         `json:"total_records"`
@@ -24,31 +118,130 @@ type ListQuizzesResp struct {
 	Limit        int           `json:"limit"`
 }
 
+// Public* types carry both the Markdown source and the rendered, sanitized HTML.
 type PublicOption struct {
-	ID   uint   `json:"id"`
-	Text string `json:"text"`
+	ID       uint   `json:"id"`
+	Text     string `json:"text"`
+	TextHTML string `json:"text_html"`
 }
 
 type PublicQuestion struct {
 	ID        uint           `json:"id"`
+	Position  int            `json:"position"`
 	Text      string         `json:"text"`
+	TextHTML  string         `json:"text_html"`
 	Type      string         `json:"type"`
 	WordLimit *int           `json:"word_limit"`
 	Options   []PublicOption `json:"options"`
 }
 
+type PublicSection struct {
+	ID               uint             `json:"id"`
+	Title            string           `json:"title"`
+	Instructions     string           `json:"instructions"`
+	InstructionsHTML string           `json:"instructions_html"`
+	TimeLimitSeconds *int             `json:"time_limit_seconds"`
+	Questions        []PublicQuestion `json:"questions"`
+}
+
+// PublicQuiz is what a quiz taker sees: ordered sections, followed by
+// any questions that don't belong to a section, and the marking rules.
+type PublicQuiz struct {
+	QuizID            uint             `json:"quiz_id"`
+	AttemptID         uint             `json:"attempt_id,omitempty"`
+	WrongMark         float64          `json:"wrong_mark"`
+	ConfidenceMarking bool             `json:"confidence_marking"`
+	RequireComplete   bool             `json:"require_complete"`
+	Sections          []PublicSection  `json:"sections"`
+	Questions         []PublicQuestion `json:"questions"`
+}
+
+// SubmitAnswer is one answer. Confidence is required for choice questions
+// in quizzes with confidence-based marking.
 type SubmitAnswer struct {
-	QuestionID        uint    `json:"question_id" validate:"required"`
-	SelectedOptionID  *uint   `json:"selected_option_id"`
-	SelectedOptionIDs []uint  `json:"selected_option_ids"`
-	TextAnswer        *string `json:"text_answer"`
+	QuestionID        uint               `json:"question_id" validate:"required"`
+	SelectedOptionID  *uint              `json:"selected_option_id"`
+	SelectedOptionIDs []uint             `json:"selected_option_ids"`
+	TextAnswer        *string            `json:"text_answer"`
+	Confidence        *models.Confidence `json:"confidence" validate:"omitempty,oneof=low medium high"`
 }
 
+// SubmitReq submits answers. AccessCode unlocks a code-protected quiz the
+// submitter has no grant for yet.
 type SubmitReq struct {
-	Answers []SubmitAnswer `json:"answers" validate:"required,dive"`
+	Answers    []SubmitAnswer `json:"answers" validate:"required,dive"`
+	AccessCode string         `json:"access_code" validate:"max=32"`
+}
+
+// DraftAnswerReq saves the answer to one question of an attempt. The
+// fields and rules are the same as for SubmitAnswer.
+type DraftAnswerReq struct {
+	SelectedOptionID  *uint              `json:"selected_option_id"`
+	SelectedOptionIDs []uint             `json:"selected_option_ids"`
+	TextAnswer        *string            `json:"text_answer"`
+	Confidence        *models.Confidence `json:"confidence" validate:"omitempty,oneof=low medium high"`
+}
+
+// AttemptResp lets a learner resume an attempt, e.g. on another device.
+type AttemptResp struct {
+	ID           uint                 `json:"id"`
+	QuizID       uint                 `json:"quiz_id"`
+	StartedAt    time.Time            `json:"started_at"`
+	SubmittedAt  *time.Time           `json:"submitted_at"`
+	SubmissionID *uint                `json:"submission_id"`
+	Answers      []models.DraftAnswer `json:"answers"`
+}
+
+// AdaptiveState is where a learner stands in an adaptive attempt: the
+// question to answer now or, once the attempt has ended, the score.
+type AdaptiveState struct {
+	AttemptID    uint            `json:"attempt_id"`
+	Answered     int             `json:"answered"`
+	Length       int             `json:"length"`
+	Question     *PublicQuestion `json:"question"`
+	SubmissionID *uint           `json:"submission_id,omitempty"`
+	Score        *ScoreResp      `json:"score,omitempty"`
+}
+
+type SectionScore struct {
+	SectionID uint   `json:"section_id"`
+	Title     string `json:"title"`
+	Score     int    `json:"score"`
+	Total     int    `json:"total"`
+}
+
+// Breakdown explains how marks were arrived at.
+type Breakdown struct {
+	Correct int `json:"correct"`
+	Wrong   int `json:"wrong"`
+	Skipped int `json:"skipped"`
+	// WrongMarks is the (negative) total of marks for wrong answers.
+	WrongMarks float64 `json:"wrong_marks"`
+	// ByConfidence tallies answers per confidence level under
+	// confidence-based marking.
+	ByConfidence map[models.Confidence]*ConfidenceTally `json:"by_confidence,omitempty"`
+}
+
+type ConfidenceTally struct {
+	Answered int     `json:"answered"`
+	Correct  int     `json:"correct"`
+	Marks    float64 `json:"marks"`
 }
 
+// ScoreResp reports correct answers (Score of Total) and marks under the
+// quiz's marking rules, which Percent is based on.
 type ScoreResp struct {
-	Score int `json:"score"`
-	Total int `json:"total"`
+	Score     int       `json:"score"`
+	Total     int       `json:"total"`
+	Marks     float64   `json:"marks"`
+	MaxMarks  float64   `json:"max_marks"`
+	Percent   int       `json:"percent"`
+	Breakdown Breakdown `json:"breakdown"`
+	// SkippedQuestionIDs lists every served question left unanswered; the
+	// choice questions among them count towards Total.
+	SkippedQuestionIDs []uint         `json:"skipped_question_ids"`
+	Sections           []SectionScore `json:"sections,omitempty"`
+	// Ability and AbilitySE are set for adaptive attempts.
+	Ability   *float64 `json:"ability,omitempty"`
+	AbilitySE *float64 `json:"ability_se,omitempty"`
 }
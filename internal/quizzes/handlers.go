@@ -1,11 +1,15 @@
 package quizzes
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+
+	"quizapi/internal/access"
+	"quizapi/internal/models"
 )
 
 type Handler struct {
@@ -35,6 +39,20 @@ func (h *Handler) CreateQuiz(c *gin.Context) {
 	c.JSON(http.StatusCreated, q)
 }
 
+func (h *Handler) PublishQuiz(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	q, err := h.svc.PublishQuiz(uint(quizID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, q)
+}
+
 func (h *Handler) ListQuizzes(c *gin.Context) {
 	// --- Parse Pagination Parameters ---
 	// Default to page 1
@@ -52,8 +70,22 @@ func (h *Handler) ListQuizzes(c *gin.Context) {
 		limit = 100
 	}
 
+	status := c.Query("status")
+	switch status {
+	case "", StatusUpcoming, StatusOpen, StatusClosed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be upcoming, open or closed"})
+		return
+	}
+
 	// --- Call the Service ---
-	quizzes, total, err := h.svc.ListQuizzes(page, limit)
+	// The route is public; a token, if sent, reveals assigned quizzes
+	role, _ := c.Get("role")
+	quizzes, total, err := h.svc.ListQuizzes(page, limit, ListFilter{
+		Status: status,
+		UserID: c.GetUint("userID"),
+		All:    role == models.RoleAdmin,
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -91,15 +123,49 @@ func (h *Handler) AddQuestion(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"id": q.ID})
 }
 
+func (h *Handler) UpdateQuestion(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	questionID, err := strconv.Atoi(c.Param("questionID"))
+	if err != nil || questionID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid questionID"})
+		return
+	}
+	var req UpdateQuestionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	q, err := h.svc.UpdateQuestion(uint(quizID), uint(questionID), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": q.ID, "revision_id": q.RevisionID})
+}
+
 func (h *Handler) GetQuestions(c *gin.Context) {
 	quizID, err := strconv.Atoi(c.Param("quizID"))
 	if err != nil || quizID <= 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
 		return
 	}
-	qs, err := h.svc.GetPublicQuestions(uint(quizID))
+	if code := c.Query("code"); code != "" {
+		if err := h.svc.Redeem(uint(quizID), c.GetUint("userID"), code); err != nil {
+			c.JSON(accessStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+			return
+		}
+	}
+	qs, err := h.svc.GetPublicQuestions(uint(quizID), c.GetUint("userID"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(accessStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, qs)
@@ -120,10 +186,458 @@ func (h *Handler) Submit(c *gin.Context) {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
 		return
 	}
-	_, score, total, serr := h.svc.SubmitAndScore(uint(quizID), req)
+	_, res, serr := h.svc.SubmitAndScore(uint(quizID), c.GetUint("userID"), req)
 	if serr != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": serr.Error()})
+		writeSubmitErr(c, serr)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) CreateSection(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	var req CreateSectionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	sec, err := h.svc.CreateSection(uint(quizID), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, sec)
+}
+
+func (h *Handler) UpdateSection(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	sectionID, err := strconv.Atoi(c.Param("sectionID"))
+	if err != nil || sectionID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sectionID"})
+		return
+	}
+	var req UpdateSectionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	sec, err := h.svc.UpdateSection(uint(quizID), uint(sectionID), req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrSectionNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sec)
+}
+
+func (h *Handler) DeleteSection(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
 		return
 	}
-	c.JSON(http.StatusOK, ScoreResp{Score: score, Total: total})
+	sectionID, err := strconv.Atoi(c.Param("sectionID"))
+	if err != nil || sectionID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sectionID"})
+		return
+	}
+	if err := h.svc.DeleteSection(uint(quizID), uint(sectionID)); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrSectionNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) ReorderSections(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	var req ReorderSectionsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.svc.ReorderSections(uint(quizID), req.SectionIDs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) MoveQuestion(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	questionID, err := strconv.Atoi(c.Param("questionID"))
+	if err != nil || questionID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid questionID"})
+		return
+	}
+	var req MoveQuestionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.svc.MoveQuestion(uint(quizID), uint(questionID), req.SectionID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) ReorderQuestions(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	var req ReorderQuestionsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.svc.ReorderQuestions(uint(quizID), req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) SetSchedule(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	var req ScheduleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	q, err := h.svc.SetSchedule(uint(quizID), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, q)
+}
+
+func (h *Handler) SetSubmissionPolicy(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	var req SubmissionPolicyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	q, err := h.svc.SetSubmissionPolicy(uint(quizID), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, q)
+}
+
+func (h *Handler) SetMarking(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	var req MarkingReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	q, err := h.svc.SetMarking(uint(quizID), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, q)
+}
+
+func (h *Handler) SetAdaptive(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	var req AdaptiveReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	q, err := h.svc.SetAdaptive(uint(quizID), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, q)
+}
+
+func (h *Handler) NextAdaptive(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	if code := c.Query("code"); code != "" {
+		if err := h.svc.Redeem(uint(quizID), c.GetUint("userID"), code); err != nil {
+			c.JSON(accessStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+			return
+		}
+	}
+	state, err := h.svc.NextAdaptive(uint(quizID), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(accessStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, state)
+}
+
+func (h *Handler) AnswerAdaptive(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	var req SubmitAnswer
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	state, err := h.svc.AnswerAdaptive(uint(quizID), c.GetUint("userID"), req)
+	if err != nil {
+		c.JSON(accessStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, state)
+}
+
+func (h *Handler) ListExtensions(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	exts, err := h.svc.ListExtensions(uint(quizID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, exts)
+}
+
+func (h *Handler) GrantExtension(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	userID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil || userID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid userID"})
+		return
+	}
+	var req ExtensionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	ext, err := h.svc.GrantExtension(uint(quizID), uint(userID), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ext)
+}
+
+func (h *Handler) RevokeExtension(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	userID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil || userID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid userID"})
+		return
+	}
+	if err := h.svc.RevokeExtension(uint(quizID), uint(userID)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) GetAttempt(c *gin.Context) {
+	attemptID, err := strconv.Atoi(c.Param("attemptID"))
+	if err != nil || attemptID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attemptID"})
+		return
+	}
+	att, err := h.svc.GetAttempt(uint(attemptID), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(attemptStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, att)
+}
+
+func (h *Handler) SaveAnswer(c *gin.Context) {
+	attemptID, err := strconv.Atoi(c.Param("attemptID"))
+	if err != nil || attemptID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attemptID"})
+		return
+	}
+	questionID, err := strconv.Atoi(c.Param("questionID"))
+	if err != nil || questionID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid questionID"})
+		return
+	}
+	var req DraftAnswerReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	draft, err := h.svc.SaveDraft(uint(attemptID), uint(questionID), c.GetUint("userID"), req)
+	if err != nil {
+		c.JSON(attemptStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, draft)
+}
+
+func (h *Handler) DiscardAnswer(c *gin.Context) {
+	attemptID, err := strconv.Atoi(c.Param("attemptID"))
+	if err != nil || attemptID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attemptID"})
+		return
+	}
+	questionID, err := strconv.Atoi(c.Param("questionID"))
+	if err != nil || questionID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid questionID"})
+		return
+	}
+	if err := h.svc.DiscardDraft(uint(attemptID), uint(questionID), c.GetUint("userID")); err != nil {
+		c.JSON(attemptStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) FinalizeAttempt(c *gin.Context) {
+	attemptID, err := strconv.Atoi(c.Param("attemptID"))
+	if err != nil || attemptID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attemptID"})
+		return
+	}
+	_, res, err := h.svc.FinalizeAttempt(uint(attemptID), c.GetUint("userID"))
+	if err != nil {
+		writeSubmitErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// writeSubmitErr reports a failed submit. Partial submissions that the
+// quiz doesn't accept get 422 with the IDs of the unanswered questions.
+func writeSubmitErr(c *gin.Context, err error) {
+	var inc *IncompleteError
+	if errors.As(err, &inc) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "skipped_question_ids": inc.QuestionIDs})
+		return
+	}
+	c.JSON(attemptStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+}
+
+// attemptStatus maps attempt errors to 404 and 409, and defers to
+// accessStatus for the rest.
+func attemptStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, ErrAttemptNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrAttemptClosed):
+		return http.StatusConflict
+	}
+	return accessStatus(err, fallback)
+}
+
+// accessStatus maps errors that deny access to a quiz, including a missing
+// or invalid access code, to 403 (or 404 for hidden quizzes), taking a quiz
+// in the wrong mode to 409, and anything else to fallback.
+func accessStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, ErrQuizHidden):
+		return http.StatusNotFound
+	case errors.Is(err, ErrQuizNotOpen), errors.Is(err, ErrQuizClosed),
+		errors.Is(err, access.ErrCodeRequired), errors.Is(err, access.ErrInvalidCode):
+		return http.StatusForbidden
+	case errors.Is(err, ErrAdaptive), errors.Is(err, ErrNotAdaptive), errors.Is(err, ErrNotCurrentQuestion):
+		return http.StatusConflict
+	}
+	return fallback
 }
@@ -0,0 +1,80 @@
+package quizzes_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"quizapi/internal/models"
+	"quizapi/internal/quizzes"
+	"quizapi/internal/testutil"
+)
+
+func TestListQuizzesHandler_AdminSeesAssignedQuizzes(t *testing.T) {
+	d := testutil.DB(t)
+	svc := quizzes.NewService(d)
+	_, err := svc.CreateQuiz("open to all")
+	require.NoError(t, err)
+	assigned, err := svc.CreateQuiz("cohort only")
+	require.NoError(t, err)
+	cohort := &models.Cohort{Name: "night class"}
+	require.NoError(t, d.Create(cohort).Error)
+	require.NoError(t, d.Create(&models.QuizAssignment{QuizID: assigned.ID, CohortID: cohort.ID}).Error)
+
+	gin.SetMode(gin.TestMode)
+	list := func(role models.Role) []string {
+		t.Helper()
+		r := gin.New()
+		// stands in for the auth middleware, which stores the role as a models.Role
+		r.GET("/quizzes", func(c *gin.Context) {
+			c.Set("userID", uint(learner))
+			c.Set("role", role)
+		}, quizzes.NewHandler(svc).ListQuizzes)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quizzes", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp quizzes.ListQuizzesResp
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		titles := []string{}
+		for _, q := range resp.Quizzes {
+			titles = append(titles, q.Title)
+		}
+		return titles
+	}
+
+	require.Equal(t, []string{"open to all"}, list(models.RoleUser))
+	require.Equal(t, []string{"cohort only", "open to all"}, list(models.RoleAdmin))
+}
+
+func TestReorderQuestionsHandler_ValidatesRequest(t *testing.T) {
+	d := testutil.DB(t)
+	svc := quizzes.NewService(d)
+	qz, err := svc.CreateQuiz("order")
+	require.NoError(t, err)
+	q, err := svc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{Text: "Why?", Type: "text", WordLimit: ptr(50)})
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.PUT("/quizzes/:quizID/questions/order", quizzes.NewHandler(svc).ReorderQuestions)
+	put := func(body string) int {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, "/quizzes/"+strconv.Itoa(int(qz.ID))+"/questions/order", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusBadRequest, put(`{"question_ids":`))
+	require.Equal(t, http.StatusUnprocessableEntity, put(`{"question_ids":[0]}`))
+	require.Equal(t, http.StatusUnprocessableEntity, put(`{"option_orders":{"1":[]}}`))
+	require.Equal(t, http.StatusBadRequest, put(`{"question_ids":[999]}`))
+	require.Equal(t, http.StatusNoContent, put(`{"question_ids":[`+strconv.Itoa(int(q.ID))+`]}`))
+}
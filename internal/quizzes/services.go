@@ -3,16 +3,37 @@ package quizzes
 import (
 	"errors"
 	"fmt"
+	"math/rand/v2"
 	"slices"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"quizapi/internal/access"
+	"quizapi/internal/certificates"
+	"quizapi/internal/cohorts"
+	"quizapi/internal/events"
+	"quizapi/internal/leaderboard"
 	"quizapi/internal/models"
+	"quizapi/internal/revisions"
+	"quizapi/internal/richtext"
 )
 
-type Service struct{ db *gorm.DB }
+type Service struct {
+	db     *gorm.DB
+	events events.Publisher
+}
+
+func NewService(db *gorm.DB) *Service { return &Service{db: db, events: events.Discard} }
 
-func NewService(db *gorm.DB) *Service { return &Service{db: db} }
+// SetPublisher sets where quiz and submission events are sent.
+func (s *Service) SetPublisher(p events.Publisher) { s.events = p }
+
+// ByPosition orders rows by their explicit position, falling back to
+// insertion order for rows that share one. Every read path that returns
+// sections, questions or options should use it.
+func ByPosition(db *gorm.DB) *gorm.DB { return db.Order("position, id") }
 
 // --- Quiz management ---
 
@@ -21,13 +42,57 @@ func (s *Service) CreateQuiz(title string) (*models.Quiz, error) {
 	return q, s.db.Create(q).Error
 }
 
-func (s *Service) ListQuizzes(page, limit int) ([]models.Quiz, int64, error) {
+// PublishQuiz marks the quiz as published and emits quiz.published.
+// Publishing an already published quiz is a no-op.
+func (s *Service) PublishQuiz(quizID uint) (*models.Quiz, error) {
+	var q models.Quiz
+	if err := s.db.First(&q, quizID).Error; err != nil {
+		return nil, fmt.Errorf("quiz %d not found", quizID)
+	}
+	if q.PublishedAt != nil {
+		return &q, nil
+	}
+	now := time.Now()
+	q.PublishedAt = &now
+	ev := events.Quiz(events.QuizPublished, &q)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&q).Update("published_at", now).Error; err != nil {
+			return err
+		}
+		return events.Record(tx, s.events, ev)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.events.Publish(ev)
+	return &q, nil
+}
+
+// ListFilter narrows ListQuizzes. Status is an availability status
+// (StatusUpcoming, StatusOpen or StatusClosed; "" for all). Quizzes
+// assigned to cohorts are only listed for their members unless All is set.
+type ListFilter struct {
+	Status string
+	UserID uint
+	All    bool
+}
+
+// ListQuizzes pages through the quizzes that match f.
+func (s *Service) ListQuizzes(page, limit int, f ListFilter) ([]models.Quiz, int64, error) {
 	var quizzes []models.Quiz
 	var total int64
 
+	scoped, err := byStatus(s.db.Model(&models.Quiz{}), f.Status, time.Now())
+	if err != nil {
+		return nil, 0, err
+	}
+	if !f.All {
+		scoped = scoped.Scopes(cohorts.Visible(f.UserID), access.Listed(f.UserID))
+	}
+
 	// First, count the total number of records without pagination.
 	// This is for the API response metadata.
-	if err := s.db.Model(&models.Quiz{}).Count(&total).Error; err != nil {
+	if err := scoped.Session(&gorm.Session{}).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -35,7 +100,7 @@ func (s *Service) ListQuizzes(page, limit int) ([]models.Quiz, int64, error) {
 	offset := (page - 1) * limit
 
 	// Now, fetch the actual page of data.
-	err := s.db.Offset(offset).Limit(limit).Order("id desc").Find(&quizzes).Error
+	err = scoped.Offset(offset).Limit(limit).Order("id desc").Find(&quizzes).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -43,93 +108,724 @@ func (s *Service) ListQuizzes(page, limit int) ([]models.Quiz, int64, error) {
 	return quizzes, total, nil
 }
 
-// AddQuestion validates per type, then writes Question + Options
-func (s *Service) AddQuestion(quizID uint, req CreateQuestionReq) (*models.Question, error) {
-	qt := models.QuestionType(req.Type)
+// SetSubmissionPolicy sets whether the quiz accepts partial submissions.
+func (s *Service) SetSubmissionPolicy(quizID uint, req SubmissionPolicyReq) (*models.Quiz, error) {
+	var q models.Quiz
+	if err := s.db.First(&q, quizID).Error; err != nil {
+		return nil, fmt.Errorf("quiz %d not found", quizID)
+	}
+	if err := s.db.Model(&q).Update("require_complete", *req.RequireComplete).Error; err != nil {
+		return nil, err
+	}
+	q.RequireComplete = *req.RequireComplete
+	return &q, nil
+}
+
+// SetMarking replaces the quiz's marking rules. Existing submissions keep
+// their marks until they are re-graded.
+func (s *Service) SetMarking(quizID uint, req MarkingReq) (*models.Quiz, error) {
+	var q models.Quiz
+	if err := s.db.First(&q, quizID).Error; err != nil {
+		return nil, fmt.Errorf("quiz %d not found", quizID)
+	}
+	if err := s.db.Model(&q).Updates(map[string]any{
+		"wrong_mark": req.WrongMark, "confidence_marking": req.Confidence,
+	}).Error; err != nil {
+		return nil, err
+	}
+	q.WrongMark, q.ConfidenceMarking = req.WrongMark, req.Confidence
+	return &q, nil
+}
+
+// --- Availability windows ---
+
+var (
+	ErrQuizNotOpen = errors.New("quiz is not open yet")
+	ErrQuizClosed  = errors.New("quiz is closed")
+	// ErrQuizHidden is returned to users outside the cohorts a quiz is
+	// assigned to; it reads as a missing quiz so its existence isn't leaked.
+	ErrQuizHidden = errors.New("quiz not found")
+)
+
+// Availability statuses accepted by ListQuizzes.
+const (
+	StatusUpcoming = "upcoming"
+	StatusOpen     = "open"
+	StatusClosed   = "closed"
+)
+
+// SetSchedule replaces the quiz's availability window and emits
+// quiz.schedule_changed.
+func (s *Service) SetSchedule(quizID uint, req ScheduleReq) (*models.Quiz, error) {
+	opens, closes := utc(req.OpensAt), utc(req.ClosesAt)
+	if opens != nil && closes != nil && !closes.After(*opens) {
+		return nil, errors.New("closes_at must be after opens_at")
+	}
+	var q models.Quiz
+	if err := s.db.First(&q, quizID).Error; err != nil {
+		return nil, fmt.Errorf("quiz %d not found", quizID)
+	}
+	q.OpensAt, q.ClosesAt = opens, closes
+	ev := events.Quiz(events.QuizScheduleChanged, &q)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&q).Updates(map[string]any{"opens_at": opens, "closes_at": closes}).Error; err != nil {
+			return err
+		}
+		return events.Record(tx, s.events, ev)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.events.Publish(ev)
+	return &q, nil
+}
+
+// GrantExtension creates or replaces a user's personal window for a quiz.
+func (s *Service) GrantExtension(quizID, userID uint, req ExtensionReq) (*models.QuizExtension, error) {
+	if req.OpensAt == nil && req.ClosesAt == nil {
+		return nil, errors.New("an extension needs opens_at and/or closes_at")
+	}
+	var q models.Quiz
+	if err := s.db.First(&q, quizID).Error; err != nil {
+		return nil, fmt.Errorf("quiz %d not found", quizID)
+	}
+	if err := s.db.First(&models.User{}, userID).Error; err != nil {
+		return nil, fmt.Errorf("user %d not found", userID)
+	}
+	ext := models.QuizExtension{
+		QuizID: quizID, UserID: userID, OpensAt: utc(req.OpensAt), ClosesAt: utc(req.ClosesAt), Reason: req.Reason,
+	}
+	opens, closes := window(&q, &ext)
+	if opens != nil && closes != nil && !closes.After(*opens) {
+		return nil, errors.New("the extended window closes before it opens")
+	}
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "quiz_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"opens_at", "closes_at", "reason", "updated_at"}),
+	}).Create(&ext).Error
+	if err != nil {
+		return nil, err
+	}
+	return &ext, s.db.Where("quiz_id = ? AND user_id = ?", quizID, userID).First(&ext).Error
+}
+
+func (s *Service) ListExtensions(quizID uint) ([]models.QuizExtension, error) {
+	var out []models.QuizExtension
+	return out, s.db.Where("quiz_id = ?", quizID).Order("user_id").Find(&out).Error
+}
+
+func (s *Service) RevokeExtension(quizID, userID uint) error {
+	res := s.db.Where("quiz_id = ? AND user_id = ?", quizID, userID).Delete(&models.QuizExtension{})
+	if res.Error == nil && res.RowsAffected == 0 {
+		return fmt.Errorf("no extension for user %d on quiz %d", userID, quizID)
+	}
+	return res.Error
+}
+
+// checkAccess loads the quiz for userID to take. It returns ErrQuizHidden
+// when the quiz is assigned to cohorts userID is not in,
+// access.ErrCodeRequired when it is code-protected and userID has no grant,
+// and ErrQuizNotOpen or ErrQuizClosed when userID may not take the quiz at
+// now, honoring their extension if they have one.
+func (s *Service) checkAccess(quizID, userID uint, now time.Time) (*models.Quiz, error) {
+	return s.checkAccessToRedeem(quizID, userID, now, false)
+}
+
+// checkAccessToRedeem is checkAccess for a caller about to redeem an
+// access code: with redeeming set, a missing grant is left for the
+// redemption to settle, but every other check still applies first.
+func (s *Service) checkAccessToRedeem(quizID, userID uint, now time.Time, redeeming bool) (*models.Quiz, error) {
+	var q models.Quiz
+	if err := s.db.First(&q, quizID).Error; err != nil {
+		return nil, fmt.Errorf("quiz %d not found", quizID)
+	}
+	visible, err := cohorts.CanSee(s.db, quizID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, ErrQuizHidden
+	}
+	if !redeeming {
+		if err := access.Check(s.db, &q, userID); err != nil {
+			return nil, err
+		}
+	}
+	var ext *models.QuizExtension
+	if userID != 0 {
+		var e models.QuizExtension
+		res := s.db.Where("quiz_id = ? AND user_id = ?", quizID, userID).Limit(1).Find(&e)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			ext = &e
+		}
+	}
+	opens, closes := window(&q, ext)
+	switch {
+	case opens != nil && now.Before(*opens):
+		return nil, ErrQuizNotOpen
+	case closes != nil && !now.Before(*closes):
+		return nil, ErrQuizClosed
+	}
+	return &q, nil
+}
+
+// Redeem unlocks a code-protected quiz for userID with an access code (see
+// access.Redeem).
+func (s *Service) Redeem(quizID, userID uint, code string) error {
+	visible, err := cohorts.CanSee(s.db, quizID, userID)
+	if err != nil {
+		return err
+	}
+	if !visible {
+		return ErrQuizHidden
+	}
+	return access.Redeem(s.db, quizID, userID, code, time.Now())
+}
+
+// window merges an optional extension over the quiz's own window.
+func window(q *models.Quiz, ext *models.QuizExtension) (opens, closes *time.Time) {
+	opens, closes = q.OpensAt, q.ClosesAt
+	if ext != nil {
+		if ext.OpensAt != nil {
+			opens = ext.OpensAt
+		}
+		if ext.ClosesAt != nil {
+			closes = ext.ClosesAt
+		}
+	}
+	return opens, closes
+}
+
+// byStatus filters quizzes by their own window (extensions are per user
+// and don't affect listings).
+func byStatus(db *gorm.DB, status string, now time.Time) (*gorm.DB, error) {
+	switch status {
+	case "":
+		return db, nil
+	case StatusUpcoming:
+		return db.Where("opens_at > ?", now), nil
+	case StatusOpen:
+		return db.Where("(opens_at IS NULL OR opens_at <= ?) AND (closes_at IS NULL OR closes_at > ?)", now, now), nil
+	case StatusClosed:
+		return db.Where("closes_at <= ?", now), nil
+	}
+	return nil, fmt.Errorf("unknown status %q (want upcoming, open or closed)", status)
+}
+
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+// --- Sections ---
+
+// CreateSection appends a new section after the quiz's existing ones.
+func (s *Service) CreateSection(quizID uint, req CreateSectionReq) (*models.Section, error) {
+	if err := s.db.First(&models.Quiz{}, quizID).Error; err != nil {
+		return nil, fmt.Errorf("quiz %d not found", quizID)
+	}
+	pos, err := NextPosition(s.db.Model(&models.Section{}).Where("quiz_id = ?", quizID))
+	if err != nil {
+		return nil, err
+	}
+	sec := &models.Section{
+		QuizID:           quizID,
+		Title:            req.Title,
+		Instructions:     req.Instructions,
+		Position:         pos,
+		TimeLimitSeconds: req.TimeLimitSeconds,
+		PickCount:        req.PickCount,
+	}
+	return sec, s.db.Create(sec).Error
+}
+
+var ErrSectionNotFound = errors.New("section not found")
+
+// UpdateSection replaces a section's title, instructions, time limit and
+// pick count. Attempts in progress keep the questions already drawn.
+func (s *Service) UpdateSection(quizID, sectionID uint, req UpdateSectionReq) (*models.Section, error) {
+	var sec models.Section
+	if err := s.db.Where("id = ? AND quiz_id = ?", sectionID, quizID).First(&sec).Error; err != nil {
+		return nil, ErrSectionNotFound
+	}
+	sec.Title, sec.Instructions = req.Title, req.Instructions
+	sec.TimeLimitSeconds, sec.PickCount = req.TimeLimitSeconds, req.PickCount
+	if err := s.db.Model(&sec).Updates(map[string]any{
+		"title": sec.Title, "instructions": sec.Instructions,
+		"time_limit_seconds": sec.TimeLimitSeconds, "pick_count": sec.PickCount,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &sec, nil
+}
+
+// DeleteSection removes a section. Its questions stay in the quiz, outside
+// any section.
+func (s *Service) DeleteSection(quizID, sectionID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var sec models.Section
+		if err := tx.Where("id = ? AND quiz_id = ?", sectionID, quizID).First(&sec).Error; err != nil {
+			return ErrSectionNotFound
+		}
+		if err := tx.Model(&models.Question{}).Where("section_id = ?", sec.ID).
+			Update("section_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&sec).Error
+	})
+}
 
+// ReorderSections sets section positions to match ids. The list must
+// contain every section of the quiz exactly once; the update is atomic.
+func (s *Service) ReorderSections(quizID uint, ids []uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var existing []uint
+		if err := tx.Model(&models.Section{}).Where("quiz_id = ?", quizID).
+			Pluck("id", &existing).Error; err != nil {
+			return err
+		}
+		if !samePermutation(existing, ids) {
+			return errors.New("section_ids must list every section of the quiz exactly once")
+		}
+		for pos, id := range ids {
+			if err := tx.Model(&models.Section{}).Where("id = ?", id).
+				Update("position", pos).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MoveQuestion assigns a question to a section of the same quiz,
+// or removes it from its section when sectionID is nil.
+func (s *Service) MoveQuestion(quizID, questionID uint, sectionID *uint) error {
+	var q models.Question
+	if err := s.db.Where("id = ? AND quiz_id = ?", questionID, quizID).First(&q).Error; err != nil {
+		return fmt.Errorf("question %d not found in quiz %d", questionID, quizID)
+	}
+	if err := CheckSection(s.db, quizID, sectionID); err != nil {
+		return err
+	}
+	return s.db.Model(&q).Update("section_id", sectionID).Error
+}
+
+// CheckSection returns an error unless sectionID is nil or one of the
+// quiz's sections. It is shared with question bank links.
+func CheckSection(db *gorm.DB, quizID uint, sectionID *uint) error {
+	if sectionID == nil {
+		return nil
+	}
+	var n int64
+	if err := db.Model(&models.Section{}).
+		Where("id = ? AND quiz_id = ?", *sectionID, quizID).Count(&n).Error; err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("section %d does not belong to quiz %d", *sectionID, quizID)
+	}
+	return nil
+}
+
+// ValidateQuestion enforces the per-type rules for question content.
+// It is shared by quiz questions and question bank entries.
+func ValidateQuestion(qt models.QuestionType, wordLimit *int, opts []CreateQuestionOption) error {
 	switch qt {
 	case models.QText:
-		if len(req.Options) > 0 {
-			return nil, errors.New("text questions must not have options")
+		if len(opts) > 0 {
+			return errors.New("text questions must not have options")
 		}
-		if req.WordLimit == nil || *req.WordLimit <= 0 || *req.WordLimit > 300 {
-			return nil, errors.New("text questions require word_limit in 1..300")
+		if wordLimit == nil || *wordLimit <= 0 || *wordLimit > 300 {
+			return errors.New("text questions require word_limit in 1..300")
 		}
 	case models.QSingle, models.QMultiple:
-		if len(req.Options) < 2 {
-			return nil, errors.New("choice questions need at least 2 options")
+		if len(opts) < 2 {
+			return errors.New("choice questions need at least 2 options")
 		}
 		corr := 0
-		for _, o := range req.Options {
+		for _, o := range opts {
 			if o.IsCorrect != nil && *o.IsCorrect {
 				corr++
 			}
 		}
 		if qt == models.QSingle && corr != 1 {
-			return nil, errors.New("single choice requires exactly 1 correct option")
+			return errors.New("single choice requires exactly 1 correct option")
 		}
 		if qt == models.QMultiple && corr < 1 {
-			return nil, errors.New("multiple choice requires >=1 correct option")
+			return errors.New("multiple choice requires >=1 correct option")
 		}
 	default:
-		return nil, errors.New("unknown question type")
+		return errors.New("unknown question type")
+	}
+	return nil
+}
+
+// AddQuestion validates per type, then writes Question + Options
+func (s *Service) AddQuestion(quizID uint, req CreateQuestionReq) (*models.Question, error) {
+	qt := models.QuestionType(req.Type)
+	if err := CheckSection(s.db, quizID, req.SectionID); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateQuestion(qt, req.WordLimit, req.Options); err != nil {
+		return nil, err
 	}
 
 	q := &models.Question{
-		QuizID:    quizID,
-		Text:      req.Text,
-		Type:      qt,
-		WordLimit: req.WordLimit,
+		QuizID:      quizID,
+		SectionID:   req.SectionID,
+		Text:        req.Text,
+		Type:        qt,
+		WordLimit:   req.WordLimit,
+		Explanation: req.Explanation,
+	}
+	if req.Difficulty != nil {
+		q.Difficulty = *req.Difficulty
 	}
-	if err := s.db.Create(q).Error; err != nil {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		return InsertQuestion(tx, q, req.Options)
+	})
+	if err != nil {
 		return nil, err
 	}
+	return q, nil
+}
+
+// InsertQuestion appends an already validated question with its options
+// to the end of its quiz and records its first revision.
+func InsertQuestion(tx *gorm.DB, q *models.Question, opts []CreateQuestionOption) error {
+	pos, err := NextPosition(tx.Model(&models.Question{}).Where("quiz_id = ?", q.QuizID))
+	if err != nil {
+		return err
+	}
+	q.Position = pos
+	if err := tx.Create(q).Error; err != nil {
+		return err
+	}
 
 	// insert options for choice questions
-	for _, o := range req.Options {
+	for i, o := range opts {
 		isCorr := o.IsCorrect != nil && *o.IsCorrect
-		op := &models.Option{QuestionID: q.ID, Text: o.Text, IsCorrect: isCorr}
-		if err := s.db.Create(op).Error; err != nil {
-			return nil, err
+		op := &models.Option{QuestionID: q.ID, Position: i, Text: o.Text, IsCorrect: isCorr}
+		if err := tx.Create(op).Error; err != nil {
+			return err
 		}
 	}
-	return q, nil
+	_, err = revisions.Record(tx, q)
+	return err
+}
+
+// UpdateQuestion edits a question's content and records a new revision.
+// Options that keep their text and correctness are kept (only their
+// position changes); changed options are replaced by new rows linked to
+// the old ones, and options left out are soft-deleted. Past answers keep
+// pointing at the rows and revision they were given against.
+func (s *Service) UpdateQuestion(quizID, questionID uint, req UpdateQuestionReq) (*models.Question, error) {
+	qt := models.QuestionType(req.Type)
+	opts := make([]CreateQuestionOption, 0, len(req.Options))
+	for _, o := range req.Options {
+		opts = append(opts, o.CreateQuestionOption)
+	}
+	if err := ValidateQuestion(qt, req.WordLimit, opts); err != nil {
+		return nil, err
+	}
+
+	var q models.Question
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND quiz_id = ?", questionID, quizID).First(&q).Error; err != nil {
+			return fmt.Errorf("question %d not found in quiz %d", questionID, quizID)
+		}
+		if q.BankQuestionID != nil {
+			return fmt.Errorf("question is linked to bank question %d; edit it in the bank", *q.BankQuestionID)
+		}
+		// questions created before revisions existed get a baseline first
+		if q.RevisionID == nil {
+			if _, err := revisions.Record(tx, &q); err != nil {
+				return err
+			}
+		}
+
+		var current []models.Option
+		if err := tx.Where("question_id = ?", q.ID).Find(&current).Error; err != nil {
+			return err
+		}
+		byID := make(map[uint]models.Option, len(current))
+		for _, o := range current {
+			byID[o.ID] = o
+		}
+		kept := map[uint]bool{}
+		for i, o := range req.Options {
+			isCorr := o.IsCorrect != nil && *o.IsCorrect
+			var origin *uint
+			if o.ID != nil {
+				old, ok := byID[*o.ID]
+				if !ok || kept[old.ID] {
+					return fmt.Errorf("option %d invalid for question %d", *o.ID, q.ID)
+				}
+				kept[old.ID] = true
+				if old.Text == o.Text && old.IsCorrect == isCorr {
+					if err := tx.Model(&old).Update("position", i).Error; err != nil {
+						return err
+					}
+					continue
+				}
+				if err := tx.Delete(&old).Error; err != nil {
+					return err
+				}
+				key := old.Key()
+				origin = &key
+			}
+			op := &models.Option{QuestionID: q.ID, Position: i, Text: o.Text, IsCorrect: isCorr, OriginID: origin}
+			if err := tx.Create(op).Error; err != nil {
+				return err
+			}
+		}
+		for _, old := range current {
+			if !kept[old.ID] {
+				if err := tx.Delete(&old).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		q.Text, q.Type, q.WordLimit, q.Explanation = req.Text, qt, req.WordLimit, req.Explanation
+		if req.Difficulty != nil {
+			q.Difficulty = *req.Difficulty
+		}
+		if err := tx.Model(&q).Updates(map[string]any{
+			"text": q.Text, "type": q.Type, "word_limit": q.WordLimit, "explanation": q.Explanation,
+			"difficulty": q.Difficulty,
+		}).Error; err != nil {
+			return err
+		}
+		_, err := revisions.Record(tx, &q)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// ReorderQuestions applies a new question order and/or per-question option
+// orders in one transaction. Each list must be a complete permutation of the
+// current rows, so a stale client can't silently drop items from the order.
+func (s *Service) ReorderQuestions(quizID uint, req ReorderQuestionsReq) error {
+	if len(req.QuestionIDs) == 0 && len(req.OptionOrders) == 0 {
+		return errors.New("nothing to reorder")
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var qids []uint
+		if err := tx.Model(&models.Question{}).Where("quiz_id = ?", quizID).
+			Pluck("id", &qids).Error; err != nil {
+			return err
+		}
+		if len(req.QuestionIDs) > 0 {
+			if !samePermutation(qids, req.QuestionIDs) {
+				return errors.New("question_ids must list every question of the quiz exactly once")
+			}
+			for pos, id := range req.QuestionIDs {
+				if err := tx.Model(&models.Question{}).Where("id = ?", id).
+					Update("position", pos).Error; err != nil {
+					return err
+				}
+			}
+		}
+		for qid, order := range req.OptionOrders {
+			if !slices.Contains(qids, qid) {
+				return fmt.Errorf("question %d does not belong to quiz", qid)
+			}
+			var oids []uint
+			if err := tx.Model(&models.Option{}).Where("question_id = ?", qid).
+				Pluck("id", &oids).Error; err != nil {
+				return err
+			}
+			if !samePermutation(oids, order) {
+				return fmt.Errorf("option order for question %d must list every option exactly once", qid)
+			}
+			for pos, id := range order {
+				if err := tx.Model(&models.Option{}).Where("id = ?", id).
+					Update("position", pos).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
 }
 
-// GetPublicQuestions returns questions + options without leaking answers
-func (s *Service) GetPublicQuestions(quizID uint) ([]PublicQuestion, error) {
+// GetPublicQuestions returns the quiz's sections and questions + options
+// without leaking answers. Explanations are withheld as well since they
+// usually reveal the answer. Sections with a pick count serve a random
+// subset of their questions, drawn once per attempt. Adaptive quizzes are only
+// served one question at a time (see NextAdaptive).
+// Fetching the questions starts (or resumes) the user's attempt, which is
+// used to measure completion time; pass userID 0 to skip that.
+func (s *Service) GetPublicQuestions(quizID, userID uint) (*PublicQuiz, error) {
+	quiz, err := s.checkAccess(quizID, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if quiz.AdaptiveLength != nil {
+		return nil, ErrAdaptive
+	}
+	var secs []models.Section
+	if err := s.db.Scopes(ByPosition).Where("quiz_id = ?", quizID).Find(&secs).Error; err != nil {
+		return nil, err
+	}
 	var qs []models.Question
-	if err := s.db.Preload("Options").Where("quiz_id = ?", quizID).Find(&qs).Error; err != nil {
+	if err := s.db.Preload("Options", ByPosition).Scopes(ByPosition).
+		Where("quiz_id = ?", quizID).Find(&qs).Error; err != nil {
 		return nil, err
 	}
-	out := make([]PublicQuestion, 0, len(qs))
+
+	out := &PublicQuiz{
+		QuizID:            quizID,
+		WrongMark:         quiz.WrongMark,
+		ConfidenceMarking: quiz.ConfidenceMarking,
+		RequireComplete:   quiz.RequireComplete,
+		Sections:          make([]PublicSection, 0, len(secs)),
+		Questions:         []PublicQuestion{},
+	}
+	var att *models.Attempt
+	if userID != 0 && len(qs) > 0 {
+		if att, err = s.startAttempt(quizID, userID); err != nil {
+			return nil, err
+		}
+		out.AttemptID = att.ID
+	}
+	bySection := map[uint][]PublicQuestion{}
 	for _, q := range qs {
-		pq := PublicQuestion{
-			ID:        q.ID,
-			Text:      q.Text,
-			Type:      string(q.Type),
-			WordLimit: q.WordLimit,
+		pq := ToPublicQuestion(q)
+		if q.SectionID == nil {
+			out.Questions = append(out.Questions, pq)
+			continue
 		}
-		for _, op := range q.Options {
-			pq.Options = append(pq.Options, PublicOption{ID: op.ID, Text: op.Text})
+		bySection[*q.SectionID] = append(bySection[*q.SectionID], pq)
+	}
+	var picked []uint
+	for _, sec := range secs {
+		pqs := bySection[sec.ID]
+		if sec.PickCount != nil {
+			if att != nil && att.PickedQuestionIDs != nil {
+				pqs = slices.DeleteFunc(pqs, func(pq PublicQuestion) bool {
+					return !slices.Contains(att.PickedQuestionIDs, pq.ID)
+				})
+			} else {
+				pqs = pickN(pqs, *sec.PickCount)
+				for _, pq := range pqs {
+					picked = append(picked, pq.ID)
+				}
+			}
+		}
+		if pqs == nil {
+			pqs = []PublicQuestion{}
+		}
+		out.Sections = append(out.Sections, PublicSection{
+			ID:               sec.ID,
+			Title:            sec.Title,
+			Instructions:     sec.Instructions,
+			InstructionsHTML: richtext.Render(sec.Instructions),
+			TimeLimitSeconds: sec.TimeLimitSeconds,
+			Questions:        pqs,
+		})
+	}
+	if att != nil && picked != nil {
+		att.PickedQuestionIDs = picked
+		if err := s.db.Model(att).Select("picked_question_ids").Updates(att).Error; err != nil {
+			return nil, err
 		}
-		out = append(out, pq)
 	}
 	return out, nil
 }
 
+// startAttempt returns the user's open attempt for the quiz, creating one if needed.
+func (s *Service) startAttempt(quizID, userID uint) (*models.Attempt, error) {
+	var att models.Attempt
+	res := s.db.Where("quiz_id = ? AND user_id = ? AND submitted_at IS NULL", quizID, userID).
+		Order("id desc").Limit(1).Find(&att)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected > 0 {
+		return &att, nil
+	}
+	att = models.Attempt{QuizID: quizID, UserID: userID, StartedAt: time.Now()}
+	return &att, s.db.Create(&att).Error
+}
+
+// ToPublicQuestion renders q for quiz takers, without correct answers or
+// the explanation.
+func ToPublicQuestion(q models.Question) PublicQuestion {
+	pq := PublicQuestion{
+		ID:        q.ID,
+		Position:  q.Position,
+		Text:      q.Text,
+		TextHTML:  richtext.Render(q.Text),
+		Type:      string(q.Type),
+		WordLimit: q.WordLimit,
+	}
+	for _, op := range q.Options {
+		pq.Options = append(pq.Options, PublicOption{
+			ID:       op.ID,
+			Text:     op.Text,
+			TextHTML: richtext.Render(op.Text),
+		})
+	}
+	return pq
+}
+
 // --- Submission & scoring ---
 
-// SubmitAndScore persists a submission + answers (transaction) and returns (score,total).
+// SubmitAndScore persists a submission + answers (transaction) and returns the score,
+// including per-section subtotals. The user's open attempt, if any, is closed
+// and used to record how long the quiz took, the leaderboards are updated and
+// a certificate is issued if the quiz's pass threshold is met.
 // Policy: auto-grade only single/multiple; text is stored but not counted in "total".
-func (s *Service) SubmitAndScore(quizID uint, req SubmitReq) (*models.Submission, int, int, error) {
+// Choice questions the learner was served but didn't answer count towards
+// "total" and score 0; quizzes that require complete submissions reject them
+// with an IncompleteError instead. Each question may be answered only once.
+// Graded answers earn marks under the quiz's marking rules.
+// Adaptive quizzes can't be submitted in one go (see AnswerAdaptive).
+func (s *Service) SubmitAndScore(quizID, userID uint, req SubmitReq) (*models.Submission, *ScoreResp, error) {
+	return s.submitAndScore(quizID, userID, req, nil)
+}
+
+// submitAndScore implements SubmitAndScore. ab is the final ability
+// estimate of an adaptive attempt, recorded with the submission.
+func (s *Service) submitAndScore(quizID, userID uint, req SubmitReq, ab *ability) (*models.Submission, *ScoreResp, error) {
+	now := time.Now()
+	// a code is only redeemed once everything else allows the submit, and
+	// inside its transaction, so a failed submit doesn't use it up
+	quiz, err := s.checkAccessToRedeem(quizID, userID, now, req.AccessCode != "")
+	if err != nil {
+		return nil, nil, err
+	}
+	if quiz.AdaptiveLength != nil && ab == nil {
+		return nil, nil, ErrAdaptive
+	}
 	// Load all quiz questions + their options once.
 	var qs []models.Question
-	if err := s.db.Preload("Options").Where("quiz_id = ?", quizID).Find(&qs).Error; err != nil {
-		return nil, 0, 0, err
+	if err := s.db.Preload("Options", ByPosition).Scopes(ByPosition).
+		Where("quiz_id = ?", quizID).Find(&qs).Error; err != nil {
+		return nil, nil, err
 	}
 	if len(qs) == 0 {
-		return nil, 0, 0, fmt.Errorf("quiz %d not found or has no questions", quizID)
+		return nil, nil, fmt.Errorf("quiz %d not found or has no questions", quizID)
+	}
+	var secs []models.Section
+	if err := s.db.Scopes(ByPosition).Where("quiz_id = ?", quizID).Find(&secs).Error; err != nil {
+		return nil, nil, err
+	}
+	secScores := make(map[uint]*SectionScore, len(secs))
+	for _, sec := range secs {
+		secScores[sec.ID] = &SectionScore{SectionID: sec.ID, Title: sec.Title}
 	}
 
 	// Build lookup maps per question
@@ -137,96 +833,477 @@ func (s *Service) SubmitAndScore(quizID uint, req SubmitReq) (*models.Submission
 	for _, q := range qs {
 		qByID[q.ID] = q
 	}
+	picks := pickSections(secs)
 
-	sub := &models.Submission{QuizID: quizID}
-	score, total := 0, 0
+	sub := &models.Submission{QuizID: quizID, UserID: userID}
+	if ab != nil {
+		sub.Ability, sub.AbilitySE = &ab.theta, &ab.se
+	}
+	score, total, marks := 0, 0, 0.0
+	bd := Breakdown{}
+	if quiz.ConfidenceMarking {
+		bd.ByConfidence = map[models.Confidence]*ConfidenceTally{}
+	}
+	answered := map[uint]bool{}
+	skippedIDs := []uint{}
+	var evs []events.Event
 
 	// Use a DB transaction to keep submission + answers atomic.
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if req.AccessCode != "" {
+			if err := access.Redeem(tx, quizID, userID, req.AccessCode, now); err != nil {
+				return err
+			}
+		}
 		if err := tx.Create(sub).Error; err != nil {
 			return err
 		}
+		att, err := closeAttempt(tx, sub)
+		if err != nil {
+			return err
+		}
 
 		for _, a := range req.Answers {
 			q, ok := qByID[a.QuestionID]
 			if !ok {
 				return fmt.Errorf("question %d does not belong to quiz", a.QuestionID)
 			}
+			// adaptive attempts are served item by item, not by draw
+			if ab == nil && !drawn(q, picks, att) {
+				return fmt.Errorf("question %d was not drawn for this attempt", q.ID)
+			}
 
-			ans := &models.Answer{SubmissionID: sub.ID, QuestionID: q.ID}
-
-			switch q.Type {
-			case models.QSingle:
-				if a.SelectedOptionID == nil {
-					return errors.New("single choice requires selected_option_id")
-				}
-				// option must belong to the question
-				if !containsOptionID(q.Options, *a.SelectedOptionID) {
-					return fmt.Errorf("option %d invalid for question %d", *a.SelectedOptionID, q.ID)
-				}
-				if err := tx.Create(ans).Error; err != nil {
-					return err
-				}
-				if err := tx.Create(&models.AnswerOption{
-					AnswerID: ans.ID, OptionID: *a.SelectedOptionID,
-				}).Error; err != nil {
+			g, err := grade(q, a)
+			if err != nil {
+				return err
+			}
+			if g.gradable && quiz.ConfidenceMarking && a.Confidence == nil {
+				return fmt.Errorf("question %d needs a confidence (low, medium or high)", q.ID)
+			}
+			if answered[q.ID] {
+				return fmt.Errorf("question %d is answered more than once", q.ID)
+			}
+			answered[q.ID] = true
+			ans := &models.Answer{
+				SubmissionID:     sub.ID,
+				QuestionID:       q.ID,
+				BankVersionID:    q.BankVersionID,
+				RevisionID:       q.RevisionID,
+				GradedRevisionID: q.RevisionID,
+				TextAnswer:       g.text,
+				Confidence:       a.Confidence,
+			}
+			m := quiz.Marks(g.correct, a.Confidence)
+			if g.gradable {
+				ans.IsCorrect, ans.Marks = &g.correct, &m
+			}
+			if err := tx.Create(ans).Error; err != nil {
+				return err
+			}
+			for _, oid := range g.optionIDs {
+				if err := tx.Create(&models.AnswerOption{AnswerID: ans.ID, OptionID: oid}).Error; err != nil {
 					return err
 				}
-				total++
-				if isCorrectSingle(q.Options, *a.SelectedOptionID) {
-					score++
-				}
+			}
 
-			case models.QMultiple:
-				if len(a.SelectedOptionIDs) == 0 {
-					return errors.New("multiple choice requires selected_option_ids")
+			// text answers are not auto-graded; don't increment total
+			if !g.gradable {
+				continue
+			}
+			total++
+			marks += m
+			if g.correct {
+				score++
+				bd.Correct++
+			} else {
+				bd.Wrong++
+				bd.WrongMarks += m
+			}
+			if bd.ByConfidence != nil {
+				t := bd.ByConfidence[*a.Confidence]
+				if t == nil {
+					t = &ConfidenceTally{}
+					bd.ByConfidence[*a.Confidence] = t
 				}
-				// validate all options belong to the question (and dedupe)
-				dedup := dedupUint(a.SelectedOptionIDs)
-				for _, oid := range dedup {
-					if !containsOptionID(q.Options, oid) {
-						return fmt.Errorf("option %d invalid for question %d", oid, q.ID)
-					}
+				t.Answered++
+				t.Marks += m
+				if g.correct {
+					t.Correct++
 				}
-				if err := tx.Create(ans).Error; err != nil {
-					return err
-				}
-				for _, oid := range dedup {
-					if err := tx.Create(&models.AnswerOption{AnswerID: ans.ID, OptionID: oid}).Error; err != nil {
-						return err
+			}
+			if q.SectionID != nil {
+				if ss, ok := secScores[*q.SectionID]; ok {
+					ss.Total++
+					if g.correct {
+						ss.Score++
 					}
 				}
-				total++
-				if exactSetMatch(correctIDs(q.Options), dedup) {
-					score++
-				}
-
-			case models.QText:
-				if q.WordLimit == nil {
-					return errors.New("text question missing word_limit")
-				}
-				if a.TextAnswer == nil {
-					return errors.New("text question requires text_answer")
-				}
-				if runeCount(*a.TextAnswer) > *q.WordLimit {
-					return fmt.Errorf("text answer exceeds word_limit %d", *q.WordLimit)
+			}
+		}
+		// adaptive attempts only count the questions that were served
+		if ab == nil {
+			for _, q := range unanswered(qs, secs, att, answered) {
+				skippedIDs = append(skippedIDs, q.ID)
+				if q.Type == models.QText {
+					continue
 				}
-				ans.TextAnswer = a.TextAnswer
-				if err := tx.Create(ans).Error; err != nil {
-					return err
+				bd.Skipped++
+				if q.SectionID != nil {
+					if ss, ok := secScores[*q.SectionID]; ok {
+						ss.Total++
+					}
 				}
-				// not auto-graded; don't increment total
+			}
+			if quiz.RequireComplete && len(skippedIDs) > 0 {
+				return &IncompleteError{QuestionIDs: skippedIDs}
+			}
+		}
+		sub.Score, sub.Total, sub.Skipped = score, total+bd.Skipped, bd.Skipped
+		sub.Marks, sub.MaxMarks = marks, float64(sub.Total)*quiz.MaxMarks()
+		sub.Percent = models.Percent(sub.Marks, sub.MaxMarks)
+		if err := tx.Model(sub).Updates(map[string]any{
+			"score": sub.Score, "total": sub.Total, "skipped": sub.Skipped,
+			"marks": sub.Marks, "max_marks": sub.MaxMarks, "percent": sub.Percent,
+		}).Error; err != nil {
+			return err
+		}
+		if err := leaderboard.Record(tx, sub); err != nil {
+			return err
+		}
+		if err := certificates.Sync(tx, sub); err != nil {
+			return err
+		}
+		// choice questions are graded as part of the submit, so both events fire now
+		evs = []events.Event{
+			events.Submission(events.SubmissionCreated, sub),
+			events.Submission(events.SubmissionGraded, sub),
+		}
+		for _, ev := range evs {
+			if err := events.Record(tx, s.events, ev); err != nil {
+				return err
 			}
 		}
 		return nil
 	})
 	if err != nil {
-		return nil, 0, 0, err
+		return nil, nil, err
+	}
+	for _, ev := range evs {
+		s.events.Publish(ev)
+	}
+
+	resp := &ScoreResp{
+		Score: sub.Score, Total: sub.Total, Marks: sub.Marks, MaxMarks: sub.MaxMarks, Percent: sub.Percent,
+		Breakdown: bd, SkippedQuestionIDs: skippedIDs, Ability: sub.Ability, AbilitySE: sub.AbilitySE,
+	}
+	for _, sec := range secs {
+		if ss := secScores[sec.ID]; ss.Total > 0 {
+			resp.Sections = append(resp.Sections, *ss)
+		}
+	}
+	return sub, resp, nil
+}
+
+// closeAttempt links the submitter's open attempt to sub, records the
+// elapsed time on the submission and returns the attempt (nil if none).
+// The attempt is closed with a conditional update, so when two submits
+// race for it the loser gets ErrAttemptClosed and its transaction rolls
+// back instead of both being scored.
+func closeAttempt(tx *gorm.DB, sub *models.Submission) (*models.Attempt, error) {
+	if sub.UserID == 0 {
+		return nil, nil
+	}
+	var att models.Attempt
+	res := tx.Where("quiz_id = ? AND user_id = ? AND submitted_at IS NULL", sub.QuizID, sub.UserID).
+		Order("id desc").Limit(1).Find(&att)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return nil, res.Error
+	}
+	now := time.Now()
+	res = tx.Model(&models.Attempt{}).Where("id = ? AND submitted_at IS NULL", att.ID).
+		Updates(map[string]any{"submitted_at": now, "submission_id": sub.ID})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrAttemptClosed
+	}
+	att.SubmittedAt, att.SubmissionID = &now, &sub.ID
+	secs := int(now.Sub(att.StartedAt).Seconds())
+	sub.DurationSeconds = &secs
+	return &att, tx.Model(sub).Update("duration_seconds", secs).Error
+}
+
+// IncompleteError rejects a partial submission to a quiz that requires
+// every question to be answered.
+type IncompleteError struct{ QuestionIDs []uint }
+
+func (e *IncompleteError) Error() string {
+	return fmt.Sprintf("%d question(s) are unanswered and this quiz requires all of them", len(e.QuestionIDs))
+}
+
+// unanswered returns the questions the learner was served but didn't
+// answer. In sections with a pick count only the attempt's draw was
+// served; without a recorded draw none of their questions count.
+func unanswered(qs []models.Question, secs []models.Section, att *models.Attempt, answered map[uint]bool) []models.Question {
+	picks := pickSections(secs)
+	var out []models.Question
+	for _, q := range qs {
+		if answered[q.ID] || !drawn(q, picks, att) {
+			continue
+		}
+		out = append(out, q)
+	}
+	return out
+}
+
+// pickSections returns the IDs of the sections with a pick count.
+func pickSections(secs []models.Section) map[uint]bool {
+	picks := map[uint]bool{}
+	for _, sec := range secs {
+		if sec.PickCount != nil {
+			picks[sec.ID] = true
+		}
+	}
+	return picks
+}
+
+// drawn reports whether q was served in att: questions outside pick
+// sections always are, the rest only if they are in the attempt's draw.
+func drawn(q models.Question, picks map[uint]bool, att *models.Attempt) bool {
+	if q.SectionID == nil || !picks[*q.SectionID] {
+		return true
+	}
+	return att != nil && slices.Contains(att.PickedQuestionIDs, q.ID)
+}
+
+// --- Draft answers ---
+
+var (
+	ErrAttemptNotFound = errors.New("attempt not found")
+	ErrAttemptClosed   = errors.New("attempt has already been submitted")
+)
+
+// ownAttempt loads one of userID's attempts; other users' attempts are
+// reported as not found.
+func (s *Service) ownAttempt(attemptID, userID uint) (*models.Attempt, error) {
+	var att models.Attempt
+	if err := s.db.Where("id = ? AND user_id = ?", attemptID, userID).First(&att).Error; err != nil {
+		return nil, ErrAttemptNotFound
+	}
+	return &att, nil
+}
+
+// GetAttempt returns an attempt with the answers saved so far.
+func (s *Service) GetAttempt(attemptID, userID uint) (*AttemptResp, error) {
+	att, err := s.ownAttempt(attemptID, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := &AttemptResp{
+		ID: att.ID, QuizID: att.QuizID, StartedAt: att.StartedAt,
+		SubmittedAt: att.SubmittedAt, SubmissionID: att.SubmissionID,
+		Answers: []models.DraftAnswer{},
+	}
+	return out, s.db.Where("attempt_id = ?", att.ID).Order("question_id").Find(&out.Answers).Error
+}
+
+// SaveDraft stores the answer to one question of an open attempt. The
+// answer is validated as on submit but not graded.
+func (s *Service) SaveDraft(attemptID, questionID, userID uint, req DraftAnswerReq) (*models.DraftAnswer, error) {
+	att, err := s.ownAttempt(attemptID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if att.SubmittedAt != nil {
+		return nil, ErrAttemptClosed
+	}
+	quiz, err := s.checkAccess(att.QuizID, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if quiz.AdaptiveLength != nil {
+		return nil, ErrAdaptive
+	}
+	var q models.Question
+	if err := s.db.Preload("Options").Where("id = ? AND quiz_id = ?", questionID, att.QuizID).
+		First(&q).Error; err != nil {
+		return nil, fmt.Errorf("question %d does not belong to quiz", questionID)
+	}
+	a := SubmitAnswer{
+		QuestionID:        questionID,
+		SelectedOptionID:  req.SelectedOptionID,
+		SelectedOptionIDs: req.SelectedOptionIDs,
+		TextAnswer:        req.TextAnswer,
+		Confidence:        req.Confidence,
+	}
+	g, err := grade(q, a)
+	if err != nil {
+		return nil, err
+	}
+	return upsertDraft(s.db, att.ID, q, a, g)
+}
+
+// upsertDraft stores an answer that passed grade as the attempt's draft
+// for q, replacing any earlier one.
+func upsertDraft(db *gorm.DB, attemptID uint, q models.Question, a SubmitAnswer, g graded) (*models.DraftAnswer, error) {
+	draft := models.DraftAnswer{AttemptID: attemptID, QuestionID: q.ID, TextAnswer: g.text, Confidence: a.Confidence}
+	switch q.Type {
+	case models.QSingle:
+		draft.SelectedOptionID = a.SelectedOptionID
+	case models.QMultiple:
+		draft.SelectedOptionIDs = g.optionIDs
+	}
+	err := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "attempt_id"}, {Name: "question_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"selected_option_id", "selected_option_ids", "text_answer", "confidence", "updated_at",
+		}),
+	}).Create(&draft).Error
+	if err != nil {
+		return nil, err
+	}
+	return &draft, nil
+}
+
+// DiscardDraft clears the saved answer to one question of an open attempt.
+func (s *Service) DiscardDraft(attemptID, questionID, userID uint) error {
+	att, err := s.ownAttempt(attemptID, userID)
+	if err != nil {
+		return err
+	}
+	if att.SubmittedAt != nil {
+		return ErrAttemptClosed
+	}
+	var quiz models.Quiz
+	if err := s.db.First(&quiz, att.QuizID).Error; err != nil {
+		return err
+	}
+	if quiz.AdaptiveLength != nil {
+		return ErrAdaptive
+	}
+	return s.db.Where("attempt_id = ? AND question_id = ?", att.ID, questionID).Delete(&models.DraftAnswer{}).Error
+}
+
+// FinalizeAttempt submits the attempt's saved answers through
+// SubmitAndScore. The drafts are kept as a record of the attempt. An
+// adaptive attempt ends early, scored on the questions answered so far.
+func (s *Service) FinalizeAttempt(attemptID, userID uint) (*models.Submission, *ScoreResp, error) {
+	att, err := s.ownAttempt(attemptID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if att.SubmittedAt != nil {
+		return nil, nil, ErrAttemptClosed
+	}
+	ab, err := s.attemptAbility(att.ID)
+	if err != nil {
+		return nil, nil, err
 	}
-	return sub, score, total, nil
+	return s.finalize(att, ab)
+}
+
+// finalize submits att's drafts, with ab as its ability estimate if the
+// attempt was adaptive.
+func (s *Service) finalize(att *models.Attempt, ab *ability) (*models.Submission, *ScoreResp, error) {
+	var drafts []models.DraftAnswer
+	if err := s.db.Where("attempt_id = ?", att.ID).Order("id").Find(&drafts).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(drafts) == 0 {
+		return nil, nil, errors.New("no answers have been saved for this attempt")
+	}
+	req := SubmitReq{Answers: make([]SubmitAnswer, len(drafts))}
+	for i, d := range drafts {
+		req.Answers[i] = SubmitAnswer{
+			QuestionID:        d.QuestionID,
+			SelectedOptionID:  d.SelectedOptionID,
+			SelectedOptionIDs: d.SelectedOptionIDs,
+			TextAnswer:        d.TextAnswer,
+			Confidence:        d.Confidence,
+		}
+	}
+	return s.submitAndScore(att.QuizID, att.UserID, req, ab)
+}
+
+// graded is the outcome of validating and grading one answer.
+type graded struct {
+	gradable  bool
+	correct   bool
+	optionIDs []uint  // deduplicated and sorted
+	text      *string // set for text answers
+}
+
+// Grade validates a against q's per-type rules and auto-grades choice
+// questions; text answers are validated but not gradable. It is the check
+// SubmitAndScore applies, for callers that score answers outside a submission.
+func Grade(q models.Question, a SubmitAnswer) (gradable, correct bool, err error) {
+	g, err := grade(q, a)
+	return g.gradable, g.correct, err
+}
+
+// grade applies the per-type answer rules for q and auto-grades choice questions.
+func grade(q models.Question, a SubmitAnswer) (graded, error) {
+	switch q.Type {
+	case models.QSingle:
+		if a.SelectedOptionID == nil {
+			return graded{}, errors.New("single choice requires selected_option_id")
+		}
+		// option must belong to the question
+		if !containsOptionID(q.Options, *a.SelectedOptionID) {
+			return graded{}, fmt.Errorf("option %d invalid for question %d", *a.SelectedOptionID, q.ID)
+		}
+		return graded{
+			gradable:  true,
+			correct:   isCorrectSingle(q.Options, *a.SelectedOptionID),
+			optionIDs: []uint{*a.SelectedOptionID},
+		}, nil
+
+	case models.QMultiple:
+		if len(a.SelectedOptionIDs) == 0 {
+			return graded{}, errors.New("multiple choice requires selected_option_ids")
+		}
+		// validate all options belong to the question (and dedupe)
+		dedup := dedupUint(a.SelectedOptionIDs)
+		for _, oid := range dedup {
+			if !containsOptionID(q.Options, oid) {
+				return graded{}, fmt.Errorf("option %d invalid for question %d", oid, q.ID)
+			}
+		}
+		return graded{
+			gradable:  true,
+			correct:   exactSetMatch(correctIDs(q.Options), dedup),
+			optionIDs: dedup,
+		}, nil
+
+	case models.QText:
+		if q.WordLimit == nil {
+			return graded{}, errors.New("text question missing word_limit")
+		}
+		if a.TextAnswer == nil {
+			return graded{}, errors.New("text question requires text_answer")
+		}
+		if runeCount(*a.TextAnswer) > *q.WordLimit {
+			return graded{}, fmt.Errorf("text answer exceeds word_limit %d", *q.WordLimit)
+		}
+		return graded{text: a.TextAnswer}, nil
+	}
+	return graded{}, fmt.Errorf("question %d has unknown type %q", q.ID, q.Type)
 }
 
 // --- helpers ---
+
+// NextPosition returns one past the highest position in the scoped rows.
+func NextPosition(scoped *gorm.DB) (int, error) {
+	var maxPos *int
+	if err := scoped.Select("MAX(position)").Scan(&maxPos).Error; err != nil {
+		return 0, err
+	}
+	if maxPos == nil {
+		return 0, nil
+	}
+	return *maxPos + 1, nil
+}
 func exactSetMatch(a, b []uint) bool {
 	if len(a) != len(b) {
 		return false
@@ -281,6 +1358,31 @@ func dedupUint(in []uint) []uint {
 	return out
 }
 
+// samePermutation reports whether b contains exactly the elements of a.
+func samePermutation(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	x, y := slices.Clone(a), slices.Clone(b)
+	slices.Sort(x)
+	slices.Sort(y)
+	return slices.Equal(x, y)
+}
+
+// pickN returns a random n-element subset of in, keeping the original order.
+func pickN[T any](in []T, n int) []T {
+	if n >= len(in) {
+		return in
+	}
+	idx := rand.Perm(len(in))[:n]
+	slices.Sort(idx)
+	out := make([]T, 0, n)
+	for _, i := range idx {
+		out = append(out, in[i])
+	}
+	return out
+}
+
 func runeCount(s string) int {
 	return len([]rune(s))
 }
@@ -1,29 +1,21 @@
 package quizzes_test
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
 	"quizapi/internal/models"
 	"quizapi/internal/quizzes"
+	"quizapi/internal/revisions"
+	"quizapi/internal/testutil"
 )
 
-func memDB(t *testing.T) *gorm.DB {
-	t.Helper()
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	require.NoError(t, err)
-	require.NoError(t, db.AutoMigrate(
-		&models.Quiz{}, &models.Question{}, &models.Option{},
-		&models.Submission{}, &models.Answer{}, &models.AnswerOption{},
-	))
-	return db
-}
-
 func TestMultipleChoice_ExactMatchScores1(t *testing.T) {
-	d := memDB(t)
+	d := testutil.DB(t)
 	svc := quizzes.NewService(d)
 
 	qz, err := svc.CreateQuiz("test")
@@ -39,11 +31,11 @@ func TestMultipleChoice_ExactMatchScores1(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	pub, err := svc.GetPublicQuestions(qz.ID)
+	pub, err := svc.GetPublicQuestions(qz.ID, learner)
 	require.NoError(t, err)
-	require.Len(t, pub, 1)
+	require.Len(t, pub.Questions, 1)
 
-	q := pub[0]
+	q := pub.Questions[0]
 	// Find IDs of the two correct options by text
 	var ids []uint
 	for _, o := range q.Options {
@@ -57,10 +49,498 @@ func TestMultipleChoice_ExactMatchScores1(t *testing.T) {
 		},
 	}
 
-	_, score, total, err := svc.SubmitAndScore(qz.ID, req)
+	_, res, err := svc.SubmitAndScore(qz.ID, learner, req)
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Total)
+	require.Equal(t, 1, res.Score)
+}
+
+func TestSections_PickAndSubtotals(t *testing.T) {
+	d := testutil.DB(t)
+	svc := quizzes.NewService(d)
+
+	qz, err := svc.CreateQuiz("sections")
+	require.NoError(t, err)
+	sec, err := svc.CreateSection(qz.ID, quizzes.CreateSectionReq{Title: "Basics", PickCount: ptr(2)})
+	require.NoError(t, err)
+
+	for _, text := range []string{"a", "b", "c"} {
+		_, err = svc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{
+			Text: text, Type: "single", SectionID: &sec.ID,
+			Options: []quizzes.CreateQuestionOption{
+				{Text: "yes", IsCorrect: ptr(true)},
+				{Text: "no", IsCorrect: ptr(false)},
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	pub, err := svc.GetPublicQuestions(qz.ID, learner)
+	require.NoError(t, err)
+	require.Empty(t, pub.Questions)
+	require.Len(t, pub.Sections, 1)
+	require.Len(t, pub.Sections[0].Questions, 2)
+
+	var answers []quizzes.SubmitAnswer
+	for _, q := range pub.Sections[0].Questions {
+		var right models.Option
+		require.NoError(t, d.Where("question_id = ? AND is_correct = ?", q.ID, true).First(&right).Error)
+		answers = append(answers, quizzes.SubmitAnswer{QuestionID: q.ID, SelectedOptionID: &right.ID})
+	}
+	_, res, err := svc.SubmitAndScore(qz.ID, learner, quizzes.SubmitReq{Answers: answers})
+	require.NoError(t, err)
+	require.Len(t, res.Sections, 1)
+	require.Equal(t, sec.ID, res.Sections[0].SectionID)
+	require.Equal(t, 2, res.Sections[0].Score)
+	require.Equal(t, 2, res.Sections[0].Total)
+}
+
+func TestSections_UpdateAndDelete(t *testing.T) {
+	d := testutil.DB(t)
+	svc := quizzes.NewService(d)
+	qz, err := svc.CreateQuiz("sections")
+	require.NoError(t, err)
+	sec, err := svc.CreateSection(qz.ID, quizzes.CreateSectionReq{Title: "Basics", PickCount: ptr(1), TimeLimitSeconds: ptr(60)})
+	require.NoError(t, err)
+	q, err := svc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{Text: "Why?", Type: "text", WordLimit: ptr(50), SectionID: &sec.ID})
+	require.NoError(t, err)
+
+	got, err := svc.UpdateSection(qz.ID, sec.ID, quizzes.UpdateSectionReq{Title: "Warm-up", Instructions: "Relax"})
+	require.NoError(t, err)
+	require.Equal(t, "Warm-up", got.Title)
+	var stored models.Section
+	require.NoError(t, d.First(&stored, sec.ID).Error)
+	require.Equal(t, "Relax", stored.Instructions)
+	require.Nil(t, stored.PickCount)
+	require.Nil(t, stored.TimeLimitSeconds)
+
+	_, err = svc.UpdateSection(qz.ID+1, sec.ID, quizzes.UpdateSectionReq{Title: "x"})
+	require.ErrorIs(t, err, quizzes.ErrSectionNotFound)
+	require.ErrorIs(t, svc.DeleteSection(qz.ID+1, sec.ID), quizzes.ErrSectionNotFound)
+
+	require.NoError(t, svc.DeleteSection(qz.ID, sec.ID))
+	require.ErrorIs(t, d.First(&models.Section{}, sec.ID).Error, gorm.ErrRecordNotFound)
+	var moved models.Question
+	require.NoError(t, d.First(&moved, q.ID).Error)
+	require.Nil(t, moved.SectionID)
+}
+
+func TestReorder_RequiresPermutations(t *testing.T) {
+	d := testutil.DB(t)
+	svc := quizzes.NewService(d)
+	qz, err := svc.CreateQuiz("order")
+	require.NoError(t, err)
+	var secIDs, qIDs []uint
+	for _, title := range []string{"one", "two"} {
+		sec, err := svc.CreateSection(qz.ID, quizzes.CreateSectionReq{Title: title})
+		require.NoError(t, err)
+		secIDs = append(secIDs, sec.ID)
+	}
+	for _, text := range []string{"a", "b"} {
+		q, err := svc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{
+			Text: text, Type: "single",
+			Options: []quizzes.CreateQuestionOption{{Text: "yes", IsCorrect: ptr(true)}, {Text: "no", IsCorrect: ptr(false)}},
+		})
+		require.NoError(t, err)
+		qIDs = append(qIDs, q.ID)
+	}
+	other, err := svc.CreateQuiz("other")
+	require.NoError(t, err)
+	foreign, err := svc.CreateSection(other.ID, quizzes.CreateSectionReq{Title: "elsewhere"})
+	require.NoError(t, err)
+
+	// sections: missing, duplicated and foreign IDs are all rejected
+	require.Error(t, svc.ReorderSections(qz.ID, secIDs[:1]))
+	require.Error(t, svc.ReorderSections(qz.ID, []uint{secIDs[0], secIDs[0]}))
+	require.Error(t, svc.ReorderSections(qz.ID, []uint{secIDs[0], foreign.ID}))
+	require.NoError(t, svc.ReorderSections(qz.ID, []uint{secIDs[1], secIDs[0]}))
+	var first models.Section
+	require.NoError(t, d.Scopes(quizzes.ByPosition).Where("quiz_id = ?", qz.ID).Take(&first).Error)
+	require.Equal(t, "two", first.Title)
+
+	// questions and their options follow the same rule
+	require.Error(t, svc.ReorderQuestions(qz.ID, quizzes.ReorderQuestionsReq{}))
+	require.Error(t, svc.ReorderQuestions(qz.ID, quizzes.ReorderQuestionsReq{QuestionIDs: qIDs[:1]}))
+	require.Error(t, svc.ReorderQuestions(qz.ID, quizzes.ReorderQuestionsReq{QuestionIDs: []uint{qIDs[1], qIDs[1]}}))
+	var opts []uint
+	require.NoError(t, d.Model(&models.Option{}).Where("question_id = ?", qIDs[0]).Order("position").Pluck("id", &opts).Error)
+	require.Error(t, svc.ReorderQuestions(qz.ID, quizzes.ReorderQuestionsReq{OptionOrders: map[uint][]uint{qIDs[0]: opts[:1]}}))
+	require.Error(t, svc.ReorderQuestions(other.ID, quizzes.ReorderQuestionsReq{OptionOrders: map[uint][]uint{qIDs[0]: opts}}))
+	require.NoError(t, svc.ReorderQuestions(qz.ID, quizzes.ReorderQuestionsReq{
+		QuestionIDs:  []uint{qIDs[1], qIDs[0]},
+		OptionOrders: map[uint][]uint{qIDs[0]: {opts[1], opts[0]}},
+	}))
+	var order []uint
+	require.NoError(t, d.Model(&models.Question{}).Scopes(quizzes.ByPosition).Where("quiz_id = ?", qz.ID).Pluck("id", &order).Error)
+	require.Equal(t, []uint{qIDs[1], qIDs[0]}, order)
+	require.NoError(t, d.Model(&models.Option{}).Where("question_id = ?", qIDs[0]).Order("position").Pluck("id", &order).Error)
+	require.Equal(t, []uint{opts[1], opts[0]}, order)
+}
+
+func TestUpdateQuestion_RegradeAgainstNewKey(t *testing.T) {
+	d := testutil.DB(t)
+	svc := quizzes.NewService(d)
+
+	qz, err := svc.CreateQuiz("regrade")
+	require.NoError(t, err)
+	q, err := svc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{
+		Text: "Capital of Australia?", Type: "single",
+		Options: []quizzes.CreateQuestionOption{
+			{Text: "Sydney", IsCorrect: ptr(true)},
+			{Text: "Canberra", IsCorrect: ptr(false)},
+		},
+	})
+	require.NoError(t, err)
+
+	pub, err := svc.GetPublicQuestions(qz.ID, learner)
+	require.NoError(t, err)
+	canberra := pub.Questions[0].Options[1].ID
+	sydney := pub.Questions[0].Options[0].ID
+	sub, res, err := svc.SubmitAndScore(qz.ID, learner, quizzes.SubmitReq{Answers: []quizzes.SubmitAnswer{
+		{QuestionID: q.ID, SelectedOptionID: &canberra},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, 0, res.Score)
+
+	// fix the answer key and reword the correct option
+	_, err = svc.UpdateQuestion(qz.ID, q.ID, quizzes.UpdateQuestionReq{
+		Text: "Capital of Australia?", Type: "single",
+		Options: []quizzes.UpdateQuestionOption{
+			{ID: &sydney, CreateQuestionOption: quizzes.CreateQuestionOption{Text: "Sydney", IsCorrect: ptr(false)}},
+			{ID: &canberra, CreateQuestionOption: quizzes.CreateQuestionOption{Text: "Canberra (ACT)", IsCorrect: ptr(true)}},
+		},
+	})
+	require.NoError(t, err)
+
+	revSvc := revisions.NewService(d)
+	diff, err := revSvc.Diff(qz.ID, q.ID, 1, 2)
+	require.NoError(t, err)
+	require.True(t, diff.AnswerKeyChanged)
+	require.Len(t, diff.Options, 2)
+
+	rg, err := revSvc.Regrade(qz.ID, q.ID, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, rg.Version)
+	require.Equal(t, 1, rg.AnswersChanged)
+
+	var stored models.Submission
+	require.NoError(t, d.First(&stored, sub.ID).Error)
+	require.Equal(t, 1, stored.Score)
+	require.Equal(t, 1, stored.Total)
+}
+
+func TestSchedule_WindowAndExtension(t *testing.T) {
+	d := testutil.DB(t)
+	svc := quizzes.NewService(d)
+	require.NoError(t, d.Create(&models.User{ID: learner, Username: "ada", PasswordHash: "x", Role: models.RoleUser}).Error)
+
+	qz, err := svc.CreateQuiz("closed")
+	require.NoError(t, err)
+	q, err := svc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{Text: "Why?", Type: "text", WordLimit: ptr(50)})
+	require.NoError(t, err)
+
+	past := time.Now().Add(-time.Hour)
+	_, err = svc.SetSchedule(qz.ID, quizzes.ScheduleReq{ClosesAt: &past})
+	require.NoError(t, err)
+
+	_, err = svc.GetPublicQuestions(qz.ID, learner)
+	require.ErrorIs(t, err, quizzes.ErrQuizClosed)
+	closed, n, err := svc.ListQuizzes(1, 10, quizzes.ListFilter{Status: quizzes.StatusClosed, UserID: learner})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n)
+	require.Equal(t, qz.ID, closed[0].ID)
+
+	later := time.Now().Add(time.Hour)
+	_, err = svc.GrantExtension(qz.ID, learner, quizzes.ExtensionReq{ClosesAt: &later, Reason: "sick leave"})
+	require.NoError(t, err)
+	_, _, err = svc.SubmitAndScore(qz.ID, learner, quizzes.SubmitReq{Answers: []quizzes.SubmitAnswer{
+		{QuestionID: q.ID, TextAnswer: ptr("because")},
+	}})
+	require.NoError(t, err)
+	_, err = svc.GetPublicQuestions(qz.ID, learner+1)
+	require.ErrorIs(t, err, quizzes.ErrQuizClosed)
+}
+
+func TestDraftAnswers_ResumeAndFinalize(t *testing.T) {
+	d := testutil.DB(t)
+	svc := quizzes.NewService(d)
+	qz, err := svc.CreateQuiz("drafts")
+	require.NoError(t, err)
+	_, err = svc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{
+		Text: "2+2?", Type: "single",
+		Options: []quizzes.CreateQuestionOption{{Text: "4", IsCorrect: ptr(true)}, {Text: "5", IsCorrect: ptr(false)}},
+	})
+	require.NoError(t, err)
+	_, err = svc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{Text: "Why?", Type: "text", WordLimit: ptr(10)})
+	require.NoError(t, err)
+
+	pub, err := svc.GetPublicQuestions(qz.ID, learner)
+	require.NoError(t, err)
+	choice, text := pub.Questions[0], pub.Questions[1]
+
+	// drafts are validated like submitted answers
+	_, err = svc.SaveDraft(pub.AttemptID, choice.ID, learner, quizzes.DraftAnswerReq{SelectedOptionID: ptr(uint(999))})
+	require.Error(t, err)
+	_, err = svc.SaveDraft(pub.AttemptID, choice.ID, learner+1, quizzes.DraftAnswerReq{SelectedOptionID: &choice.Options[0].ID})
+	require.ErrorIs(t, err, quizzes.ErrAttemptNotFound)
+
+	_, err = svc.SaveDraft(pub.AttemptID, choice.ID, learner, quizzes.DraftAnswerReq{SelectedOptionID: &choice.Options[1].ID})
+	require.NoError(t, err)
+	_, err = svc.SaveDraft(pub.AttemptID, choice.ID, learner, quizzes.DraftAnswerReq{SelectedOptionID: &choice.Options[0].ID})
+	require.NoError(t, err)
+	_, err = svc.SaveDraft(pub.AttemptID, text.ID, learner, quizzes.DraftAnswerReq{TextAnswer: ptr("math")})
+	require.NoError(t, err)
+
+	// resuming returns the same attempt and the latest drafts
+	again, err := svc.GetPublicQuestions(qz.ID, learner)
+	require.NoError(t, err)
+	require.Equal(t, pub.AttemptID, again.AttemptID)
+	att, err := svc.GetAttempt(pub.AttemptID, learner)
+	require.NoError(t, err)
+	require.Len(t, att.Answers, 2)
+	require.Equal(t, choice.Options[0].ID, *att.Answers[0].SelectedOptionID)
+
+	sub, res, err := svc.FinalizeAttempt(pub.AttemptID, learner)
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Score)
+	require.Equal(t, 1, res.Total)
+	att, err = svc.GetAttempt(pub.AttemptID, learner)
+	require.NoError(t, err)
+	require.Equal(t, sub.ID, *att.SubmissionID)
+
+	_, _, err = svc.FinalizeAttempt(pub.AttemptID, learner)
+	require.ErrorIs(t, err, quizzes.ErrAttemptClosed)
+	_, err = svc.SaveDraft(pub.AttemptID, text.ID, learner, quizzes.DraftAnswerReq{TextAnswer: ptr("late")})
+	require.ErrorIs(t, err, quizzes.ErrAttemptClosed)
+}
+
+func TestAdaptive_HarderAfterCorrectAnswers(t *testing.T) {
+	d := testutil.DB(t)
+	svc := quizzes.NewService(d)
+	qz, err := svc.CreateQuiz("adaptive")
+	require.NoError(t, err)
+	difficulty := map[uint]float64{}
+	for _, b := range []float64{-2, -1, 0, 1, 2} {
+		q, err := svc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{
+			Text: "Q?", Type: "single", Difficulty: ptr(b),
+			Options: []quizzes.CreateQuestionOption{{Text: "right", IsCorrect: ptr(true)}, {Text: "wrong", IsCorrect: ptr(false)}},
+		})
+		require.NoError(t, err)
+		difficulty[q.ID] = b
+	}
+	_, err = svc.SetAdaptive(qz.ID, quizzes.AdaptiveReq{Length: ptr(3)})
+	require.NoError(t, err)
+
+	// the fixed form is no longer available
+	_, err = svc.GetPublicQuestions(qz.ID, learner)
+	require.ErrorIs(t, err, quizzes.ErrAdaptive)
+
+	state, err := svc.NextAdaptive(qz.ID, learner)
+	require.NoError(t, err)
+	require.Equal(t, 0.0, difficulty[state.Question.ID])
+	again, err := svc.NextAdaptive(qz.ID, learner)
+	require.NoError(t, err)
+	require.Equal(t, state.Question.ID, again.Question.ID)
+
+	_, err = svc.AnswerAdaptive(qz.ID, learner, quizzes.SubmitAnswer{QuestionID: state.Question.ID + 1, SelectedOptionID: ptr(uint(1))})
+	require.ErrorIs(t, err, quizzes.ErrNotCurrentQuestion)
+
+	// each right answer raises the estimate, so the next question is harder
+	var seen []float64
+	for state.Question != nil {
+		seen = append(seen, difficulty[state.Question.ID])
+		state, err = svc.AnswerAdaptive(qz.ID, learner, quizzes.SubmitAnswer{
+			QuestionID: state.Question.ID, SelectedOptionID: &state.Question.Options[0].ID,
+		})
+		require.NoError(t, err)
+	}
+	require.Equal(t, []float64{0, 1, 2}, seen)
+	require.Equal(t, 3, state.Answered)
+	require.NotNil(t, state.SubmissionID)
+	require.Equal(t, 3, state.Score.Score)
+	require.Equal(t, 3, state.Score.Total)
+	require.Greater(t, *state.Score.Ability, 1.0)
+	require.Less(t, *state.Score.AbilitySE, 1.0)
+}
+
+func TestMarking_NegativeAndConfidence(t *testing.T) {
+	d := testutil.DB(t)
+	svc := quizzes.NewService(d)
+	qz, err := svc.CreateQuiz("marking")
+	require.NoError(t, err)
+	for range 4 {
+		_, err = svc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{Text: "Q?", Type: "single",
+			Options: []quizzes.CreateQuestionOption{{Text: "right", IsCorrect: ptr(true)}, {Text: "wrong", IsCorrect: ptr(false)}}})
+		require.NoError(t, err)
+	}
+	pub, err := svc.GetPublicQuestions(qz.ID, learner)
+	require.NoError(t, err)
+	qs := pub.Questions
+	answer := func(i, opt int, c *models.Confidence) quizzes.SubmitAnswer {
+		return quizzes.SubmitAnswer{QuestionID: qs[i].ID, SelectedOptionID: &qs[i].Options[opt].ID, Confidence: c}
+	}
+
+	// two right, one wrong, one skipped: the skipped question still counts
+	_, err = svc.SetMarking(qz.ID, quizzes.MarkingReq{WrongMark: -0.5})
+	require.NoError(t, err)
+	sub, res, err := svc.SubmitAndScore(qz.ID, learner, quizzes.SubmitReq{Answers: []quizzes.SubmitAnswer{
+		answer(0, 0, nil), answer(1, 0, nil), answer(2, 1, nil),
+	}})
+	require.NoError(t, err)
+	require.Equal(t, 2, res.Score)
+	require.Equal(t, 4, res.Total)
+	require.Equal(t, 1.5, res.Marks)
+	require.Equal(t, 4.0, res.MaxMarks)
+	require.Equal(t, 37, res.Percent)
+	require.Equal(t, quizzes.Breakdown{Correct: 2, Wrong: 1, Skipped: 1, WrongMarks: -0.5}, res.Breakdown)
+	require.Equal(t, 1, sub.Skipped)
+
+	// confidence-based marking raises the stakes of sure answers
+	_, err = svc.SetMarking(qz.ID, quizzes.MarkingReq{Confidence: true})
+	require.NoError(t, err)
+	_, _, err = svc.SubmitAndScore(qz.ID, learner, quizzes.SubmitReq{Answers: []quizzes.SubmitAnswer{answer(0, 0, nil)}})
+	require.ErrorContains(t, err, "needs a confidence")
+	high, low := ptr(models.ConfidenceHigh), ptr(models.ConfidenceLow)
+	_, res, err = svc.SubmitAndScore(qz.ID, learner, quizzes.SubmitReq{Answers: []quizzes.SubmitAnswer{
+		answer(0, 0, high), answer(1, 1, high), answer(2, 0, low), answer(3, 1, low),
+	}})
+	require.NoError(t, err)
+	require.Equal(t, -2.0, res.Marks) // 3 - 6 + 1 + 0
+	require.Equal(t, 12.0, res.MaxMarks)
+	require.Equal(t, 0, res.Percent)
+	require.Equal(t, quizzes.ConfidenceTally{Answered: 2, Correct: 1, Marks: -3}, *res.Breakdown.ByConfidence[models.ConfidenceHigh])
+}
+
+func TestSubmit_SkippedAndCompleteness(t *testing.T) {
+	d := testutil.DB(t)
+	svc := quizzes.NewService(d)
+	qz, err := svc.CreateQuiz("completeness")
+	require.NoError(t, err)
+	sec, err := svc.CreateSection(qz.ID, quizzes.CreateSectionReq{Title: "Drawn", PickCount: ptr(2)})
+	require.NoError(t, err)
+	opts := []quizzes.CreateQuestionOption{{Text: "right", IsCorrect: ptr(true)}, {Text: "wrong", IsCorrect: ptr(false)}}
+	for range 3 {
+		_, err = svc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{Text: "Drawn?", Type: "single", SectionID: &sec.ID, Options: opts})
+		require.NoError(t, err)
+	}
+	loose, err := svc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{Text: "Loose?", Type: "single", Options: opts})
+	require.NoError(t, err)
+
+	pub, err := svc.GetPublicQuestions(qz.ID, learner)
+	require.NoError(t, err)
+	again, err := svc.GetPublicQuestions(qz.ID, learner)
+	require.NoError(t, err)
+	require.Equal(t, pub.Sections[0].Questions, again.Sections[0].Questions) // drawn once per attempt
+	drawn := pub.Sections[0].Questions
+	one := quizzes.SubmitAnswer{QuestionID: drawn[0].ID, SelectedOptionID: &drawn[0].Options[0].ID}
+
+	_, _, err = svc.SubmitAndScore(qz.ID, learner, quizzes.SubmitReq{Answers: []quizzes.SubmitAnswer{one, one}})
+	require.ErrorContains(t, err, "answered more than once")
+
+	// the question left out of the draw can't be answered either
+	var inSection []uint
+	require.NoError(t, d.Model(&models.Question{}).Where("section_id = ?", sec.ID).Pluck("id", &inSection).Error)
+	for _, id := range inSection {
+		if id == drawn[0].ID || id == drawn[1].ID {
+			continue
+		}
+		var opt models.Option
+		require.NoError(t, d.Where("question_id = ?", id).First(&opt).Error)
+		undrawn := quizzes.SubmitAnswer{QuestionID: id, SelectedOptionID: &opt.ID}
+		_, _, err = svc.SubmitAndScore(qz.ID, learner, quizzes.SubmitReq{Answers: []quizzes.SubmitAnswer{one, undrawn}})
+		require.ErrorContains(t, err, "not drawn")
+	}
+
+	_, err = svc.SetSubmissionPolicy(qz.ID, quizzes.SubmissionPolicyReq{RequireComplete: ptr(true)})
+	require.NoError(t, err)
+	_, _, err = svc.SubmitAndScore(qz.ID, learner, quizzes.SubmitReq{Answers: []quizzes.SubmitAnswer{one}})
+	var inc *quizzes.IncompleteError
+	require.ErrorAs(t, err, &inc)
+	require.ElementsMatch(t, []uint{drawn[1].ID, loose.ID}, inc.QuestionIDs)
+
+	// partial submissions are allowed by default; the question left out of
+	// the draw is neither skipped nor counted
+	_, err = svc.SetSubmissionPolicy(qz.ID, quizzes.SubmissionPolicyReq{RequireComplete: ptr(false)})
+	require.NoError(t, err)
+	sub, res, err := svc.SubmitAndScore(qz.ID, learner, quizzes.SubmitReq{Answers: []quizzes.SubmitAnswer{one}})
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Score)
+	require.Equal(t, 3, res.Total)
+	require.ElementsMatch(t, []uint{drawn[1].ID, loose.ID}, res.SkippedQuestionIDs)
+	require.Equal(t, 2, sub.Skipped)
+}
+
+func TestSubmit_RejectsPickAnswersWithoutDraw(t *testing.T) {
+	d := testutil.DB(t)
+	svc := quizzes.NewService(d)
+	qz, err := svc.CreateQuiz("no draw")
+	require.NoError(t, err)
+	sec, err := svc.CreateSection(qz.ID, quizzes.CreateSectionReq{Title: "Drawn", PickCount: ptr(1)})
+	require.NoError(t, err)
+	opts := []quizzes.CreateQuestionOption{{Text: "right", IsCorrect: ptr(true)}, {Text: "wrong", IsCorrect: ptr(false)}}
+	var answers []quizzes.SubmitAnswer
+	for range 2 {
+		q, err := svc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{Text: "Drawn?", Type: "single", SectionID: &sec.ID, Options: opts})
+		require.NoError(t, err)
+		var right models.Option
+		require.NoError(t, d.Where("question_id = ? AND is_correct = ?", q.ID, true).First(&right).Error)
+		answers = append(answers, quizzes.SubmitAnswer{QuestionID: q.ID, SelectedOptionID: &right.ID})
+	}
+
+	// without fetching the questions there is no draw to answer from
+	_, _, err = svc.SubmitAndScore(qz.ID, learner, quizzes.SubmitReq{Answers: answers[:1]})
+	require.ErrorContains(t, err, "not drawn")
+
+	// once the attempt is closed, resubmitting can't answer from its draw either
+	pub, err := svc.GetPublicQuestions(qz.ID, learner)
 	require.NoError(t, err)
-	require.Equal(t, 1, total)
-	require.Equal(t, 1, score)
+	drawnID := pub.Sections[0].Questions[0].ID
+	var mine quizzes.SubmitAnswer
+	for _, a := range answers {
+		if a.QuestionID == drawnID {
+			mine = a
+		}
+	}
+	_, res, err := svc.SubmitAndScore(qz.ID, learner, quizzes.SubmitReq{Answers: []quizzes.SubmitAnswer{mine}})
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Score)
+	_, _, err = svc.SubmitAndScore(qz.ID, learner, quizzes.SubmitReq{Answers: []quizzes.SubmitAnswer{mine}})
+	require.ErrorContains(t, err, "not drawn")
 }
 
+// learner is the user ID used for quiz takers in tests.
+const learner = 42
+
 func ptr[T any](v T) *T { return &v }
+
+func TestFinalizeAttempt_LosingRaceRollsBack(t *testing.T) {
+	d := testutil.DB(t)
+	svc := quizzes.NewService(d)
+	qz, err := svc.CreateQuiz("race")
+	require.NoError(t, err)
+	_, err = svc.AddQuestion(qz.ID, quizzes.CreateQuestionReq{Text: "2+2?", Type: "single",
+		Options: []quizzes.CreateQuestionOption{{Text: "4", IsCorrect: ptr(true)}, {Text: "5", IsCorrect: ptr(false)}}})
+	require.NoError(t, err)
+	pub, err := svc.GetPublicQuestions(qz.ID, learner)
+	require.NoError(t, err)
+	_, err = svc.SaveDraft(pub.AttemptID, pub.Questions[0].ID, learner,
+		quizzes.DraftAnswerReq{SelectedOptionID: &pub.Questions[0].Options[0].ID})
+	require.NoError(t, err)
+
+	// another submit closes the attempt right after this one has looked it up
+	raced := false
+	require.NoError(t, d.Callback().Query().After("gorm:query").Register("test:race", func(tx *gorm.DB) {
+		if raced || tx.Statement.Table != "attempts" || !strings.Contains(tx.Statement.SQL.String(), "submitted_at IS NULL") {
+			return
+		}
+		raced = true
+		tx.Session(&gorm.Session{NewDB: true}).Exec("UPDATE attempts SET submitted_at = ? WHERE id = ?", time.Now(), pub.AttemptID)
+	}))
+
+	_, _, err = svc.FinalizeAttempt(pub.AttemptID, learner)
+	require.ErrorIs(t, err, quizzes.ErrAttemptClosed)
+	require.True(t, raced)
+	var n int64
+	require.NoError(t, d.Model(&models.Submission{}).Count(&n).Error)
+	require.Zero(t, n, "the losing submit is rolled back")
+}
@@ -0,0 +1,18 @@
+// Package retry schedules retries for the background delivery workers.
+package retry
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff is the wait before retrying after the given failed attempt
+// (starting at 1): base doubled per attempt and capped at max, with up to
+// 10% jitter so retries from a burst don't stay in lockstep.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	d := base << (attempt - 1)
+	if d <= 0 || d > max {
+		d = max
+	}
+	return d + time.Duration(rand.Int64N(int64(d/10)+1))
+}
@@ -0,0 +1,45 @@
+package revisions
+
+import "quizapi/internal/models"
+
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// OptionChange describes one option lineage between two revisions.
+// From is nil for added options and To is nil for removed ones.
+type OptionChange struct {
+	Key  uint                   `json:"key"`
+	From *models.RevisionOption `json:"from"`
+	To   *models.RevisionOption `json:"to"`
+}
+
+type DiffResp struct {
+	QuestionID       uint           `json:"question_id"`
+	From             int            `json:"from"`
+	To               int            `json:"to"`
+	Fields           []FieldChange  `json:"fields"`
+	Options          []OptionChange `json:"options"`
+	AnswerKeyChanged bool           `json:"answer_key_changed"`
+}
+
+// RegradeReq selects the revision whose answer key is applied; the
+// question's current revision is used when Version is omitted.
+type RegradeReq struct {
+	Version *int `json:"version" validate:"omitempty,min=1"`
+}
+
+type RegradeResp struct {
+	QuestionID         uint `json:"question_id"`
+	Version            int  `json:"version"`
+	AnswersRegraded    int  `json:"answers_regraded"`
+	AnswersChanged     int  `json:"answers_changed"`
+	SubmissionsUpdated int  `json:"submissions_updated"`
+}
+
+// GradeAnswerReq is an admin's verdict on a text answer.
+type GradeAnswerReq struct {
+	Correct *bool `json:"correct" validate:"required"`
+}
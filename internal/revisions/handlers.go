@@ -0,0 +1,121 @@
+package revisions
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type Handler struct {
+	svc *Service
+	val *validator.Validate
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc, val: validator.New()}
+}
+
+func (h *Handler) List(c *gin.Context) {
+	quizID, questionID, ok := ids(c)
+	if !ok {
+		return
+	}
+	revs, err := h.svc.List(quizID, questionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, revs)
+}
+
+func (h *Handler) Diff(c *gin.Context) {
+	quizID, questionID, ok := ids(c)
+	if !ok {
+		return
+	}
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query param 'from' must be a revision number"})
+		return
+	}
+	to, err := strconv.Atoi(c.Query("to"))
+	if err != nil || to <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query param 'to' must be a revision number"})
+		return
+	}
+	d, err := h.svc.Diff(quizID, questionID, from, to)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+func (h *Handler) Regrade(c *gin.Context) {
+	quizID, questionID, ok := ids(c)
+	if !ok {
+		return
+	}
+	var req RegradeReq
+	// an empty body means "use the current revision"
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.svc.Regrade(quizID, questionID, req.Version)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) GradeAnswer(c *gin.Context) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return
+	}
+	answerID, err := strconv.Atoi(c.Param("answerID"))
+	if err != nil || answerID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid answerID"})
+		return
+	}
+	var req GradeAnswerReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	ans, err := h.svc.GradeAnswer(uint(quizID), uint(answerID), *req.Correct)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ans)
+}
+
+func ids(c *gin.Context) (uint, uint, bool) {
+	quizID, err := strconv.Atoi(c.Param("quizID"))
+	if err != nil || quizID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quizID"})
+		return 0, 0, false
+	}
+	questionID, err := strconv.Atoi(c.Param("questionID"))
+	if err != nil || questionID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid questionID"})
+		return 0, 0, false
+	}
+	return uint(quizID), uint(questionID), true
+}
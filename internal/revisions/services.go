@@ -0,0 +1,366 @@
+package revisions
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"gorm.io/gorm"
+
+	"quizapi/internal/certificates"
+	"quizapi/internal/events"
+	"quizapi/internal/leaderboard"
+	"quizapi/internal/models"
+)
+
+type Service struct {
+	db     *gorm.DB
+	events events.Publisher
+}
+
+func NewService(db *gorm.DB) *Service { return &Service{db: db, events: events.Discard} }
+
+// SetPublisher sets where grading events are sent.
+func (s *Service) SetPublisher(p events.Publisher) { s.events = p }
+
+// Record snapshots q's current content and live options as its next
+// revision and points q at it. Call it inside the transaction that
+// changed q so the snapshot and the change commit together.
+func Record(tx *gorm.DB, q *models.Question) (*models.QuestionRevision, error) {
+	var opts []models.Option
+	if err := tx.Where("question_id = ?", q.ID).Order("position, id").Find(&opts).Error; err != nil {
+		return nil, err
+	}
+	var last *int
+	if err := tx.Model(&models.QuestionRevision{}).Where("question_id = ?", q.ID).
+		Select("MAX(version)").Scan(&last).Error; err != nil {
+		return nil, err
+	}
+	version := 1
+	if last != nil {
+		version = *last + 1
+	}
+
+	rev := &models.QuestionRevision{
+		QuestionID:  q.ID,
+		Version:     version,
+		Text:        q.Text,
+		Type:        q.Type,
+		WordLimit:   q.WordLimit,
+		Explanation: q.Explanation,
+		Options:     make([]models.RevisionOption, 0, len(opts)),
+	}
+	for _, o := range opts {
+		rev.Options = append(rev.Options, models.RevisionOption{
+			ID: o.ID, Key: o.Key(), Text: o.Text, IsCorrect: o.IsCorrect,
+		})
+	}
+	if err := tx.Create(rev).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(q).Update("revision_id", rev.ID).Error; err != nil {
+		return nil, err
+	}
+	q.RevisionID = &rev.ID
+	return rev, nil
+}
+
+func (s *Service) List(quizID, questionID uint) ([]models.QuestionRevision, error) {
+	if err := s.checkQuestion(quizID, questionID); err != nil {
+		return nil, err
+	}
+	var revs []models.QuestionRevision
+	err := s.db.Where("question_id = ?", questionID).Order("version").Find(&revs).Error
+	return revs, err
+}
+
+func (s *Service) get(questionID uint, version int) (*models.QuestionRevision, error) {
+	var rev models.QuestionRevision
+	if err := s.db.Where("question_id = ? AND version = ?", questionID, version).First(&rev).Error; err != nil {
+		return nil, fmt.Errorf("revision %d of question %d not found", version, questionID)
+	}
+	return &rev, nil
+}
+
+// Diff compares two revisions field by field and option lineage by lineage.
+func (s *Service) Diff(quizID, questionID uint, from, to int) (*DiffResp, error) {
+	if err := s.checkQuestion(quizID, questionID); err != nil {
+		return nil, err
+	}
+	a, err := s.get(questionID, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.get(questionID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &DiffResp{QuestionID: questionID, From: from, To: to, Fields: []FieldChange{}, Options: []OptionChange{}}
+	if a.Text != b.Text {
+		out.Fields = append(out.Fields, FieldChange{Field: "text", From: a.Text, To: b.Text})
+	}
+	if a.Type != b.Type {
+		out.Fields = append(out.Fields, FieldChange{Field: "type", From: a.Type, To: b.Type})
+	}
+	if !equalIntPtr(a.WordLimit, b.WordLimit) {
+		out.Fields = append(out.Fields, FieldChange{Field: "word_limit", From: a.WordLimit, To: b.WordLimit})
+	}
+	if a.Explanation != b.Explanation {
+		out.Fields = append(out.Fields, FieldChange{Field: "explanation", From: a.Explanation, To: b.Explanation})
+	}
+
+	byKey := map[uint]*models.RevisionOption{}
+	for i := range b.Options {
+		byKey[b.Options[i].Key] = &b.Options[i]
+	}
+	seen := map[uint]bool{}
+	for i := range a.Options {
+		o := &a.Options[i]
+		seen[o.Key] = true
+		n := byKey[o.Key]
+		if n == nil {
+			out.Options = append(out.Options, OptionChange{Key: o.Key, From: o})
+		} else if n.Text != o.Text || n.IsCorrect != o.IsCorrect {
+			out.Options = append(out.Options, OptionChange{Key: o.Key, From: o, To: n})
+		}
+	}
+	for i := range b.Options {
+		if o := &b.Options[i]; !seen[o.Key] {
+			out.Options = append(out.Options, OptionChange{Key: o.Key, To: o})
+		}
+	}
+	out.AnswerKeyChanged = a.Type != b.Type || !slices.Equal(correctKeys(a), correctKeys(b))
+	return out, nil
+}
+
+// Regrade re-grades every stored answer to a choice question against the
+// answer key of the given revision (the current one when version is nil)
+// and refreshes the affected submissions' scores. Selected options are
+// matched by lineage, so answers given before an option was reworded still
+// count for its replacement. A question linked to the bank is refused if an
+// answer chose an option with no counterpart in the revision, since the
+// bank version that dropped it can't say what replaced it.
+func (s *Service) Regrade(quizID, questionID uint, version *int) (*RegradeResp, error) {
+	var q models.Question
+	if err := s.db.Where("id = ? AND quiz_id = ?", questionID, quizID).First(&q).Error; err != nil {
+		return nil, fmt.Errorf("question %d not found in quiz %d", questionID, quizID)
+	}
+	var rev *models.QuestionRevision
+	var err error
+	switch {
+	case version != nil:
+		rev, err = s.get(questionID, *version)
+	case q.RevisionID != nil:
+		var r models.QuestionRevision
+		err = s.db.First(&r, *q.RevisionID).Error
+		rev = &r
+	default:
+		err = errors.New("question has no revisions yet")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rev.Type == models.QText {
+		return nil, errors.New("text questions are not auto-graded")
+	}
+	key := correctKeys(rev)
+	known := make(map[uint]bool, len(rev.Options))
+	for _, o := range rev.Options {
+		known[o.Key] = true
+	}
+
+	out := &RegradeResp{QuestionID: questionID, Version: rev.Version}
+	touched := map[uint]bool{}
+	var evs []events.Event
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var answers []models.Answer
+		if err := tx.Preload("Options").Where("question_id = ?", questionID).Find(&answers).Error; err != nil {
+			return err
+		}
+		for _, ans := range answers {
+			if ans.TextAnswer != nil || len(ans.Options) == 0 {
+				continue
+			}
+			ids := make([]uint, 0, len(ans.Options))
+			for _, ao := range ans.Options {
+				ids = append(ids, ao.OptionID)
+			}
+			var opts []models.Option
+			if err := tx.Unscoped().Where("id IN ?", ids).Find(&opts).Error; err != nil {
+				return err
+			}
+			chosen := make([]uint, 0, len(opts))
+			for _, o := range opts {
+				if q.BankQuestionID != nil && !known[o.Key()] {
+					return fmt.Errorf("option %d has no counterpart in revision %d; it can't be regraded across bank versions", o.ID, rev.Version)
+				}
+				chosen = append(chosen, o.Key())
+			}
+			slices.Sort(chosen)
+			chosen = slices.Compact(chosen)
+
+			correct := slices.Equal(chosen, key)
+			out.AnswersRegraded++
+			if ans.IsCorrect == nil || *ans.IsCorrect != correct {
+				out.AnswersChanged++
+				touched[ans.SubmissionID] = true
+			}
+			if err := tx.Model(&models.Answer{}).Where("id = ?", ans.ID).Updates(map[string]any{
+				"is_correct":         correct,
+				"graded_revision_id": rev.ID,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		for subID := range touched {
+			if err := RescoreSubmission(tx, subID); err != nil {
+				return err
+			}
+			var sub models.Submission
+			if err := tx.First(&sub, subID).Error; err != nil {
+				return err
+			}
+			ev := events.Submission(events.SubmissionGraded, &sub)
+			if err := events.Record(tx, s.events, ev); err != nil {
+				return err
+			}
+			evs = append(evs, ev)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	out.SubmissionsUpdated = len(touched)
+	for _, ev := range evs {
+		s.events.Publish(ev)
+	}
+	return out, nil
+}
+
+// GradeAnswer records an admin's verdict on a text answer, which is not
+// auto-graded. From then on the answer counts towards its submission's
+// score and total. It emits answer.graded and submission.graded.
+func (s *Service) GradeAnswer(quizID, answerID uint, correct bool) (*models.Answer, error) {
+	var ans models.Answer
+	err := s.db.Joins("JOIN submissions ON submissions.id = answers.submission_id").
+		Where("answers.id = ? AND submissions.quiz_id = ?", answerID, quizID).First(&ans).Error
+	if err != nil {
+		return nil, fmt.Errorf("answer %d not found in quiz %d", answerID, quizID)
+	}
+	if ans.TextAnswer == nil {
+		return nil, errors.New("only text answers are graded by hand")
+	}
+	var evs []events.Event
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&ans).Update("is_correct", correct).Error; err != nil {
+			return err
+		}
+		if err := RescoreSubmission(tx, ans.SubmissionID); err != nil {
+			return err
+		}
+		var sub models.Submission
+		if err := tx.First(&sub, ans.SubmissionID).Error; err != nil {
+			return err
+		}
+		evs = []events.Event{
+			events.New(events.AnswerGraded, quizID, events.AnswerData{
+				AnswerID: ans.ID, SubmissionID: sub.ID, QuestionID: ans.QuestionID, UserID: sub.UserID, Correct: correct,
+			}),
+			events.Submission(events.SubmissionGraded, &sub),
+		}
+		for _, ev := range evs {
+			if err := events.Record(tx, s.events, ev); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	ans.IsCorrect = &correct
+	for _, ev := range evs {
+		s.events.Publish(ev)
+	}
+	return &ans, nil
+}
+
+// RescoreSubmission recomputes a submission's score, marks and percent
+// from its stored answer grades under the quiz's current marking rules,
+// rebuilds the submitter's leaderboard entry (a regrade can lower the best
+// submission as well as raise it), and issues or revokes its certificate
+// to match.
+func RescoreSubmission(tx *gorm.DB, submissionID uint) error {
+	var sub models.Submission
+	if err := tx.First(&sub, submissionID).Error; err != nil {
+		return err
+	}
+	var quiz models.Quiz
+	if err := tx.First(&quiz, sub.QuizID).Error; err != nil {
+		return err
+	}
+	var answers []models.Answer
+	if err := tx.Where("submission_id = ? AND is_correct IS NOT NULL", submissionID).
+		Find(&answers).Error; err != nil {
+		return err
+	}
+	score, marks := 0, 0.0
+	for _, a := range answers {
+		m := quiz.Marks(*a.IsCorrect, a.Confidence)
+		if a.Marks == nil || *a.Marks != m {
+			if err := tx.Model(&a).Update("marks", m).Error; err != nil {
+				return err
+			}
+		}
+		if *a.IsCorrect {
+			score++
+		}
+		marks += m
+	}
+	sub.Score, sub.Total, sub.Marks = score, len(answers)+sub.Skipped, marks
+	sub.MaxMarks = float64(sub.Total) * quiz.MaxMarks()
+	sub.Percent = models.Percent(sub.Marks, sub.MaxMarks)
+	if err := tx.Model(&sub).Updates(map[string]any{
+		"score": sub.Score, "total": sub.Total, "marks": sub.Marks, "max_marks": sub.MaxMarks, "percent": sub.Percent,
+	}).Error; err != nil {
+		return err
+	}
+	if err := leaderboard.Rebuild(tx, sub.QuizID, sub.UserID); err != nil {
+		return err
+	}
+	return certificates.Sync(tx, &sub)
+}
+
+// --- helpers ---
+
+func (s *Service) checkQuestion(quizID, questionID uint) error {
+	var n int64
+	if err := s.db.Model(&models.Question{}).
+		Where("id = ? AND quiz_id = ?", questionID, quizID).Count(&n).Error; err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("question %d not found in quiz %d", questionID, quizID)
+	}
+	return nil
+}
+
+func correctKeys(rev *models.QuestionRevision) []uint {
+	var keys []uint
+	for _, o := range rev.Options {
+		if o.IsCorrect {
+			keys = append(keys, o.Key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func equalIntPtr(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
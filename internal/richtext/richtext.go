@@ -0,0 +1,37 @@
+package richtext
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var (
+	md = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+	// policy starts from bluemonday's user-generated-content policy and keeps
+	// the language class on fenced code blocks so clients can highlight them.
+	policy = func() *bluemonday.Policy {
+		p := bluemonday.UGCPolicy()
+		p.AllowRelativeURLs(true)
+		p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#.-]+$`)).OnElements("code")
+		return p
+	}()
+)
+
+// Render converts Markdown source to sanitized HTML.
+// Raw HTML inside the source is stripped unless the policy allows it.
+func Render(src string) string {
+	if src == "" {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(src), &buf); err != nil {
+		// goldmark only fails on writer errors; fall back to escaped text
+		return policy.Sanitize(src)
+	}
+	return policy.Sanitize(buf.String())
+}
@@ -0,0 +1,16 @@
+package richtext_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"quizapi/internal/richtext"
+)
+
+func TestRender_SanitizesButKeepsCode(t *testing.T) {
+	out := richtext.Render("Hello <script>alert(1)</script>\n\n```go\nfmt.Println(\"hi\")\n```\n\n![d](/attachments/1)")
+	require.NotContains(t, out, "<script>")
+	require.Contains(t, out, `<code class="language-go">`)
+	require.Contains(t, out, `<img src="/attachments/1"`)
+}
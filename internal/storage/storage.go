@@ -0,0 +1,18 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when a key does not exist in the store.
+var ErrNotFound = errors.New("object not found")
+
+// Storage persists uploaded blobs (e.g. question images) by key.
+// Implementations must be safe for concurrent use.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
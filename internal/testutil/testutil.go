@@ -0,0 +1,21 @@
+// Package testutil holds helpers shared by the service tests.
+package testutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"quizapi/internal/db"
+)
+
+// DB returns an in-memory SQLite database with every model migrated.
+func DB(t testing.TB) *gorm.DB {
+	t.Helper()
+	d, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, d.AutoMigrate(db.Models()...))
+	return d
+}
@@ -0,0 +1,35 @@
+package webhooks
+
+import (
+	"time"
+
+	"quizapi/internal/models"
+)
+
+type CreateSubscriptionReq struct {
+	URL    string   `json:"url" validate:"required,url,max=2048"`
+	Events []string `json:"events" validate:"required,min=1,dive,required"`
+	// Secret is generated when omitted.
+	Secret string `json:"secret" validate:"omitempty,min=16,max=128"`
+}
+
+// SubscriptionResp includes the signing secret; it is only returned on create.
+type SubscriptionResp struct {
+	models.WebhookSubscription
+	Secret string `json:"secret,omitempty"`
+}
+
+type DeliveriesResp struct {
+	Deliveries   []models.WebhookDelivery `json:"deliveries"`
+	TotalRecords int64                    `json:"total_records"`
+	Page         int                      `json:"page"`
+	Limit        int                      `json:"limit"`
+}
+
+// Payload is the JSON body POSTed to subscribers.
+type Payload struct {
+	Type      string    `json:"type"`
+	QuizID    uint      `json:"quiz_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
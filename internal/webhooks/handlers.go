@@ -0,0 +1,102 @@
+package webhooks
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type Handler struct {
+	svc *Service
+	val *validator.Validate
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc, val: validator.New()}
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	var req CreateSubscriptionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.val.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	sub, err := h.svc.Create(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, sub)
+}
+
+func (h *Handler) List(c *gin.Context) {
+	subs, err := h.svc.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, subs)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("webhookID"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhookID"})
+		return
+	}
+	if err := h.svc.Delete(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) Deliveries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("webhookID"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhookID"})
+		return
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	ds, total, err := h.svc.Deliveries(uint(id), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, DeliveriesResp{Deliveries: ds, TotalRecords: total, Page: page, Limit: limit})
+}
+
+func (h *Handler) Redeliver(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("webhookID"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhookID"})
+		return
+	}
+	deliveryID, err := strconv.Atoi(c.Param("deliveryID"))
+	if err != nil || deliveryID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid deliveryID"})
+		return
+	}
+	d, err := h.svc.Redeliver(uint(id), uint(deliveryID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, d)
+}
@@ -0,0 +1,287 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"quizapi/internal/events"
+	"quizapi/internal/models"
+	"quizapi/internal/retry"
+)
+
+// Known event types a subscription may ask for.
+var knownEvents = []string{
+	events.SubmissionCreated, events.SubmissionGraded, events.SubmissionInvalidated, events.AnswerGraded,
+	events.QuizPublished, events.QuizScheduleChanged,
+}
+
+const (
+	maxAttempts  = 8
+	baseBackoff  = 30 * time.Second
+	maxBackoff   = 6 * time.Hour
+	claimLease   = 2 * time.Minute // how long a worker owns a claimed delivery
+	pollInterval = 2 * time.Second
+	batchSize    = 20
+)
+
+// Service stores subscriptions, queues deliveries when events are
+// published and delivers them from a background worker (see Run).
+type Service struct {
+	db     *gorm.DB
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db, client: &http.Client{Timeout: 10 * time.Second}, now: time.Now}
+}
+
+// WithClock overrides the time source (used by tests).
+func (s *Service) WithClock(now func() time.Time) *Service {
+	s.now = now
+	return s
+}
+
+func (s *Service) Create(req CreateSubscriptionReq) (*SubscriptionResp, error) {
+	evs := make([]string, 0, len(req.Events))
+	for _, e := range req.Events {
+		if e != "*" && !slices.Contains(knownEvents, e) {
+			return nil, fmt.Errorf("unknown event %q", e)
+		}
+		if !slices.Contains(evs, e) {
+			evs = append(evs, e)
+		}
+	}
+	secret := req.Secret
+	if secret == "" {
+		b := make([]byte, 24)
+		if _, err := crand.Read(b); err != nil {
+			return nil, err
+		}
+		secret = hex.EncodeToString(b)
+	}
+	sub := models.WebhookSubscription{URL: req.URL, Secret: secret, Events: strings.Join(evs, ","), Active: true}
+	if err := s.db.Create(&sub).Error; err != nil {
+		return nil, err
+	}
+	return &SubscriptionResp{WebhookSubscription: sub, Secret: secret}, nil
+}
+
+func (s *Service) List() ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	return subs, s.db.Order("id").Find(&subs).Error
+}
+
+// Delete deactivates a subscription; its delivery log is kept.
+func (s *Service) Delete(id uint) error {
+	res := s.db.Model(&models.WebhookSubscription{}).Where("id = ?", id).Update("active", false)
+	if res.Error == nil && res.RowsAffected == 0 {
+		return fmt.Errorf("webhook %d not found", id)
+	}
+	return res.Error
+}
+
+func (s *Service) Deliveries(subID uint, page, limit int) ([]models.WebhookDelivery, int64, error) {
+	q := s.db.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subID)
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []models.WebhookDelivery
+	err := q.Order("id desc").Offset((page - 1) * limit).Limit(limit).Find(&out).Error
+	return out, total, err
+}
+
+// Redeliver queues a fresh copy of a past delivery.
+func (s *Service) Redeliver(subID, deliveryID uint) (*models.WebhookDelivery, error) {
+	var old models.WebhookDelivery
+	if err := s.db.Where("id = ? AND subscription_id = ?", deliveryID, subID).First(&old).Error; err != nil {
+		return nil, fmt.Errorf("delivery %d not found for webhook %d", deliveryID, subID)
+	}
+	d := models.WebhookDelivery{
+		SubscriptionID: subID,
+		EventType:      old.EventType,
+		Payload:        old.Payload,
+		Status:         models.DeliveryPending,
+		NextAttemptAt:  s.now(),
+		RedeliveryOf:   &old.ID,
+	}
+	return &d, s.db.Create(&d).Error
+}
+
+// Record queues the event for every active subscription that wants it,
+// inside tx, the transaction that produced it: the deliveries are an
+// outbox that commits or rolls back with the change. Only rows are
+// written here; HTTP delivery happens in the worker, so the request that
+// produced the event is never held up by slow receivers.
+func (s *Service) Record(tx *gorm.DB, e events.Event) error {
+	var subs []models.WebhookSubscription
+	if err := tx.Where("active = ?", true).Find(&subs).Error; err != nil {
+		return fmt.Errorf("webhooks: load subscriptions: %w", err)
+	}
+	var body []byte
+	for _, sub := range subs {
+		if !wants(sub, e.Type) {
+			continue
+		}
+		if body == nil {
+			var err error
+			body, err = json.Marshal(Payload{Type: e.Type, QuizID: e.QuizID, CreatedAt: e.Time, Data: e.Data})
+			if err != nil {
+				return fmt.Errorf("webhooks: marshal %s: %w", e.Type, err)
+			}
+		}
+		d := models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventType:      e.Type,
+			Payload:        string(body),
+			Status:         models.DeliveryPending,
+			NextAttemptAt:  s.now(),
+		}
+		if err := tx.Create(&d).Error; err != nil {
+			return fmt.Errorf("webhooks: queue %s for %d: %w", e.Type, sub.ID, err)
+		}
+	}
+	return nil
+}
+
+// Publish does nothing: deliveries were queued by Record (see events.Record).
+func (s *Service) Publish(events.Event) {}
+
+// Run delivers due webhooks until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	t := time.NewTicker(pollInterval)
+	defer t.Stop()
+	for {
+		if _, err := s.DeliverDue(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			log.Printf("webhooks: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// DeliverDue claims and attempts one batch of due deliveries and returns
+// how many it attempted. Claiming bumps NextAttemptAt by a lease with a
+// conditional update, so several workers can share the queue.
+func (s *Service) DeliverDue(ctx context.Context) (int, error) {
+	now := s.now()
+	var due []models.WebhookDelivery
+	if err := s.db.Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+		Order("next_attempt_at").Limit(batchSize).Find(&due).Error; err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, d := range due {
+		if ctx.Err() != nil {
+			return n, ctx.Err()
+		}
+		res := s.db.Model(&models.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", d.ID, models.DeliveryPending, d.NextAttemptAt).
+			Update("next_attempt_at", now.Add(claimLease))
+		if res.Error != nil {
+			return n, res.Error
+		}
+		if res.RowsAffected == 0 {
+			continue // another worker has it
+		}
+		var sub models.WebhookSubscription
+		if err := s.db.First(&sub, d.SubscriptionID).Error; err != nil {
+			return n, err
+		}
+		s.attempt(ctx, sub, d)
+		n++
+	}
+	return n, nil
+}
+
+func (s *Service) attempt(ctx context.Context, sub models.WebhookSubscription, d models.WebhookDelivery) {
+	status, err := s.post(ctx, sub, d)
+	now := s.now()
+	upd := map[string]any{"attempts": d.Attempts + 1, "response_status": status}
+	switch {
+	case err == nil:
+		upd["status"] = models.DeliverySucceeded
+		upd["delivered_at"] = now
+		upd["last_error"] = ""
+	case !sub.Active || d.Attempts+1 >= maxAttempts:
+		upd["status"] = models.DeliveryFailed
+		upd["last_error"] = err.Error()
+	default:
+		upd["next_attempt_at"] = now.Add(retry.Backoff(d.Attempts+1, baseBackoff, maxBackoff))
+		upd["last_error"] = err.Error()
+	}
+	if uerr := s.db.Model(&models.WebhookDelivery{}).Where("id = ?", d.ID).Updates(upd).Error; uerr != nil {
+		log.Printf("webhooks: update delivery %d: %v", d.ID, uerr)
+	}
+}
+
+// post sends the payload and returns the HTTP status, if any.
+func (s *Service) post(ctx context.Context, sub models.WebhookSubscription, d models.WebhookDelivery) (*int, error) {
+	if !sub.Active {
+		return nil, errors.New("subscription is inactive")
+	}
+	ts := strconv.FormatInt(s.now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader([]byte(d.Payload)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "quizapi-webhooks/1")
+	req.Header.Set("X-Webhook-Event", d.EventType)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(d.ID), 10))
+	req.Header.Set("X-Webhook-Timestamp", ts)
+	req.Header.Set("X-Webhook-Signature", "sha256="+Sign(sub.Secret, ts, []byte(d.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &resp.StatusCode, fmt.Errorf("receiver responded %d", resp.StatusCode)
+	}
+	return &resp.StatusCode, nil
+}
+
+// Sign computes the hex HMAC-SHA256 of "timestamp.body" with the
+// subscription secret. Receivers should recompute it and compare in
+// constant time, and reject stale timestamps to prevent replays.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// --- helpers ---
+
+func wants(sub models.WebhookSubscription, typ string) bool {
+	for _, e := range strings.Split(sub.Events, ",") {
+		if e == "*" || e == typ {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package webhooks_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"quizapi/internal/events"
+	"quizapi/internal/models"
+	"quizapi/internal/testutil"
+	"quizapi/internal/webhooks"
+)
+
+func TestDeliver_SignedAndRetriedAfterFailure(t *testing.T) {
+	const secret = "0123456789abcdef0123"
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		want := "sha256=" + webhooks.Sign(secret, r.Header.Get("X-Webhook-Timestamp"), body)
+		if r.Header.Get("X-Webhook-Signature") != want {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	d := testutil.DB(t)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	svc := webhooks.NewService(d).WithClock(func() time.Time { return now })
+
+	_, err := svc.Create(webhooks.CreateSubscriptionReq{URL: srv.URL, Events: []string{events.SubmissionCreated}, Secret: secret})
+	require.NoError(t, err)
+	_, err = svc.Create(webhooks.CreateSubscriptionReq{URL: "http://example.invalid", Events: []string{"quiz.nope"}})
+	require.Error(t, err)
+
+	require.NoError(t, svc.Record(d, events.New(events.SubmissionCreated, 1, events.SubmissionData{SubmissionID: 9})))
+	require.NoError(t, svc.Record(d, events.New(events.QuizPublished, 1, nil))) // not subscribed
+
+	n, err := svc.DeliverDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	var del models.WebhookDelivery
+	require.NoError(t, d.First(&del).Error)
+	require.Equal(t, models.DeliveryPending, del.Status)
+	require.Equal(t, 1, del.Attempts)
+	require.Equal(t, http.StatusInternalServerError, *del.ResponseStatus)
+	require.True(t, del.NextAttemptAt.After(now))
+
+	// not due yet
+	n, err = svc.DeliverDue(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	now = now.Add(time.Hour)
+	n, err = svc.DeliverDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	var done models.WebhookDelivery
+	require.NoError(t, d.First(&done, del.ID).Error)
+	require.Equal(t, models.DeliverySucceeded, done.Status)
+	require.Equal(t, 2, done.Attempts)
+	require.NotNil(t, done.DeliveredAt)
+}
+
+func TestRecord_CommitsWithProducer(t *testing.T) {
+	d := testutil.DB(t)
+	svc := webhooks.NewService(d)
+	_, err := svc.Create(webhooks.CreateSubscriptionReq{URL: "http://example.invalid", Events: []string{"*"}})
+	require.NoError(t, err)
+	pub := events.Multi{events.Discard, svc}
+	ev := events.New(events.SubmissionCreated, 1, events.SubmissionData{SubmissionID: 9})
+
+	// a producer that rolls back leaves nothing to deliver
+	err = d.Transaction(func(tx *gorm.DB) error {
+		if err := events.Record(tx, pub, ev); err != nil {
+			return err
+		}
+		return errors.New("submit failed")
+	})
+	require.Error(t, err)
+	var n int64
+	require.NoError(t, d.Model(&models.WebhookDelivery{}).Count(&n).Error)
+	require.Zero(t, n)
+
+	require.NoError(t, d.Transaction(func(tx *gorm.DB) error { return events.Record(tx, pub, ev) }))
+	pub.Publish(ev) // recorders ignore the post-commit publish
+	require.NoError(t, d.Model(&models.WebhookDelivery{}).Count(&n).Error)
+	require.EqualValues(t, 1, n)
+}